@@ -0,0 +1,51 @@
+package downloader
+
+import (
+	"fmt"
+
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/save"
+)
+
+func (d *Downloader) saveChunk(pos level.ChunkPos) error {
+	chunk, ok := d.w.Columns[pos]
+	if !ok {
+		return nil
+	}
+	dimType := d.c.Registries.DimensionType.GetByID(d.p.DimensionType)
+	if dimType == nil {
+		return fmt.Errorf("dimension type %d not found", d.p.DimensionType)
+	}
+
+	dst := save.Chunk{
+		DataVersion: save.DataVersion,
+		XPos:        pos[0],
+		YPos:        dimType.MinY >> 4,
+		ZPos:        pos[1],
+	}
+	if err := level.ChunkToSave(chunk, &dst); err != nil {
+		return err
+	}
+	// Chunks received from the server are always fully generated.
+	dst.Status = "minecraft:" + string(level.StatusFull)
+	// Only these two heightmaps are sent by the server,
+	// the others are recalculated by the game when the chunk is loaded.
+	for k := range dst.Heightmaps {
+		if k != "MOTION_BLOCKING" && k != "WORLD_SURFACE" {
+			delete(dst.Heightmaps, k)
+		}
+	}
+
+	// The light data is not kept by world.World, let the game recalculate it.
+	dst.IsLightOn = 0
+
+	data, err := dst.Data(d.Compression)
+	if err != nil {
+		return err
+	}
+	r, x, z, err := d.region("region", pos)
+	if err != nil {
+		return err
+	}
+	return r.WriteSector(x, z, data)
+}
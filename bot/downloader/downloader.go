@@ -0,0 +1,245 @@
+// Package downloader saves the world received by a bot into a Minecraft save directory.
+//
+// The [Downloader] works with [world.World]. Every chunk the bot receives is written into
+// the region files of the dimension it belongs to. Block updates make the chunk to be saved again,
+// so the saved world keeps updated while the bot is playing.
+// The entities seen by the bot are saved into the entities region files,
+// and a level.dat is generated so that the directory can be opened by the vanilla game.
+//
+// The Downloader is not safe for concurrent use.
+// It should be used in the goroutine running [bot.Client.HandleGame], or after HandleGame returned.
+// Call [Downloader.Close] at the end to write everything remaining into the disk.
+package downloader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/bot/basic"
+	"github.com/Tnze/go-mc/bot/world"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/save/region"
+)
+
+type Downloader struct {
+	c   *bot.Client
+	p   *basic.Player
+	w   *world.World
+	dir string
+
+	// FlushInterval is the minimal interval between two automatic flushes.
+	// Modified chunks are kept in memory until the next flush.
+	// If zero, modified chunks are saved immediately.
+	FlushInterval time.Duration
+	lastFlush     time.Time
+
+	// Compression is the compression type used for writing the chunks. Default is zlib.
+	Compression byte
+
+	// dimension is the dimension that the dirty chunks belong to
+	dimension string
+	dirty     map[level.ChunkPos]struct{}
+	regions   map[regionKey]*region.Region
+
+	entities          map[int32]*entity
+	dirtyEntities     map[level.ChunkPos]struct{}
+	spawnPos          pk.Position
+	spawnAngle        float32
+	dayTime, gameTime int64
+}
+
+type regionKey struct {
+	dir    string // directory of region files, e.g. "DIM-1/region"
+	rx, rz int
+}
+
+// New creates a Downloader saving the world into the dir.
+// The dir is created if it doesn't exist.
+func New(c *bot.Client, p *basic.Player, w *world.World, dir string) (*Downloader, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d := &Downloader{
+		c: c, p: p, w: w, dir: dir,
+		Compression:   2,
+		dirty:         make(map[level.ChunkPos]struct{}),
+		regions:       make(map[regionKey]*region.Region),
+		entities:      make(map[int32]*entity),
+		dirtyEntities: make(map[level.ChunkPos]struct{}),
+	}
+	c.Events.AddListener(
+		// Save all chunks before the world forgets them.
+		// The priorities are higher than the world.World's handlers.
+		bot.PacketHandler{Priority: 96, ID: packetid.ClientboundLogin, F: d.onPlayerSpawn},
+		bot.PacketHandler{Priority: 96, ID: packetid.ClientboundRespawn, F: d.onPlayerSpawn},
+		bot.PacketHandler{Priority: 96, ID: packetid.ClientboundForgetLevelChunk, F: d.onForgetLevelChunk},
+		// Mark chunks dirty after the world.World updated them.
+		bot.PacketHandler{Priority: -64, ID: packetid.ClientboundLevelChunkWithLight, F: d.onLevelChunkWithLight},
+		bot.PacketHandler{Priority: -64, ID: packetid.ClientboundBlockUpdate, F: d.onBlockUpdate},
+		bot.PacketHandler{Priority: -64, ID: packetid.ClientboundSectionBlocksUpdate, F: d.onSectionBlocksUpdate},
+		bot.PacketHandler{Priority: -64, ID: packetid.ClientboundBlockEntityData, F: d.onBlockUpdate},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundSetDefaultSpawnPosition, F: d.onSetDefaultSpawnPosition},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundSetTime, F: d.onSetTime},
+	)
+	d.attachEntityHandlers()
+	return d, nil
+}
+
+func (d *Downloader) onPlayerSpawn(pk.Packet) error {
+	// The basic.Player hasn't updated the dimension yet,
+	// so the chunks are saved into the previous dimension.
+	if err := d.Flush(); err != nil {
+		return err
+	}
+	d.entities = make(map[int32]*entity)
+	return nil
+}
+
+func (d *Downloader) onLevelChunkWithLight(p pk.Packet) error {
+	var pos level.ChunkPos
+	if err := p.Scan(&pos); err != nil {
+		return err
+	}
+	return d.markDirty(pos)
+}
+
+func (d *Downloader) onForgetLevelChunk(p pk.Packet) error {
+	pos, err := world.ScanForgetLevelChunk(p)
+	if err != nil {
+		return err
+	}
+	if _, ok := d.dirty[pos]; ok {
+		if err := d.saveChunk(pos); err != nil {
+			return err
+		}
+		delete(d.dirty, pos)
+	}
+	if _, ok := d.dirtyEntities[pos]; ok {
+		if err := d.saveEntities(pos); err != nil {
+			return err
+		}
+		delete(d.dirtyEntities, pos)
+	}
+	d.dropEntities(pos)
+	return nil
+}
+
+func (d *Downloader) onBlockUpdate(p pk.Packet) error {
+	var pos pk.Position
+	if err := p.Scan(&pos); err != nil {
+		return err
+	}
+	return d.markDirty(level.ChunkPos{int32(pos.X >> 4), int32(pos.Z >> 4)})
+}
+
+func (d *Downloader) onSectionBlocksUpdate(p pk.Packet) error {
+	var sectionPos pk.Long
+	if err := p.Scan(&sectionPos); err != nil {
+		return err
+	}
+	return d.markDirty(level.ChunkPos{int32(sectionPos >> 42), int32(sectionPos << 22 >> 42)})
+}
+
+func (d *Downloader) onSetDefaultSpawnPosition(p pk.Packet) error {
+	return p.Scan(&d.spawnPos, (*pk.Float)(&d.spawnAngle))
+}
+
+func (d *Downloader) onSetTime(p pk.Packet) error {
+	return p.Scan((*pk.Long)(&d.gameTime), (*pk.Long)(&d.dayTime))
+}
+
+func (d *Downloader) markDirty(pos level.ChunkPos) error {
+	if _, ok := d.w.Columns[pos]; !ok {
+		return nil
+	}
+	d.dimension = d.p.DimensionName
+	d.dirty[pos] = struct{}{}
+	if d.FlushInterval == 0 || time.Since(d.lastFlush) >= d.FlushInterval {
+		return d.Flush()
+	}
+	return nil
+}
+
+// Flush saves all modified chunks and entities into the region files.
+func (d *Downloader) Flush() error {
+	d.lastFlush = time.Now()
+	for pos := range d.dirty {
+		if err := d.saveChunk(pos); err != nil {
+			return err
+		}
+		delete(d.dirty, pos)
+	}
+	for pos := range d.dirtyEntities {
+		if err := d.saveEntities(pos); err != nil {
+			return err
+		}
+		delete(d.dirtyEntities, pos)
+	}
+	return nil
+}
+
+// Close flushes the downloader, writes the level.dat and closes all region files.
+func (d *Downloader) Close() error {
+	err := d.Flush()
+	if err == nil {
+		err = d.writeLevel()
+	}
+	for k, r := range d.regions {
+		err = errors.Join(err, r.PadToFullSector(), r.Close())
+		delete(d.regions, k)
+	}
+	return err
+}
+
+// dimensionDir returns the directory of the dimension, relative to the world directory.
+func dimensionDir(name string) string {
+	switch name {
+	case "minecraft:overworld", "":
+		return "."
+	case "minecraft:the_nether":
+		return "DIM-1"
+	case "minecraft:the_end":
+		return "DIM1"
+	default:
+		ns, path, ok := strings.Cut(name, ":")
+		if !ok {
+			ns, path = "minecraft", name
+		}
+		return filepath.Join("dimensions", ns, filepath.FromSlash(path))
+	}
+}
+
+// region returns the region file containing the chunk, create it if not exist.
+func (d *Downloader) region(kind string, pos level.ChunkPos) (*region.Region, int, int, error) {
+	rx, rz := region.At(int(pos[0]), int(pos[1]))
+	key := regionKey{
+		dir: filepath.Join(dimensionDir(d.dimension), kind),
+		rx:  rx, rz: rz,
+	}
+	x, z := region.In(int(pos[0]), int(pos[1]))
+	if r, ok := d.regions[key]; ok {
+		return r, x, z, nil
+	}
+
+	dir := filepath.Join(d.dir, key.dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, 0, 0, err
+	}
+	name := filepath.Join(dir, "r."+strconv.Itoa(rx)+"."+strconv.Itoa(rz)+".mca")
+	r, err := region.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		r, err = region.Create(name)
+	}
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	d.regions[key] = r
+	return r, x, z, nil
+}
@@ -0,0 +1,72 @@
+package downloader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/server"
+)
+
+type testClient chan pk.Packet
+
+func (c testClient) WritePacket(p pk.Packet) error {
+	c <- p
+	return nil
+}
+
+func (c testClient) wait(t *testing.T, id packetid.ClientboundPacketID) pk.Packet {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case p := <-c:
+			if p.ID == int32(id) {
+				return p
+			}
+		case <-timeout:
+			t.Fatalf("packet %v not received", id)
+		}
+	}
+}
+
+type testProvider struct{}
+
+func (testProvider) Chunk(level.ChunkPos) (*level.Chunk, error) {
+	return level.EmptyChunk(1), nil
+}
+
+func TestDownloader_onForgetLevelChunk(t *testing.T) {
+	pos := level.ChunkPos{1, -2}
+	s := server.NewChunkStream(testProvider{})
+	c := make(testClient, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	s.ClientJoin(c, pos, 0)
+	c.wait(t, packetid.ClientboundLevelChunkWithLight)
+	s.SetCenter(c, level.ChunkPos{10, 10})
+	p := c.wait(t, packetid.ClientboundForgetLevelChunk)
+
+	d := &Downloader{
+		dirty:         make(map[level.ChunkPos]struct{}),
+		dirtyEntities: make(map[level.ChunkPos]struct{}),
+		entities: map[int32]*entity{
+			1: {Pos: [3]float64{24, 64, -24}}, // in chunk (1, -2)
+			2: {Pos: [3]float64{-24, 64, 24}}, // in chunk (-2, 1)
+		},
+	}
+	if err := d.onForgetLevelChunk(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := d.entities[1]; ok {
+		t.Errorf("the entity in the forgotten chunk %v is kept", pos)
+	}
+	if _, ok := d.entities[2]; !ok {
+		t.Error("the entity in another chunk is dropped")
+	}
+}
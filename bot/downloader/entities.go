@@ -0,0 +1,226 @@
+package downloader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"math"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/data/registryid"
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/nbt"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/save"
+)
+
+// entity is the entity tracked by the downloader.
+// Only the data sent by AddEntity and movement packets are known,
+// the other fields are left default and the game fills them when loading.
+type entity struct {
+	ID       string     `nbt:"id"`
+	UUID     [4]int32   `nbt:"UUID"`
+	Pos      [3]float64 `nbt:"Pos"`
+	Motion   [3]float64 `nbt:"Motion"`
+	Rotation [2]float32 `nbt:"Rotation"` // yaw, pitch
+	OnGround bool       `nbt:"OnGround"`
+}
+
+func (e *entity) chunkPos() level.ChunkPos {
+	return level.ChunkPos{int32(math.Floor(e.Pos[0])) >> 4, int32(math.Floor(e.Pos[2])) >> 4}
+}
+
+// entityChunk is the format of the entities region files since 1.17.
+type entityChunk struct {
+	DataVersion int32
+	Position    [2]int32
+	Entities    []*entity
+}
+
+func (d *Downloader) attachEntityHandlers() {
+	d.c.Events.AddListener(
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundAddEntity, F: d.onAddEntity},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundRemoveEntities, F: d.onRemoveEntities},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundTeleportEntity, F: d.onTeleportEntity},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundMoveEntityPos, F: d.onMoveEntity},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundMoveEntityPosRot, F: d.onMoveEntity},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundMoveEntityRot, F: d.onMoveEntity},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundSetEntityMotion, F: d.onSetEntityMotion},
+	)
+}
+
+func (d *Downloader) onAddEntity(p pk.Packet) error {
+	var (
+		eid            pk.VarInt
+		id             pk.UUID
+		typ            pk.VarInt
+		x, y, z        pk.Double
+		pitch, yaw, hy pk.Angle
+		data           pk.VarInt
+		vx, vy, vz     pk.Short
+	)
+	err := p.Scan(&eid, &id, &typ, &x, &y, &z, &pitch, &yaw, &hy, &data, &vx, &vy, &vz)
+	if err != nil {
+		return err
+	}
+	if typ < 0 || int(typ) >= len(registryid.EntityType) {
+		return nil
+	}
+	name := registryid.EntityType[typ]
+	if name == "minecraft:player" {
+		// players are not stored in the entity chunks
+		return nil
+	}
+	e := &entity{
+		ID:       name,
+		UUID:     uuidToInts(uuid.UUID(id)),
+		Pos:      [3]float64{float64(x), float64(y), float64(z)},
+		Motion:   [3]float64{float64(vx) / 8000, float64(vy) / 8000, float64(vz) / 8000},
+		Rotation: [2]float32{float32(yaw.ToDeg()), float32(pitch.ToDeg())},
+	}
+	d.entities[int32(eid)] = e
+	d.markEntitiesDirty(e.chunkPos())
+	return nil
+}
+
+func (d *Downloader) onRemoveEntities(p pk.Packet) error {
+	var eids []pk.VarInt
+	if err := p.Scan(pk.Array(&eids)); err != nil {
+		return err
+	}
+	// Only the listed entities are removed.
+	// The others in the same chunk are kept, and saved again with the chunk.
+	for _, eid := range eids {
+		if e, ok := d.entities[int32(eid)]; ok {
+			if _, ok := d.w.Columns[e.chunkPos()]; ok {
+				d.markEntitiesDirty(e.chunkPos())
+			}
+			delete(d.entities, int32(eid))
+		}
+	}
+	return nil
+}
+
+// dropEntities forgets the entities in the unloaded chunk, which are saved before.
+// The server removes them later, or sends them again when the chunk is loaded again.
+func (d *Downloader) dropEntities(pos level.ChunkPos) {
+	for eid, e := range d.entities {
+		if e.chunkPos() == pos {
+			delete(d.entities, eid)
+		}
+	}
+}
+
+func (d *Downloader) onTeleportEntity(p pk.Packet) error {
+	var (
+		eid        pk.VarInt
+		x, y, z    pk.Double
+		yaw, pitch pk.Angle
+		onGround   pk.Boolean
+	)
+	if err := p.Scan(&eid, &x, &y, &z, &yaw, &pitch, &onGround); err != nil {
+		return err
+	}
+	e, ok := d.entities[int32(eid)]
+	if !ok {
+		return nil
+	}
+	d.markEntitiesDirty(e.chunkPos())
+	e.Pos = [3]float64{float64(x), float64(y), float64(z)}
+	e.Rotation = [2]float32{float32(yaw.ToDeg()), float32(pitch.ToDeg())}
+	e.OnGround = bool(onGround)
+	d.markEntitiesDirty(e.chunkPos())
+	return nil
+}
+
+func (d *Downloader) onMoveEntity(p pk.Packet) error {
+	var (
+		eid        pk.VarInt
+		dx, dy, dz pk.Short
+		yaw, pitch pk.Angle
+		onGround   pk.Boolean
+		err        error
+	)
+	switch packetid.ClientboundPacketID(p.ID) {
+	case packetid.ClientboundMoveEntityPos:
+		err = p.Scan(&eid, &dx, &dy, &dz, &onGround)
+	case packetid.ClientboundMoveEntityPosRot:
+		err = p.Scan(&eid, &dx, &dy, &dz, &yaw, &pitch, &onGround)
+	case packetid.ClientboundMoveEntityRot:
+		err = p.Scan(&eid, &yaw, &pitch, &onGround)
+	}
+	if err != nil {
+		return err
+	}
+	e, ok := d.entities[int32(eid)]
+	if !ok {
+		return nil
+	}
+	d.markEntitiesDirty(e.chunkPos())
+	e.Pos[0] += float64(dx) / 4096
+	e.Pos[1] += float64(dy) / 4096
+	e.Pos[2] += float64(dz) / 4096
+	if packetid.ClientboundPacketID(p.ID) != packetid.ClientboundMoveEntityPos {
+		e.Rotation = [2]float32{float32(yaw.ToDeg()), float32(pitch.ToDeg())}
+	}
+	e.OnGround = bool(onGround)
+	d.markEntitiesDirty(e.chunkPos())
+	return nil
+}
+
+func (d *Downloader) onSetEntityMotion(p pk.Packet) error {
+	var (
+		eid        pk.VarInt
+		vx, vy, vz pk.Short
+	)
+	if err := p.Scan(&eid, &vx, &vy, &vz); err != nil {
+		return err
+	}
+	if e, ok := d.entities[int32(eid)]; ok {
+		e.Motion = [3]float64{float64(vx) / 8000, float64(vy) / 8000, float64(vz) / 8000}
+	}
+	return nil
+}
+
+func (d *Downloader) markEntitiesDirty(pos level.ChunkPos) {
+	d.dimension = d.p.DimensionName
+	d.dirtyEntities[pos] = struct{}{}
+}
+
+func (d *Downloader) saveEntities(pos level.ChunkPos) error {
+	c := entityChunk{
+		DataVersion: save.DataVersion,
+		Position:    pos,
+		Entities:    []*entity{},
+	}
+	for _, e := range d.entities {
+		if e.chunkPos() == pos {
+			c.Entities = append(c.Entities, e)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(2) // zlib
+	w := zlib.NewWriter(&buf)
+	if err := nbt.NewEncoder(w).Encode(c, ""); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	r, x, z, err := d.region("entities", pos)
+	if err != nil {
+		return err
+	}
+	return r.WriteSector(x, z, buf.Bytes())
+}
+
+func uuidToInts(id uuid.UUID) (ints [4]int32) {
+	for i := range ints {
+		ints[i] = int32(id[i*4])<<24 | int32(id[i*4+1])<<16 | int32(id[i*4+2])<<8 | int32(id[i*4+3])
+	}
+	return
+}
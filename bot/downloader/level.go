@@ -0,0 +1,54 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Tnze/go-mc/save"
+)
+
+// writeLevel generates the level.dat of the downloaded world.
+// The world generation settings are the vanilla defaults,
+// because the seed and the generators are never sent to the clients.
+func (d *Downloader) writeLevel() error {
+	var level save.Level
+	data := &level.Data
+	data.DataVersion = save.DataVersion
+	data.Version.ID = save.DataVersion
+	data.Version.Name = save.VersionName
+	data.Version.Series = "main"
+	data.LevelName = filepath.Base(d.dir)
+	data.SpawnX, data.SpawnY, data.SpawnZ = int32(d.spawnPos.X), int32(d.spawnPos.Y), int32(d.spawnPos.Z)
+	data.SpawnAngle = d.spawnAngle
+	data.GameType = int32(d.p.Gamemode)
+	data.DayTime, data.Time = d.dayTime, d.gameTime
+	data.DataPacks.Enabled = []string{"vanilla"}
+	data.DataPacks.Disabled = []string{}
+	data.GameRules = map[string]string{
+		// Keep the world as it was downloaded.
+		"doDaylightCycle": "false",
+		"doMobSpawning":   "false",
+		"doWeatherCycle":  "false",
+	}
+	data.WorldGenSettings.Dimensions = save.DefaultDimensionsGenerators
+	data.BorderSize = 59999968
+	data.BorderSizeLerpTarget = 59999968
+	data.BorderSafeZone = 5
+	data.BorderDamagePerBlock = 0.2
+	data.BorderWarningBlocks = 5
+	data.BorderWarningTime = 15
+	data.Initialized = true
+	data.StorageVersion = 19133
+	data.LastPlayed = time.Now().UnixMilli()
+
+	f, err := os.Create(filepath.Join(d.dir, "level.dat"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := save.WriteLevel(f, level); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/Tnze/go-mc/net"
 	"github.com/Tnze/go-mc/net/CFB8"
 	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/yggdrasil"
 )
 
 type LoginErr struct {
@@ -151,6 +152,17 @@ type Auth struct {
 	Name string
 	UUID string
 	AsTk string
+
+	// Endpoints is the Yggdrasil implementation that issued the AsTk.
+	// If nil, yggdrasil.Mojang is used.
+	Endpoints *yggdrasil.Endpoints
+}
+
+func (a *Auth) endpoints() *yggdrasil.Endpoints {
+	if a.Endpoints == nil {
+		return yggdrasil.Mojang
+	}
+	return a.Endpoints
 }
 
 func handleEncryptionRequest(conn *net.Conn, c *Client, p pk.Packet) error {
@@ -265,7 +277,7 @@ func loginAuth(auth Auth, shareSecret []byte, er encryptionRequest) error {
 		return fmt.Errorf("create request packet to yggdrasil faile: %v", err)
 	}
 
-	PostRequest, err := http.NewRequest(http.MethodPost, "https://sessionserver.mojang.com/session/minecraft/join",
+	PostRequest, err := http.NewRequest(http.MethodPost, auth.endpoints().SessionServer+"/session/minecraft/join",
 		bytes.NewReader(requestPacket))
 	if err != nil {
 		return fmt.Errorf("make request error: %v", err)
@@ -0,0 +1,180 @@
+// Package maps tracks the content of the map items received by the client.
+//
+// The server sends the colors of a map in patches, and the icons (decorations) drawn on it.
+// The [Manager] keeps the latest state of every map ID, which can be rendered by [Map.Image].
+// The maps saved in worlds (data/map_<id>.dat) can be read by [save.ReadMapData]
+// and rendered with the [mapcolor] package.
+package maps
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level/mapcolor"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// Manager keeps the state of all maps.
+type Manager struct {
+	events EventsListener
+	Maps   map[int32]*Map
+}
+
+// EventsListener is the handlers of map events.
+// The handlers are called after the [Manager] state is updated.
+type EventsListener struct {
+	// MapUpdate is called when the content or the icons of a map is changed.
+	MapUpdate func(m *Map) error
+}
+
+// New creates a new map manager and attaches it to the client.
+func New(c *bot.Client, events EventsListener) *Manager {
+	m := &Manager{events: events, Maps: make(map[int32]*Map)}
+	c.Events.AddListener(
+		bot.PacketHandler{Priority: 64, ID: packetid.ClientboundLogin, F: m.onLogin},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundMapItemData, F: m.onMapItemData},
+	)
+	return m
+}
+
+// Map is the state of a map.
+type Map struct {
+	ID int32
+	// Scale is the zoom level of the map, from 0 (1 block per pixel) to 4 (16×16 blocks per pixel).
+	Scale  int8
+	Locked bool
+	Icons  []Icon
+	// Colors are the 128×128 colors of the map, indexed by x + z*128.
+	Colors [mapcolor.Size * mapcolor.Size]byte
+}
+
+// Image renders the colors of the map.
+func (m *Map) Image() *image.Paletted {
+	return mapcolor.Render(m.Colors[:])
+}
+
+// Icon is a decoration drawn on the map.
+type Icon struct {
+	Type IconType
+	// X and Z are the position of the icon, from -128 to 127.
+	// Divide by 2 and add 64 to get the pixel coordinates of the map.
+	X, Z int8
+	// Direction is the rotation of the icon, from 0 to 15, in 1/16 turns clockwise.
+	Direction   int8
+	DisplayName *chat.Message // Optional
+}
+
+func (i *Icon) ReadFrom(r io.Reader) (int64, error) {
+	var displayName pk.Option[chat.Message, *chat.Message]
+	n, err := pk.Tuple{
+		(*pk.VarInt)(&i.Type),
+		(*pk.Byte)(&i.X),
+		(*pk.Byte)(&i.Z),
+		(*pk.Byte)(&i.Direction),
+		&displayName,
+	}.ReadFrom(r)
+	i.DisplayName = displayName.Pointer()
+	return n, err
+}
+
+// IconType is the ID in the minecraft:map_decoration_type registry.
+type IconType int32
+
+// Name returns the resource location of the icon type.
+func (t IconType) Name() string {
+	if t < 0 || int(t) >= len(iconTypeNames) {
+		return ""
+	}
+	return "minecraft:" + iconTypeNames[t]
+}
+
+var iconTypeNames = [...]string{
+	"player", "frame", "red_marker", "blue_marker", "target_x", "target_point",
+	"player_off_map", "player_off_limits", "mansion", "monument",
+	"banner_white", "banner_orange", "banner_magenta", "banner_light_blue",
+	"banner_yellow", "banner_lime", "banner_pink", "banner_gray",
+	"banner_light_gray", "banner_cyan", "banner_purple", "banner_blue",
+	"banner_brown", "banner_green", "banner_red", "banner_black",
+	"red_x", "village_desert", "village_plains", "village_savanna",
+	"village_snowy", "village_taiga", "jungle_temple", "swamp_hut", "trial_chambers",
+}
+
+func (m *Manager) onLogin(pk.Packet) error {
+	// Map IDs are only valid in the server that sent them.
+	m.Maps = make(map[int32]*Map)
+	return nil
+}
+
+func (m *Manager) onMapItemData(p pk.Packet) error {
+	var (
+		id       pk.VarInt
+		scale    pk.Byte
+		locked   pk.Boolean
+		hasIcons pk.Boolean
+		icons    []Icon
+		columns  pk.UnsignedByte
+	)
+	r := bytes.NewReader(p.Data)
+	_, err := pk.Tuple{
+		&id, &scale, &locked,
+		pk.Opt{Has: &hasIcons, Field: pk.Array(&icons)},
+		&columns,
+	}.ReadFrom(r)
+	if err != nil {
+		return Error{err}
+	}
+
+	mp, ok := m.Maps[int32(id)]
+	if !ok {
+		mp = &Map{ID: int32(id)}
+		m.Maps[int32(id)] = mp
+	}
+	mp.Scale = int8(scale)
+	mp.Locked = bool(locked)
+	if hasIcons {
+		mp.Icons = icons
+	}
+
+	if columns > 0 {
+		var (
+			rows, x, z pk.UnsignedByte
+			data       pk.ByteArray
+		)
+		if _, err := (pk.Tuple{&rows, &x, &z, &data}).ReadFrom(r); err != nil {
+			return Error{err}
+		}
+		if int(x)+int(columns) > mapcolor.Size || int(z)+int(rows) > mapcolor.Size ||
+			len(data) < int(columns)*int(rows) {
+			return Error{errors.New("map color patch out of bounds")}
+		}
+		for i := 0; i < int(columns); i++ {
+			for j := 0; j < int(rows); j++ {
+				mp.Colors[int(x)+i+(int(z)+j)*mapcolor.Size] = data[i+j*int(columns)]
+			}
+		}
+	}
+
+	if m.events.MapUpdate != nil {
+		if err := m.events.MapUpdate(mp); err != nil {
+			return Error{err}
+		}
+	}
+	return nil
+}
+
+type Error struct {
+	Err error
+}
+
+func (e Error) Error() string {
+	return "bot/maps: " + e.Err.Error()
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
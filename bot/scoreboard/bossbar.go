@@ -0,0 +1,121 @@
+package scoreboard
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/chat"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+type BossBar struct {
+	ID       uuid.UUID
+	Title    chat.Message
+	Health   float32 // From 0 to 1.
+	Color    BossBarColor
+	Division BossBarDivision
+	Flags    byte
+}
+
+type BossBarColor int32
+
+const (
+	BossBarPink BossBarColor = iota
+	BossBarBlue
+	BossBarRed
+	BossBarGreen
+	BossBarYellow
+	BossBarPurple
+	BossBarWhite
+)
+
+// BossBarDivision is the number of notches shown on the boss bar.
+type BossBarDivision int32
+
+const (
+	BossBarProgress BossBarDivision = iota
+	BossBarNotched6
+	BossBarNotched10
+	BossBarNotched12
+	BossBarNotched20
+)
+
+// Used by BossBar.Flags.
+const (
+	DarkenScreen = 1 << iota
+	PlayBossMusic
+	CreateWorldFog
+)
+
+func (m *Manager) onBossEvent(p pk.Packet) error {
+	const (
+		OperationAdd = iota
+		OperationRemove
+		OperationUpdateProgress
+		OperationUpdateName
+		OperationUpdateStyle
+		OperationUpdateProperties
+	)
+	r := bytes.NewReader(p.Data)
+	var (
+		id        pk.UUID
+		operation pk.VarInt
+	)
+	if _, err := (pk.Tuple{&id, &operation}).ReadFrom(r); err != nil {
+		return Error{err}
+	}
+
+	bar, ok := m.BossBars[uuid.UUID(id)]
+	if operation == OperationAdd {
+		bar = &BossBar{ID: uuid.UUID(id)}
+		m.BossBars[uuid.UUID(id)] = bar
+	} else if !ok {
+		return nil
+	}
+
+	var err error
+	switch operation {
+	case OperationAdd:
+		_, err = pk.Tuple{
+			&bar.Title,
+			(*pk.Float)(&bar.Health),
+			(*pk.VarInt)(&bar.Color),
+			(*pk.VarInt)(&bar.Division),
+			(*pk.UnsignedByte)(&bar.Flags),
+		}.ReadFrom(r)
+	case OperationRemove:
+		delete(m.BossBars, uuid.UUID(id))
+		if m.events.BossBarRemove != nil {
+			if err := m.events.BossBarRemove(uuid.UUID(id)); err != nil {
+				return Error{err}
+			}
+		}
+		return nil
+	case OperationUpdateProgress:
+		_, err = (*pk.Float)(&bar.Health).ReadFrom(r)
+	case OperationUpdateName:
+		_, err = bar.Title.ReadFrom(r)
+	case OperationUpdateStyle:
+		_, err = pk.Tuple{
+			(*pk.VarInt)(&bar.Color),
+			(*pk.VarInt)(&bar.Division),
+		}.ReadFrom(r)
+	case OperationUpdateProperties:
+		_, err = (*pk.UnsignedByte)(&bar.Flags).ReadFrom(r)
+	default:
+		err = errors.New("unknown boss event operation: " + strconv.Itoa(int(operation)))
+	}
+	if err != nil {
+		return Error{err}
+	}
+
+	if m.events.BossBarChange != nil {
+		if err := m.events.BossBarChange(bar); err != nil {
+			return Error{err}
+		}
+	}
+	return nil
+}
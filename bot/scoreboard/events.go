@@ -0,0 +1,34 @@
+package scoreboard
+
+import "github.com/google/uuid"
+
+// EventsListener is a collection of event handlers.
+// Fill the fields with your handler functions and pass it to [New] to create the scoreboard manager.
+// The handlers are called after the [Manager] state is updated.
+// For the event you don't want to handle, just leave it nil.
+type EventsListener struct {
+	// ObjectiveChange is called when an objective is created or its display name or format is changed.
+	ObjectiveChange func(obj *Objective) error
+	// ObjectiveRemove is called after the objective is removed.
+	ObjectiveRemove func(name string) error
+
+	// DisplayChange is called when the objective displayed in a slot is changed.
+	// The objective is empty when the slot is cleared.
+	DisplayChange func(slot DisplaySlot, objective string) error
+
+	// ScoreChange is called when a score is set.
+	ScoreChange func(obj *Objective, score *Score) error
+	// ScoreReset is called when the score of the holder is reset.
+	// The objective is empty when scores of all objectives are reset.
+	ScoreReset func(holder, objective string) error
+
+	// TeamChange is called when a team is created, changed, or its members are changed.
+	TeamChange func(team *Team) error
+	// TeamRemove is called after the team is removed.
+	TeamRemove func(name string) error
+
+	// BossBarChange is called when a boss bar is added or updated.
+	BossBarChange func(bar *BossBar) error
+	// BossBarRemove is called after the boss bar is removed.
+	BossBarRemove func(id uuid.UUID) error
+}
@@ -0,0 +1,372 @@
+// Package scoreboard tracks the scoreboard, teams and boss bars sent by the server.
+//
+// The [Manager] keeps every objective and its scores, the objective displayed in each slot
+// (the "sidebar", the "TAB list" and "below name"), the teams with their members,
+// and the boss bars on the top of the screen.
+// Texts are stored as [chat.Message] so that they can be rendered the same way as the vanilla client does.
+//
+// If a [playerlist.PlayerList] is given to [New], the team prefix, suffix and color
+// can be applied to the player names by calling [Manager.PlayerName].
+package scoreboard
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/bot/playerlist"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+type Manager struct {
+	pl     *playerlist.PlayerList
+	events EventsListener
+
+	Objectives map[string]*Objective
+	// Displayed maps the display slot to the name of objective shown in it.
+	Displayed map[DisplaySlot]string
+	Teams     map[string]*Team
+	BossBars  map[uuid.UUID]*BossBar
+
+	// teamOf maps the member (player name or entity UUID) to the team it belongs to.
+	teamOf map[string]*Team
+}
+
+// New creates a new scoreboard manager and attaches it to the client.
+// The pl is optional, pass nil if you don't need [Manager.PlayerName].
+func New(c *bot.Client, pl *playerlist.PlayerList, events EventsListener) *Manager {
+	m := &Manager{pl: pl, events: events}
+	m.reset()
+	c.Events.AddListener(
+		bot.PacketHandler{Priority: 64, ID: packetid.ClientboundLogin, F: m.onLogin},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundSetObjective, F: m.onSetObjective},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundSetDisplayObjective, F: m.onSetDisplayObjective},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundSetScore, F: m.onSetScore},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundResetScore, F: m.onResetScore},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundSetPlayerTeam, F: m.onSetPlayerTeam},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundBossEvent, F: m.onBossEvent},
+	)
+	return m
+}
+
+func (m *Manager) reset() {
+	m.Objectives = make(map[string]*Objective)
+	m.Displayed = make(map[DisplaySlot]string)
+	m.Teams = make(map[string]*Team)
+	m.BossBars = make(map[uuid.UUID]*BossBar)
+	m.teamOf = make(map[string]*Team)
+}
+
+func (m *Manager) onLogin(pk.Packet) error {
+	// A new Login packet means we are joining a new server (or are transferred),
+	// nothing we know is valid anymore.
+	m.reset()
+	return nil
+}
+
+// DisplaySlot is the place where an objective is displayed.
+type DisplaySlot int32
+
+const (
+	SlotList DisplaySlot = iota
+	SlotSidebar
+	SlotBelowName
+	// SlotTeamSidebar is the first of 16 sidebar slots,
+	// which are only visible to the players in the team of corresponding color.
+	// Use SlotTeamSidebar + DisplaySlot(color) to get the slot for a team color.
+	SlotTeamSidebar
+)
+
+// Displaying returns the objective displayed in the slot, or nil if nothing is displayed.
+func (m *Manager) Displaying(slot DisplaySlot) *Objective {
+	name, ok := m.Displayed[slot]
+	if !ok {
+		return nil
+	}
+	return m.Objectives[name]
+}
+
+// Sidebar returns the objective that the vanilla client shows on the right of the screen, or nil.
+// The team sidebar has priority over the global one if the bot's team has a color.
+func (m *Manager) Sidebar(playerName string) *Objective {
+	if t := m.teamOf[playerName]; t != nil && t.Color >= 0 && t.Color < 16 {
+		if obj := m.Displaying(SlotTeamSidebar + DisplaySlot(t.Color)); obj != nil {
+			return obj
+		}
+	}
+	return m.Displaying(SlotSidebar)
+}
+
+// RenderType is how the client displays the objective scores in the TAB list.
+type RenderType int32
+
+const (
+	RenderInteger RenderType = iota
+	RenderHearts
+)
+
+type Objective struct {
+	Name         string
+	DisplayName  chat.Message
+	RenderType   RenderType
+	NumberFormat *NumberFormat // Optional
+
+	// Scores maps the score holder (player name or entity UUID) to its score.
+	Scores map[string]*Score
+}
+
+type Score struct {
+	Holder       string
+	Value        int32
+	DisplayName  *chat.Message // Optional
+	NumberFormat *NumberFormat // Optional
+}
+
+// Format returns the score value formatted with the number format of the score,
+// or the number format of the objective if the score doesn't have one.
+func (o *Objective) Format(s *Score) chat.Message {
+	switch {
+	case s.NumberFormat != nil:
+		return s.NumberFormat.Format(s.Value)
+	case o.NumberFormat != nil:
+		return o.NumberFormat.Format(s.Value)
+	default:
+		return chat.Text(strconv.Itoa(int(s.Value)))
+	}
+}
+
+// SortedScores returns the scores in the order the vanilla sidebar displays them:
+// higher value first, then sorted by the holder name.
+func (o *Objective) SortedScores() []*Score {
+	scores := make([]*Score, 0, len(o.Scores))
+	for _, s := range o.Scores {
+		scores = append(scores, s)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Value != scores[j].Value {
+			return scores[i].Value > scores[j].Value
+		}
+		return scores[i].Holder < scores[j].Holder
+	})
+	return scores
+}
+
+func (m *Manager) onSetObjective(p pk.Packet) error {
+	const (
+		MethodAdd = iota
+		MethodRemove
+		MethodChange
+	)
+	r := bytes.NewReader(p.Data)
+	var (
+		name   pk.String
+		method pk.Byte
+	)
+	if _, err := (pk.Tuple{&name, &method}).ReadFrom(r); err != nil {
+		return Error{err}
+	}
+
+	if method == MethodRemove {
+		delete(m.Objectives, string(name))
+		for slot, v := range m.Displayed {
+			if v == string(name) {
+				delete(m.Displayed, slot)
+			}
+		}
+		if m.events.ObjectiveRemove != nil {
+			if err := m.events.ObjectiveRemove(string(name)); err != nil {
+				return Error{err}
+			}
+		}
+		return nil
+	}
+
+	var (
+		displayName  chat.Message
+		renderType   pk.VarInt
+		numberFormat pk.Option[NumberFormat, *NumberFormat]
+	)
+	if _, err := (pk.Tuple{&displayName, &renderType, &numberFormat}).ReadFrom(r); err != nil {
+		return Error{err}
+	}
+
+	obj, ok := m.Objectives[string(name)]
+	if !ok || method == MethodAdd {
+		obj = &Objective{Name: string(name), Scores: make(map[string]*Score)}
+		m.Objectives[string(name)] = obj
+	}
+	obj.DisplayName = displayName
+	obj.RenderType = RenderType(renderType)
+	obj.NumberFormat = numberFormat.Pointer()
+
+	if m.events.ObjectiveChange != nil {
+		if err := m.events.ObjectiveChange(obj); err != nil {
+			return Error{err}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) onSetDisplayObjective(p pk.Packet) error {
+	var (
+		slot pk.VarInt
+		name pk.String
+	)
+	if err := p.Scan(&slot, &name); err != nil {
+		return Error{err}
+	}
+	if name == "" {
+		delete(m.Displayed, DisplaySlot(slot))
+	} else {
+		m.Displayed[DisplaySlot(slot)] = string(name)
+	}
+	if m.events.DisplayChange != nil {
+		if err := m.events.DisplayChange(DisplaySlot(slot), string(name)); err != nil {
+			return Error{err}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) onSetScore(p pk.Packet) error {
+	var (
+		holder       pk.String
+		objective    pk.String
+		value        pk.VarInt
+		displayName  pk.Option[chat.Message, *chat.Message]
+		numberFormat pk.Option[NumberFormat, *NumberFormat]
+	)
+	if err := p.Scan(&holder, &objective, &value, &displayName, &numberFormat); err != nil {
+		return Error{err}
+	}
+	obj, ok := m.Objectives[string(objective)]
+	if !ok {
+		// The vanilla client ignores scores of unknown objectives.
+		return nil
+	}
+	score, ok := obj.Scores[string(holder)]
+	if !ok {
+		score = &Score{Holder: string(holder)}
+		obj.Scores[string(holder)] = score
+	}
+	score.Value = int32(value)
+	score.DisplayName = displayName.Pointer()
+	score.NumberFormat = numberFormat.Pointer()
+
+	if m.events.ScoreChange != nil {
+		if err := m.events.ScoreChange(obj, score); err != nil {
+			return Error{err}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) onResetScore(p pk.Packet) error {
+	var (
+		holder    pk.String
+		objective pk.Option[pk.String, *pk.String]
+	)
+	if err := p.Scan(&holder, &objective); err != nil {
+		return Error{err}
+	}
+	if objective.Has {
+		if obj, ok := m.Objectives[string(objective.Val)]; ok {
+			delete(obj.Scores, string(holder))
+		}
+	} else {
+		for _, obj := range m.Objectives {
+			delete(obj.Scores, string(holder))
+		}
+	}
+	if m.events.ScoreReset != nil {
+		if err := m.events.ScoreReset(string(holder), string(objective.Val)); err != nil {
+			return Error{err}
+		}
+	}
+	return nil
+}
+
+// NumberFormat controls how the score values are displayed.
+type NumberFormat struct {
+	Type NumberFormatType
+	// Style is only used when Type is NumberFormatStyled.
+	// Only the style fields of the message are used, the text is ignored.
+	Style chat.Message
+	// Fixed is only used when Type is NumberFormatFixed.
+	Fixed chat.Message
+}
+
+type NumberFormatType int32
+
+const (
+	NumberFormatBlank NumberFormatType = iota
+	NumberFormatStyled
+	NumberFormatFixed
+)
+
+// Format the score value.
+func (f *NumberFormat) Format(value int32) chat.Message {
+	switch f.Type {
+	case NumberFormatStyled:
+		msg := f.Style
+		msg.Text = strconv.Itoa(int(value))
+		msg.Translate, msg.With, msg.Extra = "", nil, nil
+		return msg
+	case NumberFormatFixed:
+		return f.Fixed
+	default:
+		return chat.Text("")
+	}
+}
+
+func (f NumberFormat) WriteTo(w io.Writer) (n int64, err error) {
+	n, err = pk.VarInt(f.Type).WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+	var n1 int64
+	switch f.Type {
+	case NumberFormatStyled:
+		n1, err = pk.NBT(&f.Style).WriteTo(w)
+	case NumberFormatFixed:
+		n1, err = f.Fixed.WriteTo(w)
+	}
+	return n + n1, err
+}
+
+func (f *NumberFormat) ReadFrom(r io.Reader) (n int64, err error) {
+	n, err = (*pk.VarInt)(&f.Type).ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+	var n1 int64
+	switch f.Type {
+	case NumberFormatBlank:
+	case NumberFormatStyled:
+		n1, err = f.Style.ReadFrom(r)
+	case NumberFormatFixed:
+		n1, err = f.Fixed.ReadFrom(r)
+	default:
+		err = errors.New("unknown number format type: " + strconv.Itoa(int(f.Type)))
+	}
+	return n + n1, err
+}
+
+type Error struct {
+	Err error
+}
+
+func (e Error) Error() string {
+	return "bot/scoreboard: " + e.Err.Error()
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
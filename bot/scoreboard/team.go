@@ -0,0 +1,188 @@
+package scoreboard
+
+import (
+	"bytes"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/chat"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+type Team struct {
+	Name              string
+	DisplayName       chat.Message
+	FriendlyFlags     byte // 0x01: Allow friendly fire, 0x02: can see invisible players on same team.
+	NameTagVisibility string
+	CollisionRule     string
+	Color             int32 // The ChatFormatting id. 0-15 for colors, 21 for "reset" which means no color.
+	Prefix            chat.Message
+	Suffix            chat.Message
+
+	// Members are player names or entity UUIDs.
+	Members map[string]struct{}
+}
+
+// Used by Team.FriendlyFlags.
+const (
+	AllowFriendlyFire = 1 << iota
+	SeeFriendlyInvisibles
+)
+
+// teamColors is the color names indexed by the ChatFormatting id.
+var teamColors = [16]string{
+	chat.Black, chat.DarkBlue, chat.DarkGreen, chat.DarkAqua,
+	chat.DarkRed, chat.DarkPurple, chat.Gold, chat.Gray,
+	chat.DarkGray, chat.Blue, chat.Green, chat.Aqua,
+	chat.Red, chat.LightPurple, chat.Yellow, chat.White,
+}
+
+// ColorName returns the chat color name of the team, or an empty string if the team has no color.
+func (t *Team) ColorName() string {
+	if t.Color < 0 || int(t.Color) >= len(teamColors) {
+		return ""
+	}
+	return teamColors[t.Color]
+}
+
+// FormatName decorates the name with the team prefix, suffix and color,
+// the same as the vanilla client renders the player names.
+func (t *Team) FormatName(name chat.Message) chat.Message {
+	return chat.Message{
+		Color: t.ColorName(),
+		Extra: []chat.Message{t.Prefix, name, t.Suffix},
+	}
+}
+
+// TeamOf returns the team that the member (player name or entity UUID) belongs to, or nil.
+func (m *Manager) TeamOf(member string) *Team {
+	return m.teamOf[member]
+}
+
+// PlayerName returns the player name for displaying in the TAB list.
+// The display name set by the server is preferred,
+// otherwise the player name decorated by its team is returned.
+//
+// The second return value is false if the player is not in the player list,
+// or the Manager is created without a player list.
+func (m *Manager) PlayerName(id uuid.UUID) (chat.Message, bool) {
+	if m.pl == nil {
+		return chat.Message{}, false
+	}
+	info, ok := m.pl.PlayerInfos[id]
+	if !ok {
+		return chat.Message{}, false
+	}
+	if info.DisplayName != nil {
+		return *info.DisplayName, true
+	}
+	name := chat.Text(info.Name)
+	if t := m.teamOf[info.Name]; t != nil {
+		return t.FormatName(name), true
+	}
+	return name, true
+}
+
+func (m *Manager) onSetPlayerTeam(p pk.Packet) error {
+	const (
+		MethodAdd = iota
+		MethodRemove
+		MethodChange
+		MethodJoin
+		MethodLeave
+	)
+	r := bytes.NewReader(p.Data)
+	var (
+		name   pk.String
+		method pk.Byte
+	)
+	if _, err := (pk.Tuple{&name, &method}).ReadFrom(r); err != nil {
+		return Error{err}
+	}
+
+	team, ok := m.Teams[string(name)]
+	if method == MethodAdd {
+		if ok { // replacing an existing team
+			for member := range team.Members {
+				m.leaveTeam(member, team)
+			}
+		}
+		team = &Team{Name: string(name), Members: make(map[string]struct{})}
+		m.Teams[string(name)] = team
+	} else if !ok {
+		// The vanilla client ignores changes of unknown teams.
+		return nil
+	}
+
+	switch method {
+	case MethodRemove:
+		for member := range team.Members {
+			m.leaveTeam(member, team)
+		}
+		delete(m.Teams, string(name))
+		if m.events.TeamRemove != nil {
+			if err := m.events.TeamRemove(string(name)); err != nil {
+				return Error{err}
+			}
+		}
+		return nil
+
+	case MethodAdd, MethodChange:
+		var (
+			nameTagVisibility pk.String
+			collisionRule     pk.String
+			color             pk.VarInt
+		)
+		_, err := pk.Tuple{
+			&team.DisplayName,
+			(*pk.UnsignedByte)(&team.FriendlyFlags),
+			&nameTagVisibility,
+			&collisionRule,
+			&color,
+			&team.Prefix,
+			&team.Suffix,
+		}.ReadFrom(r)
+		if err != nil {
+			return Error{err}
+		}
+		team.NameTagVisibility = string(nameTagVisibility)
+		team.CollisionRule = string(collisionRule)
+		team.Color = int32(color)
+	}
+
+	if method == MethodAdd || method == MethodJoin || method == MethodLeave {
+		var members []pk.String
+		if _, err := pk.Array(&members).ReadFrom(r); err != nil {
+			return Error{err}
+		}
+		for _, member := range members {
+			if method == MethodLeave {
+				m.leaveTeam(string(member), team)
+			} else {
+				m.joinTeam(string(member), team)
+			}
+		}
+	}
+
+	if m.events.TeamChange != nil {
+		if err := m.events.TeamChange(team); err != nil {
+			return Error{err}
+		}
+	}
+	return nil
+}
+
+func (m *Manager) joinTeam(member string, team *Team) {
+	if old := m.teamOf[member]; old != nil {
+		delete(old.Members, member)
+	}
+	team.Members[member] = struct{}{}
+	m.teamOf[member] = team
+}
+
+func (m *Manager) leaveTeam(member string, team *Team) {
+	delete(team.Members, member)
+	if m.teamOf[member] == team {
+		delete(m.teamOf, member)
+	}
+}
@@ -0,0 +1,119 @@
+package swarm
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	"github.com/Tnze/go-mc/bot/world"
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/registry"
+)
+
+// Shared holds the data shared by all bots in a swarm.
+// All methods are safe for concurrent use.
+type Shared struct {
+	registriesMu sync.Mutex
+	registries   map[string]*registry.Registries
+
+	// Chunks can be set to world.World.Cache, deduplicating the chunks received by bots.
+	Chunks *ChunkCache
+}
+
+func NewShared() *Shared {
+	return &Shared{
+		registries: make(map[string]*registry.Registries),
+		Chunks:     NewChunkCache(),
+	}
+}
+
+// shareRegistries returns the registries the bot should use.
+// The first bot joined the server donates its registries,
+// other bots use the clones of it, and their own copies are released.
+func (s *Shared) shareRegistries(addr string, regs *registry.Registries) registry.Registries {
+	s.registriesMu.Lock()
+	defer s.registriesMu.Unlock()
+	if shared, ok := s.registries[addr]; ok {
+		return shared.Clone()
+	}
+	shared := regs.Clone()
+	s.registries[addr] = &shared
+	return *regs
+}
+
+// ForgetRegistries drops the registries shared for the server address.
+// Call it if the registries of the server have changed, for example, after the server is restarted with new data packs.
+func (s *Shared) ForgetRegistries(addr string) {
+	s.registriesMu.Lock()
+	delete(s.registries, addr)
+	s.registriesMu.Unlock()
+}
+
+// ChunkCache deduplicates chunks by their content.
+// Bots receiving the same chunk from the server share one [level.Chunk],
+// which is released after no bot uses it.
+//
+// ChunkCache implements world.ChunkCache.
+type ChunkCache struct {
+	mu      sync.Mutex
+	entries map[chunkKey]*chunkEntry
+	keys    map[*level.Chunk]chunkKey
+}
+
+type chunkKey struct {
+	pos      level.ChunkPos
+	sections int
+	sum      [sha256.Size]byte
+}
+
+type chunkEntry struct {
+	chunk *level.Chunk
+	refs  int
+}
+
+var _ world.ChunkCache = (*ChunkCache)(nil)
+
+func NewChunkCache() *ChunkCache {
+	return &ChunkCache{
+		entries: make(map[chunkKey]*chunkEntry),
+		keys:    make(map[*level.Chunk]chunkKey),
+	}
+}
+
+func (c *ChunkCache) Acquire(pos level.ChunkPos, sections int, data []byte, decode func() (*level.Chunk, error)) (*level.Chunk, error) {
+	key := chunkKey{pos: pos, sections: sections, sum: sha256.Sum256(data)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.refs++
+		return e.chunk, nil
+	}
+	chunk, err := decode()
+	if err != nil {
+		return nil, err
+	}
+	c.entries[key] = &chunkEntry{chunk: chunk, refs: 1}
+	c.keys[chunk] = key
+	return chunk, nil
+}
+
+func (c *ChunkCache) Release(_ level.ChunkPos, chunk *level.Chunk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.keys[chunk]
+	if !ok {
+		return
+	}
+	e := c.entries[key]
+	if e.refs--; e.refs <= 0 {
+		delete(c.entries, key)
+		delete(c.keys, chunk)
+	}
+}
+
+// Len returns the number of distinct chunks in the cache.
+func (c *ChunkCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
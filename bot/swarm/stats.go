@@ -0,0 +1,68 @@
+package swarm
+
+import (
+	"sync"
+	"time"
+)
+
+// Stats is the statistics of the bots in a swarm.
+type Stats struct {
+	Online int // Number of bots currently in game.
+	Joined int // Total times of successful joining.
+	Failed int // Total times of failed joining, including being kicked while logging in.
+	Kicked int // Total times of being disconnected by the server, both during joining and in game.
+	Lost   int // Total times of losing the connection in game, excluding being kicked.
+
+	// Time spent on joining the server, from dialing to the end of the configuration.
+	JoinLatencyAvg time.Duration
+	JoinLatencyMax time.Duration
+
+	// LastError is the error of the last failed joining or disconnection.
+	LastError error
+}
+
+type statsCollector struct {
+	mu           sync.Mutex
+	stats        Stats
+	totalLatency time.Duration
+}
+
+func (s *statsCollector) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+func (s *statsCollector) onJoined(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Online++
+	s.stats.Joined++
+	s.totalLatency += latency
+	s.stats.JoinLatencyAvg = s.totalLatency / time.Duration(s.stats.Joined)
+	if latency > s.stats.JoinLatencyMax {
+		s.stats.JoinLatencyMax = latency
+	}
+}
+
+func (s *statsCollector) onFailed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Failed++
+	if IsKicked(err) {
+		s.stats.Kicked++
+	}
+	s.stats.LastError = err
+}
+
+func (s *statsCollector) onLeft(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Online--
+	if IsKicked(err) {
+		s.stats.Kicked++
+	} else {
+		s.stats.Lost++
+	}
+	s.stats.LastError = err
+}
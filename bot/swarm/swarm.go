@@ -0,0 +1,273 @@
+// Package swarm launches and manages many bots joining the same server.
+//
+// It is designed for load testing and bot farms. The [Swarm] creates the [bot.Client]s,
+// let them join the server one after another, reconnects them with an exponential backoff
+// after they are disconnected, and collects the [Stats] of all bots.
+//
+// Bots in the same swarm can share the immutable data through [Shared],
+// for example the registries and the chunks received by [world.World].
+package swarm
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+type Options struct {
+	// Address of the server to join.
+	Address string
+	// Number of bots.
+	Number int
+	// JoinInterval is the delay between launching two bots.
+	JoinInterval time.Duration
+
+	// Auth returns the account used by the i-th bot.
+	// If nil, offline accounts named "Bot0", "Bot1", ... are used.
+	Auth func(i int) (bot.Auth, error)
+
+	// Setup is called once for each bot after its client is created and before it first joins the server.
+	// It's the place to attach packet handlers and create managers such as basic.Player or world.World.
+	Setup func(b *Bot) error
+
+	// JoinOptions are passed to bot.Client.JoinServerWithOptions.
+	// The Context is overridden by the context passed to Swarm.Run.
+	JoinOptions bot.JoinOptions
+
+	// Backoff controls the delay before reconnecting.
+	// Zero value means DefaultBackoff.
+	Backoff Backoff
+}
+
+// Backoff is an exponential backoff policy.
+// The delay begins with Initial, and multiplied by Multiplier after each failure until Max.
+// It's reset to Initial after the bot successfully joins the server.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+var DefaultBackoff = Backoff{
+	Initial:    time.Second,
+	Max:        time.Minute,
+	Multiplier: 2,
+}
+
+func (b Backoff) next(d time.Duration) time.Duration {
+	d = time.Duration(float64(d) * b.Multiplier)
+	if d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+// Swarm manages a group of bots.
+type Swarm struct {
+	opts   Options
+	Shared *Shared
+
+	stats statsCollector
+
+	botsMu sync.Mutex
+	bots   []*Bot
+}
+
+// Bot is a member of the swarm.
+type Bot struct {
+	ID     int
+	Client *bot.Client
+	Swarm  *Swarm
+
+	// connMu protects the Client.Conn from being closed while joining
+	connMu sync.Mutex
+	joined bool
+	closed bool
+}
+
+func New(opts Options) *Swarm {
+	if opts.Backoff == (Backoff{}) {
+		opts.Backoff = DefaultBackoff
+	}
+	if opts.Auth == nil {
+		opts.Auth = func(i int) (bot.Auth, error) {
+			return bot.Auth{Name: "Bot" + strconv.Itoa(i)}, nil
+		}
+	}
+	return &Swarm{
+		opts:   opts,
+		Shared: NewShared(),
+	}
+}
+
+// Bots returns the bots have been launched.
+func (s *Swarm) Bots() []*Bot {
+	s.botsMu.Lock()
+	defer s.botsMu.Unlock()
+	return append([]*Bot(nil), s.bots...)
+}
+
+// Stats returns a snapshot of the statistics of all bots.
+func (s *Swarm) Stats() Stats {
+	return s.stats.snapshot()
+}
+
+// Run launches all bots and blocks until the ctx is done.
+// Bots are disconnected when Run returns.
+// If any bot fails to be set up, the launched bots are stopped and the error is returned.
+func (s *Swarm) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for i := 0; i < s.opts.Number; i++ {
+		if i > 0 && s.opts.JoinInterval > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.opts.JoinInterval):
+			}
+		}
+		b, err := s.newBot(i)
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.run(ctx)
+		}()
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *Swarm) newBot(i int) (*Bot, error) {
+	auth, err := s.opts.Auth(i)
+	if err != nil {
+		return nil, SetupErr{ID: i, Err: err}
+	}
+	c := bot.NewClient()
+	c.Auth = auth
+	b := &Bot{ID: i, Client: c, Swarm: s}
+	if s.opts.Setup != nil {
+		if err := s.opts.Setup(b); err != nil {
+			return nil, SetupErr{ID: i, Err: err}
+		}
+	}
+	// The handler is added at last with the lowest priority,
+	// so the user's Disconnect handler still has a chance to see the packet.
+	c.Events.AddListener(bot.PacketHandler{
+		Priority: -64, ID: packetid.ClientboundDisconnect,
+		F: func(p pk.Packet) error {
+			var reason chat.Message
+			if err := p.Scan(&reason); err != nil {
+				return err
+			}
+			return bot.DisconnectErr(reason)
+		},
+	})
+
+	s.botsMu.Lock()
+	s.bots = append(s.bots, b)
+	s.botsMu.Unlock()
+	return b, nil
+}
+
+// run joins the server, handles the game, and reconnects after the bot is disconnected, until the ctx is done.
+func (b *Bot) run(ctx context.Context) {
+	stop := context.AfterFunc(ctx, b.close)
+	defer stop()
+
+	backoff := b.Swarm.opts.Backoff
+	delay := backoff.Initial
+	for ctx.Err() == nil {
+		if b.join(ctx) {
+			delay = backoff.Initial
+			b.handleGame()
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		delay = backoff.next(delay)
+	}
+}
+
+func (b *Bot) join(ctx context.Context) bool {
+	s := b.Swarm
+	opts := s.opts.JoinOptions
+	opts.Context = ctx
+	// each connection needs its own queues
+	opts.QueueRead, opts.QueueWrite = nil, nil
+
+	start := time.Now()
+	err := b.Client.JoinServerWithOptions(s.opts.Address, opts)
+	if err != nil {
+		s.stats.onFailed(err)
+		return false
+	}
+
+	b.connMu.Lock()
+	defer b.connMu.Unlock()
+	if b.closed {
+		_ = b.Client.Close()
+		return false
+	}
+	b.joined = true
+	b.Client.Registries = s.Shared.shareRegistries(s.opts.Address, &b.Client.Registries)
+	s.stats.onJoined(time.Since(start))
+	return true
+}
+
+func (b *Bot) handleGame() {
+	err := b.Client.HandleGame()
+
+	b.connMu.Lock()
+	b.joined = false
+	_ = b.Client.Close()
+	b.connMu.Unlock()
+
+	b.Swarm.stats.onLeft(err)
+}
+
+// close interrupts the running bot.
+func (b *Bot) close() {
+	b.connMu.Lock()
+	defer b.connMu.Unlock()
+	b.closed = true
+	if b.joined {
+		// Only close the underlying connection, the HandleGame will return with an error
+		// and the Client is closed by the goroutine running it.
+		_ = b.Client.Conn.Conn.Close()
+	}
+}
+
+// IsKicked reports whether the err is caused by the server disconnecting the bot.
+func IsKicked(err error) bool {
+	var disconnectErr bot.DisconnectErr
+	return errors.As(err, &disconnectErr)
+}
+
+type SetupErr struct {
+	ID  int
+	Err error
+}
+
+func (s SetupErr) Error() string {
+	return "swarm: setup bot " + strconv.Itoa(s.ID) + " error: " + s.Err.Error()
+}
+
+func (s SetupErr) Unwrap() error {
+	return s.Err
+}
@@ -0,0 +1,35 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSwarm_Run_setupErr(t *testing.T) {
+	errSetup := errors.New("setup failed")
+	s := New(Options{
+		// Nothing is listening, the first bot keeps reconnecting
+		Address: "127.0.0.1:1",
+		Number:  2,
+		Setup: func(b *Bot) error {
+			if b.ID == 1 {
+				return errSetup
+			}
+			return nil
+		},
+		Backoff: Backoff{Initial: 10 * time.Millisecond, Max: 10 * time.Millisecond, Multiplier: 1},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := s.Run(ctx)
+	var setupErr SetupErr
+	if !errors.As(err, &setupErr) || setupErr.ID != 1 || !errors.Is(err, errSetup) {
+		t.Fatalf("got error %v, want the setup error of bot 1", err)
+	}
+	if ctx.Err() != nil {
+		t.Error("Run returned after the ctx is done")
+	}
+}
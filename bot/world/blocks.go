@@ -0,0 +1,163 @@
+package world
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+// ErrNotLoaded is returned when accessing a block in an unloaded chunk.
+var ErrNotLoaded = errors.New("chunk not loaded")
+
+// GetBlock returns the block state at the given position.
+func (w *World) GetBlock(pos pk.Position) (level.BlocksState, error) {
+	chunk, sec, i, err := w.locate(pos)
+	if err != nil {
+		return 0, err
+	}
+	return chunk.Sections[sec].GetBlock(i), nil
+}
+
+// locate finds the chunk, section index and block index of the position.
+func (w *World) locate(pos pk.Position) (chunk *level.Chunk, sec, i int, err error) {
+	chunk, ok := w.Columns[level.ChunkPos{int32(pos.X >> 4), int32(pos.Z >> 4)}]
+	if !ok {
+		return nil, 0, 0, ErrNotLoaded
+	}
+	dimType := w.c.Registries.DimensionType.GetByID(w.p.DimensionType)
+	if dimType == nil {
+		return nil, 0, 0, fmt.Errorf("dimension type %d not found", w.p.DimensionType)
+	}
+	y := pos.Y - int(dimType.MinY)
+	sec = y >> 4
+	if y < 0 || sec >= len(chunk.Sections) {
+		return nil, 0, 0, fmt.Errorf("block Y value %d out of bounds", pos.Y)
+	}
+	i = (y&15)<<8 | (pos.Z&15)<<4 | pos.X&15
+	return chunk, sec, i, nil
+}
+
+// setBlock changes the block state. Chunks shared by the Cache are copied before modified.
+func (w *World) setBlock(pos pk.Position, state level.BlocksState) error {
+	chunk, sec, i, err := w.locate(pos)
+	if errors.Is(err, ErrNotLoaded) {
+		// the vanilla client ignores updates of unloaded chunks
+		return nil
+	} else if err != nil {
+		return err
+	}
+	chunk, err = w.modify(level.ChunkPos{int32(pos.X >> 4), int32(pos.Z >> 4)}, chunk)
+	if err != nil {
+		return err
+	}
+	chunk.Sections[sec].SetBlock(i, state)
+
+	// remove the block entity if it doesn't belong to the new block anymore
+	var xz level.BlockEntity
+	xz.PackXZ(pos.X&15, pos.Z&15)
+	for j, be := range chunk.BlockEntity {
+		if be.XZ != xz.XZ || int(be.Y) != pos.Y {
+			continue
+		}
+		if int(be.Type) >= len(block.EntityList) || int(state) >= len(block.StateList) ||
+			!block.EntityList[be.Type].IsValidBlock(block.StateList[state]) {
+			chunk.BlockEntity = append(chunk.BlockEntity[:j], chunk.BlockEntity[j+1:]...)
+		}
+		break
+	}
+	return nil
+}
+
+// modify returns a chunk that is safe to be modified.
+// If the chunk is shared by the Cache, it's copied and released.
+func (w *World) modify(pos level.ChunkPos, chunk *level.Chunk) (*level.Chunk, error) {
+	if _, ok := w.detached[pos]; ok || w.Cache == nil {
+		return chunk, nil
+	}
+	var buf bytes.Buffer
+	if _, err := chunk.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	clone := level.EmptyChunk(len(chunk.Sections))
+	if _, err := clone.ReadFrom(&buf); err != nil {
+		return nil, err
+	}
+	for i := range chunk.Sections {
+		clone.Sections[i].SkyLight = chunk.Sections[i].SkyLight
+		clone.Sections[i].BlockLight = chunk.Sections[i].BlockLight
+	}
+	clone.Status = chunk.Status
+	w.Cache.Release(pos, chunk)
+	w.Columns[pos] = clone
+	w.detached[pos] = struct{}{}
+	return clone, nil
+}
+
+func (w *World) handleBlockUpdatePacket(packet pk.Packet) error {
+	var (
+		pos   pk.Position
+		state pk.VarInt
+	)
+	if err := packet.Scan(&pos, &state); err != nil {
+		return err
+	}
+	return w.setBlock(pos, level.BlocksState(state))
+}
+
+func (w *World) handleSectionBlocksUpdatePacket(packet pk.Packet) error {
+	var (
+		sectionPos pk.Long
+		blocks     []pk.VarLong
+	)
+	if err := packet.Scan(&sectionPos, pk.Array(&blocks)); err != nil {
+		return err
+	}
+	sx, sy, sz := int(sectionPos>>42), int(sectionPos<<44>>44), int(sectionPos<<22>>42)
+	for _, v := range blocks {
+		pos := pk.Position{
+			X: sx<<4 | int(v>>8&15),
+			Y: sy<<4 | int(v&15),
+			Z: sz<<4 | int(v>>4&15),
+		}
+		if err := w.setBlock(pos, level.BlocksState(v>>12)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *World) handleBlockEntityDataPacket(packet pk.Packet) error {
+	var (
+		pos  pk.Position
+		typ  pk.VarInt
+		data nbt.RawMessage
+	)
+	if err := packet.Scan(&pos, &typ, pk.NBTField{V: &data, AllowUnknownFields: true}); err != nil {
+		return err
+	}
+	cpos := level.ChunkPos{int32(pos.X >> 4), int32(pos.Z >> 4)}
+	chunk, ok := w.Columns[cpos]
+	if !ok {
+		return nil
+	}
+	chunk, err := w.modify(cpos, chunk)
+	if err != nil {
+		return err
+	}
+
+	be := level.BlockEntity{Y: int16(pos.Y), Type: block.EntityType(typ), Data: data}
+	be.PackXZ(pos.X&15, pos.Z&15)
+	for i := range chunk.BlockEntity {
+		if chunk.BlockEntity[i].XZ == be.XZ && chunk.BlockEntity[i].Y == be.Y {
+			chunk.BlockEntity[i] = be
+			return nil
+		}
+	}
+	chunk.BlockEntity = append(chunk.BlockEntity, be)
+	return nil
+}
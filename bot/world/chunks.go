@@ -16,25 +16,51 @@ type World struct {
 	events EventsListener
 
 	Columns map[level.ChunkPos]*level.Chunk
+
+	// Cache is optional. If it is set, the chunks are loaded through it,
+	// allowing multiple World to share the same chunk data.
+	//
+	// Chunks shared by the cache must not be modified.
+	Cache ChunkCache
+	// detached records chunks copied from the Cache for modifying.
+	detached map[level.ChunkPos]struct{}
+}
+
+// ChunkCache is used for sharing chunks between worlds.
+// Implementations must be safe for concurrent use.
+type ChunkCache interface {
+	// Acquire returns the chunk decoded from data.
+	// The decode function is only called if the chunk is not cached yet.
+	// The data is only valid during the call.
+	Acquire(pos level.ChunkPos, sections int, data []byte, decode func() (*level.Chunk, error)) (*level.Chunk, error)
+	// Release is called when the world no longer uses the chunk.
+	Release(pos level.ChunkPos, chunk *level.Chunk)
 }
 
 func NewWorld(c *bot.Client, p *basic.Player, events EventsListener) (w *World) {
 	w = &World{
 		c: c, p: p,
-		events:  events,
-		Columns: make(map[level.ChunkPos]*level.Chunk),
+		events:   events,
+		Columns:  make(map[level.ChunkPos]*level.Chunk),
+		detached: make(map[level.ChunkPos]struct{}),
 	}
 	c.Events.AddListener(
 		bot.PacketHandler{Priority: 64, ID: packetid.ClientboundLogin, F: w.onPlayerSpawn},
 		bot.PacketHandler{Priority: 64, ID: packetid.ClientboundRespawn, F: w.onPlayerSpawn},
 		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundLevelChunkWithLight, F: w.handleLevelChunkWithLightPacket},
 		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundForgetLevelChunk, F: w.handleForgetLevelChunkPacket},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundBlockUpdate, F: w.handleBlockUpdatePacket},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundSectionBlocksUpdate, F: w.handleSectionBlocksUpdatePacket},
+		bot.PacketHandler{Priority: 0, ID: packetid.ClientboundBlockEntityData, F: w.handleBlockEntityDataPacket},
 	)
 	return
 }
 
 func (w *World) onPlayerSpawn(pk.Packet) error {
 	// unload all chunks
+	for pos, chunk := range w.Columns {
+		w.release(pos, chunk)
+	}
 	w.Columns = make(map[level.ChunkPos]*level.Chunk)
 	return nil
 }
@@ -45,10 +71,31 @@ func (w *World) handleLevelChunkWithLightPacket(packet pk.Packet) error {
 	if currentDimType == nil {
 		return fmt.Errorf("dimension type %d not found", w.p.DimensionType)
 	}
-	chunk := level.EmptyChunk(int(currentDimType.Height) / 16)
-	if err := packet.Scan(&pos, chunk); err != nil {
+	secs := int(currentDimType.Height) / 16
+	decode := func() (*level.Chunk, error) {
+		chunk := level.EmptyChunk(secs)
+		if err := packet.Scan(&pos, chunk); err != nil {
+			return nil, err
+		}
+		return chunk, nil
+	}
+
+	var chunk *level.Chunk
+	var err error
+	if w.Cache != nil {
+		if err := packet.Scan(&pos); err != nil {
+			return err
+		}
+		chunk, err = w.Cache.Acquire(pos, secs, packet.Data, decode)
+	} else {
+		chunk, err = decode()
+	}
+	if err != nil {
 		return err
 	}
+	if old, ok := w.Columns[pos]; ok {
+		w.release(pos, old)
+	}
 	w.Columns[pos] = chunk
 	if w.events.LoadChunk != nil {
 		if err := w.events.LoadChunk(pos); err != nil {
@@ -58,15 +105,38 @@ func (w *World) handleLevelChunkWithLightPacket(packet pk.Packet) error {
 	return nil
 }
 
+// ScanForgetLevelChunk reads the position of the chunk in the ForgetLevelChunk packet.
+// Unlike the other packets, the position is sent as a Long with z in the higher bits, so z comes first.
+func ScanForgetLevelChunk(packet pk.Packet) (level.ChunkPos, error) {
+	var z, x pk.Int
+	if err := packet.Scan(&z, &x); err != nil {
+		return level.ChunkPos{}, err
+	}
+	return level.ChunkPos{int32(x), int32(z)}, nil
+}
+
 func (w *World) handleForgetLevelChunkPacket(packet pk.Packet) error {
-	var pos level.ChunkPos
-	if err := packet.Scan(&pos); err != nil {
+	pos, err := ScanForgetLevelChunk(packet)
+	if err != nil {
 		return err
 	}
-	var err error
 	if w.events.UnloadChunk != nil {
 		err = w.events.UnloadChunk(pos)
 	}
+	if chunk, ok := w.Columns[pos]; ok {
+		w.release(pos, chunk)
+	}
 	delete(w.Columns, pos)
 	return err
 }
+
+// release returns the chunk to the Cache if it's shared.
+func (w *World) release(pos level.ChunkPos, chunk *level.Chunk) {
+	if _, ok := w.detached[pos]; ok {
+		delete(w.detached, pos)
+		return
+	}
+	if w.Cache != nil {
+		w.Cache.Release(pos, chunk)
+	}
+}
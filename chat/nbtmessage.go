@@ -25,11 +25,19 @@ func (m Message) TagType() byte {
 }
 
 func (m Message) MarshalNBT(w io.Writer) error {
+	var v any = rawMsgStruct(m)
 	if m.Translate != "" {
-		return nbt.NewEncoder(w).Encode(translateMsg(m), "")
-	} else {
-		return nbt.NewEncoder(w).Encode(rawMsgStruct(m), "")
+		v = translateMsg(m)
 	}
+	var buf bytes.Buffer
+	encoder := nbt.NewEncoder(&buf)
+	encoder.NetworkFormat(true)
+	if err := encoder.Encode(v, ""); err != nil {
+		return err
+	}
+	// The tag type is written by the caller, only the payload is needed
+	_, err := w.Write(buf.Bytes()[1:])
+	return err
 }
 
 func (m *Message) UnmarshalNBT(tagType byte, r nbt.DecoderReader) error {
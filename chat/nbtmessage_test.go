@@ -1,6 +1,7 @@
 package chat_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/Tnze/go-mc/chat"
@@ -19,14 +20,14 @@ func TestMessage_UnmarshalJSON_string(t *testing.T) {
 
 	chat.SetLanguage(en_us.Map)
 	for i, v := range snbts {
-		bytes, err := nbt.Marshal(nbt.StringifiedMessage(v))
+		data, err := nbt.Marshal(nbt.StringifiedMessage(v))
 		if err != nil {
 			t.Errorf("Invalid SNBT: %v", err)
 			continue
 		}
 
 		var cm chat.Message
-		if err := nbt.Unmarshal(bytes, &cm); err != nil {
+		if err := nbt.Unmarshal(data, &cm); err != nil {
 			t.Error(err)
 		}
 		if str := cm.String(); str != texts[i] {
@@ -34,3 +35,24 @@ func TestMessage_UnmarshalJSON_string(t *testing.T) {
 		}
 	}
 }
+
+func TestMessage_WriteTo(t *testing.T) {
+	msgs := []chat.Message{
+		chat.Text("Hello"),
+		{Text: "Hello", Bold: true, Extra: []chat.Message{chat.Text(", world")}},
+		chat.TranslateMsg("sleep.players_sleeping", chat.Text("1"), chat.Text("37")),
+	}
+	for _, want := range msgs {
+		var buf bytes.Buffer
+		if _, err := want.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var got chat.Message
+		if _, err := got.ReadFrom(&buf); err != nil {
+			t.Fatalf("decode %v: %v", want, err)
+		}
+		if got.ClearString() != want.ClearString() || got.Bold != want.Bold {
+			t.Errorf("gets %v, wants %v", got, want)
+		}
+	}
+}
@@ -34,6 +34,9 @@ var registersJson []byte
 //go:embed template.go.tmpl
 var tempSource string
 
+//go:embed registries.go.tmpl
+var registriesTempSource string
+
 type tempData struct {
 	PackageName string
 	Default     string
@@ -51,17 +54,45 @@ var temp = template.Must(template.
 	Parse(tempSource),
 )
 
+var registriesTemp = template.Must(template.
+	New("registries_template").
+	Funcs(template.FuncMap{
+		"Generator": func() string { return "data/registry/generate.go" },
+	}).
+	Parse(registriesTempSource),
+)
+
 func main() {
 	var registries map[string]registry
 	if err := json.Unmarshal(registersJson, &registries); err != nil {
 		log.Fatal(err)
 	}
 
+	typeNames := make(map[string]string, len(registries))
 	for key, reg := range registries {
 		registryName := strings.TrimPrefix(key, "minecraft:")
 		typeName := generateutils.ToGoTypeName(strings.ReplaceAll(registryName, "/", "_"))
 		filename := strings.NewReplacer("_", "", "/", "_").Replace(registryName)
 		generateRegistry(reg, typeName, filename)
+		typeNames[key] = typeName
+	}
+	generateRegistries(typeNames)
+}
+
+func generateRegistries(typeNames map[string]string) {
+	var buff bytes.Buffer
+	if err := registriesTemp.Execute(&buff, typeNames); err != nil {
+		log.Fatal(err)
+	}
+
+	formattedSource, err := format.Source(buff.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = os.WriteFile(filepath.Join("..", "registries.go"), formattedSource, 0o666)
+	if err != nil {
+		log.Fatal(err)
 	}
 }
 
@@ -0,0 +1,85 @@
+// Code generated by data/registry/generate.go; DO NOT EDIT.
+
+package registryid
+
+// Registries maps the ID of each builtin registry to its entries.
+var Registries = map[string][]string{
+	"minecraft:activity":                               Activity,
+	"minecraft:armor_material":                         ArmorMaterial,
+	"minecraft:attribute":                              Attribute,
+	"minecraft:block":                                  Block,
+	"minecraft:block_entity_type":                      BlockEntityType,
+	"minecraft:block_predicate_type":                   BlockPredicateType,
+	"minecraft:block_type":                             BlockType,
+	"minecraft:cat_variant":                            CatVariant,
+	"minecraft:chunk_status":                           ChunkStatus,
+	"minecraft:command_argument_type":                  CommandArgumentType,
+	"minecraft:creative_mode_tab":                      CreativeModeTab,
+	"minecraft:custom_stat":                            CustomStat,
+	"minecraft:data_component_type":                    DataComponentType,
+	"minecraft:decorated_pot_pattern":                  DecoratedPotPattern,
+	"minecraft:enchantment_effect_component_type":      EnchantmentEffectComponentType,
+	"minecraft:enchantment_entity_effect_type":         EnchantmentEntityEffectType,
+	"minecraft:enchantment_level_based_value_type":     EnchantmentLevelBasedValueType,
+	"minecraft:enchantment_location_based_effect_type": EnchantmentLocationBasedEffectType,
+	"minecraft:enchantment_provider_type":              EnchantmentProviderType,
+	"minecraft:enchantment_value_effect_type":          EnchantmentValueEffectType,
+	"minecraft:entity_sub_predicate_type":              EntitySubPredicateType,
+	"minecraft:entity_type":                            EntityType,
+	"minecraft:float_provider_type":                    FloatProviderType,
+	"minecraft:fluid":                                  Fluid,
+	"minecraft:frog_variant":                           FrogVariant,
+	"minecraft:game_event":                             GameEvent,
+	"minecraft:height_provider_type":                   HeightProviderType,
+	"minecraft:instrument":                             Instrument,
+	"minecraft:int_provider_type":                      IntProviderType,
+	"minecraft:item":                                   Item,
+	"minecraft:item_sub_predicate_type":                ItemSubPredicateType,
+	"minecraft:loot_condition_type":                    LootConditionType,
+	"minecraft:loot_function_type":                     LootFunctionType,
+	"minecraft:loot_nbt_provider_type":                 LootNbtProviderType,
+	"minecraft:loot_number_provider_type":              LootNumberProviderType,
+	"minecraft:loot_pool_entry_type":                   LootPoolEntryType,
+	"minecraft:loot_score_provider_type":               LootScoreProviderType,
+	"minecraft:map_decoration_type":                    MapDecorationType,
+	"minecraft:memory_module_type":                     MemoryModuleType,
+	"minecraft:menu":                                   Menu,
+	"minecraft:mob_effect":                             MobEffect,
+	"minecraft:number_format_type":                     NumberFormatType,
+	"minecraft:particle_type":                          ParticleType,
+	"minecraft:point_of_interest_type":                 PointOfInterestType,
+	"minecraft:pos_rule_test":                          PosRuleTest,
+	"minecraft:position_source_type":                   PositionSourceType,
+	"minecraft:potion":                                 Potion,
+	"minecraft:recipe_serializer":                      RecipeSerializer,
+	"minecraft:recipe_type":                            RecipeType,
+	"minecraft:rule_block_entity_modifier":             RuleBlockEntityModifier,
+	"minecraft:rule_test":                              RuleTest,
+	"minecraft:schedule":                               Schedule,
+	"minecraft:sensor_type":                            SensorType,
+	"minecraft:sound_event":                            SoundEvent,
+	"minecraft:stat_type":                              StatType,
+	"minecraft:trigger_type":                           TriggerType,
+	"minecraft:villager_profession":                    VillagerProfession,
+	"minecraft:villager_type":                          VillagerType,
+	"minecraft:worldgen/biome_source":                  WorldgenBiomeSource,
+	"minecraft:worldgen/block_state_provider_type":     WorldgenBlockStateProviderType,
+	"minecraft:worldgen/carver":                        WorldgenCarver,
+	"minecraft:worldgen/chunk_generator":               WorldgenChunkGenerator,
+	"minecraft:worldgen/density_function_type":         WorldgenDensityFunctionType,
+	"minecraft:worldgen/feature":                       WorldgenFeature,
+	"minecraft:worldgen/feature_size_type":             WorldgenFeatureSizeType,
+	"minecraft:worldgen/foliage_placer_type":           WorldgenFoliagePlacerType,
+	"minecraft:worldgen/material_condition":            WorldgenMaterialCondition,
+	"minecraft:worldgen/material_rule":                 WorldgenMaterialRule,
+	"minecraft:worldgen/placement_modifier_type":       WorldgenPlacementModifierType,
+	"minecraft:worldgen/pool_alias_binding":            WorldgenPoolAliasBinding,
+	"minecraft:worldgen/root_placer_type":              WorldgenRootPlacerType,
+	"minecraft:worldgen/structure_piece":               WorldgenStructurePiece,
+	"minecraft:worldgen/structure_placement":           WorldgenStructurePlacement,
+	"minecraft:worldgen/structure_pool_element":        WorldgenStructurePoolElement,
+	"minecraft:worldgen/structure_processor":           WorldgenStructureProcessor,
+	"minecraft:worldgen/structure_type":                WorldgenStructureType,
+	"minecraft:worldgen/tree_decorator_type":           WorldgenTreeDecoratorType,
+	"minecraft:worldgen/trunk_placer_type":             WorldgenTrunkPlacerType,
+}
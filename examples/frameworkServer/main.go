@@ -0,0 +1,63 @@
+// Example frameworkServer hosts a read-only world from disk
+// with the server framework and the reference GamePlay.
+//
+// Usage: go run ./examples/frameworkServer -world ./world
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/registry"
+	"github.com/Tnze/go-mc/server"
+	"github.com/Tnze/go-mc/server/gameplay"
+)
+
+var (
+	address    = flag.String("address", "127.0.0.1:25565", "The listening address")
+	worldDir   = flag.String("world", "./world", "The directory of the world save")
+	onlineMode = flag.Bool("online", false, "Whether to authenticate the players")
+	maxPlayers = flag.Int("max", 20, "The maximum number of online players")
+)
+
+func main() {
+	flag.Parse()
+
+	registries := registry.Vanilla()
+
+	playerList := server.NewPlayerList(*maxPlayers)
+	game, err := gameplay.New(*worldDir, &registries, playerList)
+	if err != nil {
+		log.Fatalf("load world: %v", err)
+	}
+	game.Logger = log.Default()
+	go game.Run(context.Background())
+
+	s := server.Server{
+		Logger: log.Default(),
+		ListPingHandler: struct {
+			*server.PingInfo
+			*server.PlayerList
+		}{
+			server.NewPingInfo(server.ProtocolName, server.ProtocolVersion, chat.Text("A Go-MC Server"), nil),
+			playerList,
+		},
+		LoginHandler: &server.MojangLoginHandler{
+			OnlineMode:   *onlineMode,
+			Threshold:    256,
+			LoginChecker: playerList,
+		},
+		ConfigHandler: &server.Configurations{
+			Registries: registries,
+			ServerLinks: []server.ServerLink{
+				{Builtin: 6, URL: "https://github.com/Tnze/go-mc"},
+			},
+		},
+		GamePlay: game,
+	}
+	if err := s.Listen(*address); err != nil {
+		log.Fatal(err)
+	}
+}
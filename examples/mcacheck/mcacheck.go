@@ -0,0 +1,96 @@
+// This is an example of how to verify, repair and compact .mca files with the go-mc/save/region package.
+// It reports the problems of each region file, and fixes them if the -r flag is set.
+//
+// The chunks are decoded by the name of the folder, "region", "entities" or "poi".
+// Make sure the world isn't opened by a running server, and backup it before repairing.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Tnze/go-mc/save"
+	"github.com/Tnze/go-mc/save/region"
+)
+
+var (
+	repair  = flag.Bool("r", false, "repair the region files, the chunks can't be recovered are removed")
+	compact = flag.Bool("c", false, "compact the region files, removing the unused sectors")
+	shallow = flag.Bool("s", false, "only check the structure of the region files, without decoding the chunks")
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	if len(args) < 1 {
+		usage()
+	}
+
+	var failed bool
+	for _, f := range args {
+		for _, f := range must(filepath.Glob(f)) {
+			if !check(f) {
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	_, _ = fmt.Fprintf(os.Stderr, "usage: %s [-r] [-c] [-s] r.<X>.<Z>.mca\n", os.Args[0])
+	os.Exit(1)
+}
+
+// check verifies the region file f, and returns if it's fine or fixed.
+func check(f string) bool {
+	var x, z int
+	must(fmt.Sscanf(filepath.Base(f), "r.%d.%d.mca", &x, &z))
+
+	r := must(region.Open(f))
+	defer r.Close()
+
+	var checkFunc region.CheckFunc
+	if !*shallow {
+		switch filepath.Base(filepath.Dir(f)) {
+		case "entities":
+			checkFunc = save.CheckEntityChunk(x, z)
+		case "poi":
+			checkFunc = save.CheckPOIChunk()
+		default:
+			checkFunc = save.CheckChunk(x, z)
+		}
+	}
+
+	var problems []region.Problem
+	if *repair {
+		problems = must(r.Repair(checkFunc))
+	} else {
+		problems = must(r.Verify(checkFunc))
+	}
+	for _, p := range problems {
+		fmt.Printf("%s: %v\n", f, p)
+	}
+	if *compact && (len(problems) == 0 || *repair) {
+		must(0, r.Compact())
+	}
+
+	if len(problems) > 0 && *repair {
+		fmt.Printf("%s: repaired %d problems\n", f, len(problems))
+		return true
+	}
+	return len(problems) == 0
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return v
+}
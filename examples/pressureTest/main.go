@@ -7,85 +7,74 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
 	"strconv"
 	"time"
 
 	"github.com/Tnze/go-mc/bot"
 	"github.com/Tnze/go-mc/bot/basic"
-	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/bot/swarm"
+	"github.com/Tnze/go-mc/bot/world"
 )
 
 var (
-	address = flag.String("address", "127.0.0.1", "The server address")
-	number  = flag.Int("number", 1023, "The number of clients")
+	address  = flag.String("address", "127.0.0.1", "The server address")
+	number   = flag.Int("number", 1023, "The number of clients")
+	interval = flag.Duration("interval", time.Millisecond, "The interval between two clients joining")
 )
 
 func main() {
 	flag.Parse()
 
-	for i := 0; i < *number; i++ {
-		go func(i int) {
-			for {
-				ind := newIndividual(i, "Player"+strconv.Itoa(i))
-				ind.run(*address)
-				time.Sleep(time.Second * 3)
-			}
-		}(i)
-		time.Sleep(time.Millisecond)
-	}
-	select {}
-}
-
-type individual struct {
-	id     int
-	client *bot.Client
-	player *basic.Player
-}
-
-func newIndividual(id int, name string) (i *individual) {
-	i = new(individual)
-	i.id = id
-	i.client = bot.NewClient()
-	i.client.Auth.Name = name
-	i.player = basic.NewPlayer(i.client, basic.DefaultSettings, basic.EventsListener{
-		GameStart:  i.onGameStart,
-		Disconnect: onDisconnect,
+	s := swarm.New(swarm.Options{
+		Address:      *address,
+		Number:       *number,
+		JoinInterval: *interval,
+		Auth: func(i int) (bot.Auth, error) {
+			return bot.Auth{Name: "Player" + strconv.Itoa(i)}, nil
+		},
+		Setup: setup,
+		Backoff: swarm.Backoff{
+			Initial:    time.Second * 3,
+			Max:        time.Minute,
+			Multiplier: 2,
+		},
 	})
-	return
-}
 
-func (i *individual) run(address string) {
-	// Login
-	err := i.client.JoinServer(address)
-	if err != nil {
-		log.Printf("[%d]Login fail: %v", i.id, err)
-		return
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go report(ctx, s)
+	if err := s.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Fatal(err)
 	}
-	defer i.client.Close()
-	log.Printf("[%d]Login success", i.id)
-
-	// JoinGame
-	if err = i.client.HandleGame(); err == nil {
-		panic("HandleGame never return nil")
-	}
-	log.Printf("[%d] Handle game error: %v", i.id, err)
 }
 
-func (i *individual) onGameStart() error {
-	log.Printf("[%d]Game start", i.id)
+func setup(b *swarm.Bot) error {
+	p := basic.NewPlayer(b.Client, basic.DefaultSettings, basic.EventsListener{})
+	w := world.NewWorld(b.Client, p, world.EventsListener{})
+	w.Cache = b.Swarm.Shared.Chunks
 	return nil
 }
 
-type DisconnectErr struct {
-	Reason chat.Message
-}
-
-func (d DisconnectErr) Error() string {
-	return "disconnect: " + d.Reason.ClearString()
-}
-
-func onDisconnect(reason chat.Message) error {
-	return DisconnectErr{Reason: reason}
+func report(ctx context.Context, s *swarm.Swarm) {
+	ticker := time.NewTicker(time.Second * 5)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		stats := s.Stats()
+		log.Printf("online: %d, joined: %d, failed: %d, kicked: %d, lost: %d, join latency: avg %v max %v, chunks: %d",
+			stats.Online, stats.Joined, stats.Failed, stats.Kicked, stats.Lost,
+			stats.JoinLatencyAvg, stats.JoinLatencyMax, s.Shared.Chunks.Len())
+		if stats.LastError != nil {
+			log.Printf("last error: %v", stats.LastError)
+		}
+	}
 }
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"compress/gzip"
 	_ "embed"
+	"encoding"
 	"fmt"
 	"math/bits"
+	"reflect"
 
 	"github.com/Tnze/go-mc/nbt"
 )
@@ -88,3 +90,64 @@ func init() {
 	}
 	BitsPerBlock = bits.Len(uint(len(StateList)))
 }
+
+// Properties returns the properties of the block in strings, like {"facing": "east", "half": "bottom"}.
+func Properties(b Block) map[string]string {
+	v := reflect.ValueOf(b)
+	t := v.Type()
+	props := make(map[string]string, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		text, err := v.Field(i).Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			panic(err)
+		}
+		props[t.Field(i).Tag.Get("nbt")] = string(text)
+	}
+	return props
+}
+
+// FromProperties returns the state of the block with the properties, like Properties but in reverse.
+// If some properties are missing, the first state of the block with the given properties is returned.
+func FromProperties(name string, props map[string]string) (StateID, error) {
+	b, ok := FromID[name]
+	if !ok {
+		return 0, UnknownBlockErr{name}
+	}
+	v := reflect.New(reflect.TypeOf(b)).Elem()
+	t := v.Type()
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		fields[t.Field(i).Tag.Get("nbt")] = i
+	}
+	for k, text := range props {
+		i, ok := fields[k]
+		if !ok {
+			return 0, fmt.Errorf("unknown property %q of block %s", k, name)
+		}
+		if err := v.Field(i).Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+			return 0, fmt.Errorf("property %q of block %s: %w", k, name, err)
+		}
+	}
+	if id, ok := ToStateID[v.Interface().(Block)]; ok {
+		return id, nil
+	}
+	if len(props) < len(fields) {
+		for id, s := range StateList {
+			if reflect.TypeOf(s) != t {
+				continue
+			}
+			sv := reflect.ValueOf(s)
+			match := true
+			for k := range props {
+				if sv.Field(fields[k]).Interface() != v.Field(fields[k]).Interface() {
+					match = false
+					break
+				}
+			}
+			if match {
+				return StateID(id), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid state of block %s: %v", name, props)
+}
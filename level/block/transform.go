@@ -0,0 +1,236 @@
+package block
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Rotation is the clockwise rotation around the Y axis, seen from above.
+type Rotation byte
+
+const (
+	RotationNone Rotation = iota
+	RotationClockwise90
+	RotationClockwise180
+	RotationCounterclockwise90
+)
+
+// Rotate returns the state turned by the rotation, following the vanilla Block.rotate.
+// The facing, axis, rotation, rail shape, orientation and the north, east, south and west properties are turned.
+func (s StateID) Rotate(rot Rotation) StateID {
+	initTransformTables()
+	for i := Rotation(0); i < rot&3; i++ {
+		s = rotateTable[s]
+	}
+	return s
+}
+
+// Mirror returns the state flipped along the axis, following the vanilla Block.mirror.
+// Mirroring along X swaps east and west, like the FRONT_BACK mirror of the structure blocks,
+// and Z swaps north and south, like LEFT_RIGHT. The states are unchanged by Y, which isn't supported by the game.
+//
+// Besides the directions, the hinges of doors and the types of chests are swapped,
+// and so are the shapes of stairs facing along the axis.
+func (s StateID) Mirror(axis Axis) StateID {
+	initTransformTables()
+	switch axis {
+	case X:
+		return mirrorXTable[s]
+	case Z:
+		return mirrorZTable[s]
+	default:
+		return s
+	}
+}
+
+var (
+	transformOnce sync.Once
+	rotateTable   []StateID // Clockwise by 90 degrees
+	mirrorXTable  []StateID
+	mirrorZTable  []StateID
+)
+
+func initTransformTables() {
+	transformOnce.Do(func() {
+		rotateTable = newTransformTable(rotateClockwise90)
+		mirrorXTable = newTransformTable(transform{
+			dir:    [...]Direction{Down, Up, North, South, East, West},
+			rail:   railMirrorX,
+			rot16:  func(r int) int { return 16 - r },
+			mirror: X,
+		})
+		mirrorZTable = newTransformTable(transform{
+			dir:    [...]Direction{Down, Up, South, North, West, East},
+			rail:   railMirrorZ,
+			rot16:  func(r int) int { return 8 - r },
+			mirror: Z,
+		})
+	})
+}
+
+func newTransformTable(t transform) []StateID {
+	table := make([]StateID, len(StateList))
+	for i, b := range StateList {
+		table[i] = StateID(i)
+		if id, ok := ToStateID[t.apply(b)]; ok {
+			table[i] = id
+		}
+	}
+	return table
+}
+
+// transform is a clockwise rotation by 90 degrees or a mirror of the block properties.
+type transform struct {
+	dir      [6]Direction  // Indexed by Direction
+	rail     [10]RailShape // Indexed by RailShape
+	rot16    func(int) int // The 16 rotation segments clockwise from south, of the signs, banners and skulls
+	swapAxes bool
+	mirror   Axis // The flipped axis, or Y for rotations
+}
+
+var rotateClockwise90 = transform{
+	dir: [...]Direction{
+		Down:  Down,
+		Up:    Up,
+		North: East,
+		South: West,
+		West:  North,
+		East:  South,
+	},
+	rail: [...]RailShape{
+		RailShapeNorthSouth:     RailShapeEastWest,
+		RailShapeEastWest:       RailShapeNorthSouth,
+		RailShapeAscendingEast:  RailShapeAscendingSouth,
+		RailShapeAscendingWest:  RailShapeAscendingNorth,
+		RailShapeAscendingNorth: RailShapeAscendingEast,
+		RailShapeAscendingSouth: RailShapeAscendingWest,
+		RailShapeSouthEast:      RailShapeSouthWest,
+		RailShapeSouthWest:      RailShapeNorthWest,
+		RailShapeNorthWest:      RailShapeNorthEast,
+		RailShapeNorthEast:      RailShapeSouthEast,
+	},
+	rot16:    func(r int) int { return r + 4 },
+	swapAxes: true,
+	mirror:   Y,
+}
+
+var (
+	railMirrorX = [...]RailShape{
+		RailShapeNorthSouth:     RailShapeNorthSouth,
+		RailShapeEastWest:       RailShapeEastWest,
+		RailShapeAscendingEast:  RailShapeAscendingWest,
+		RailShapeAscendingWest:  RailShapeAscendingEast,
+		RailShapeAscendingNorth: RailShapeAscendingNorth,
+		RailShapeAscendingSouth: RailShapeAscendingSouth,
+		RailShapeSouthEast:      RailShapeSouthWest,
+		RailShapeSouthWest:      RailShapeSouthEast,
+		RailShapeNorthWest:      RailShapeNorthEast,
+		RailShapeNorthEast:      RailShapeNorthWest,
+	}
+	railMirrorZ = [...]RailShape{
+		RailShapeNorthSouth:     RailShapeNorthSouth,
+		RailShapeEastWest:       RailShapeEastWest,
+		RailShapeAscendingEast:  RailShapeAscendingEast,
+		RailShapeAscendingWest:  RailShapeAscendingWest,
+		RailShapeAscendingNorth: RailShapeAscendingSouth,
+		RailShapeAscendingSouth: RailShapeAscendingNorth,
+		RailShapeSouthEast:      RailShapeNorthEast,
+		RailShapeSouthWest:      RailShapeNorthWest,
+		RailShapeNorthWest:      RailShapeSouthWest,
+		RailShapeNorthEast:      RailShapeSouthEast,
+	}
+)
+
+func (d Direction) axis() Axis {
+	switch d {
+	case East, West:
+		return X
+	case North, South:
+		return Z
+	default:
+		return Y
+	}
+}
+
+func (t *transform) apply(b Block) Block {
+	v := reflect.New(reflect.TypeOf(b)).Elem()
+	v.Set(reflect.ValueOf(b))
+	typ := v.Type()
+
+	sides := make(map[Direction]reflect.Value, 4) // The old values of the north, east, south and west properties
+	sideFields := make(map[Direction]int, 4)
+	var facing Direction
+	var shape *StairsShape
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Tag.Get("nbt")
+		switch p := v.Field(i).Addr().Interface().(type) {
+		case *Direction:
+			if name == "facing" {
+				facing = *p
+			}
+			*p = t.dir[*p]
+		case *Axis:
+			if t.swapAxes && *p == X {
+				*p = Z
+			} else if t.swapAxes && *p == Z {
+				*p = X
+			}
+		case *RailShape:
+			*p = t.rail[*p]
+		case *FrontAndTop:
+			front, top := p.Directions()
+			*p = frontAndTop(t.dir[front], t.dir[top])
+		case *Integer:
+			if name == "rotation" {
+				*p = Integer((t.rot16(int(*p)) + 16) % 16)
+			}
+		case *StairsShape:
+			shape = p
+		case *DoorHingeSide:
+			if t.mirror != Y && *p == DoorHingeSideLeft {
+				*p = DoorHingeSideRight
+			} else if t.mirror != Y && *p == DoorHingeSideRight {
+				*p = DoorHingeSideLeft
+			}
+		case *ChestType:
+			if t.mirror != Y && *p == ChestTypeLeft {
+				*p = ChestTypeRight
+			} else if t.mirror != Y && *p == ChestTypeRight {
+				*p = ChestTypeLeft
+			}
+		}
+		var side Direction
+		if err := side.UnmarshalText([]byte(name)); err == nil && side != Up && side != Down {
+			sides[side] = reflect.ValueOf(v.Field(i).Interface())
+			sideFields[side] = i
+		}
+	}
+	for side, value := range sides {
+		if i, ok := sideFields[t.dir[side]]; ok {
+			v.Field(i).Set(value)
+		}
+	}
+	// The vanilla only swaps the shape of the stairs facing the flipped axis
+	if shape != nil && t.mirror != Y && facing.axis() == t.mirror {
+		switch *shape {
+		case StairsShapeInnerLeft:
+			*shape = StairsShapeInnerRight
+		case StairsShapeInnerRight:
+			*shape = StairsShapeInnerLeft
+		case StairsShapeOuterLeft:
+			*shape = StairsShapeOuterRight
+		case StairsShapeOuterRight:
+			*shape = StairsShapeOuterLeft
+		}
+	}
+	return v.Interface().(Block)
+}
+
+func frontAndTop(front, top Direction) FrontAndTop {
+	for f := DownEast; f <= SouthUp; f++ {
+		if ff, ft := f.Directions(); ff == front && ft == top {
+			return f
+		}
+	}
+	panic("invalid FrontAndTop")
+}
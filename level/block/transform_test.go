@@ -0,0 +1,75 @@
+package block
+
+import "testing"
+
+func TestStateID_Rotate(t *testing.T) {
+	for _, tt := range []struct {
+		rot      Rotation
+		from, to Block
+	}{
+		{RotationClockwise90, OakStairs{Facing: North, Half: Bottom, Shape: StairsShapeInnerLeft}, OakStairs{Facing: East, Half: Bottom, Shape: StairsShapeInnerLeft}},
+		{RotationCounterclockwise90, OakStairs{Facing: North, Half: Top}, OakStairs{Facing: West, Half: Top}},
+		{RotationClockwise90, Rail{Shape: RailShapeNorthEast}, Rail{Shape: RailShapeSouthEast}},
+		{RotationClockwise180, Rail{Shape: RailShapeAscendingNorth}, Rail{Shape: RailShapeAscendingSouth}},
+		{RotationCounterclockwise90, PoweredRail{Shape: RailShapeAscendingEast}, PoweredRail{Shape: RailShapeAscendingNorth}},
+		{RotationClockwise90, OakSign{Rotation: 1}, OakSign{Rotation: 5}},
+		{RotationCounterclockwise90, WhiteBanner{Rotation: 2}, WhiteBanner{Rotation: 14}},
+		{RotationClockwise90, OakWallSign{Facing: South}, OakWallSign{Facing: West}},
+		{RotationClockwise90, Vine{North: true, Up: true}, Vine{East: true, Up: true}},
+		{RotationClockwise180, CobblestoneWall{North: WallSideTall, East: WallSideLow, Up: true}, CobblestoneWall{South: WallSideTall, West: WallSideLow, Up: true}},
+		{RotationClockwise90, OakFence{North: true, West: true}, OakFence{North: true, East: true}},
+		{RotationClockwise90, OakLog{Axis: X}, OakLog{Axis: Z}},
+		{RotationClockwise90, OakLog{Axis: Y}, OakLog{Axis: Y}},
+		{RotationClockwise90, Hopper{Facing: Down, Enabled: true}, Hopper{Facing: Down, Enabled: true}},
+		{RotationClockwise90, Jigsaw{Orientation: NorthUp}, Jigsaw{Orientation: EastUp}},
+		{RotationClockwise90, Jigsaw{Orientation: DownNorth}, Jigsaw{Orientation: DownEast}},
+	} {
+		if got := ToStateID[tt.from].Rotate(tt.rot); got != ToStateID[tt.to] {
+			t.Errorf("rotate %#v by %d: got %#v, want %#v", tt.from, tt.rot, StateList[got], tt.to)
+		}
+	}
+}
+
+func TestStateID_Mirror(t *testing.T) {
+	for _, tt := range []struct {
+		axis     Axis
+		from, to Block
+	}{
+		{Z, OakStairs{Facing: North, Half: Bottom, Shape: StairsShapeInnerLeft}, OakStairs{Facing: South, Half: Bottom, Shape: StairsShapeInnerRight}},
+		// The vanilla doesn't change the stairs facing the other axis
+		{Z, OakStairs{Facing: East, Half: Bottom, Shape: StairsShapeOuterLeft}, OakStairs{Facing: East, Half: Bottom, Shape: StairsShapeOuterLeft}},
+		{X, OakStairs{Facing: East, Half: Bottom, Shape: StairsShapeOuterLeft}, OakStairs{Facing: West, Half: Bottom, Shape: StairsShapeOuterRight}},
+		{Z, Rail{Shape: RailShapeAscendingNorth}, Rail{Shape: RailShapeAscendingSouth}},
+		{X, Rail{Shape: RailShapeSouthEast}, Rail{Shape: RailShapeSouthWest}},
+		{Z, OakSign{Rotation: 1}, OakSign{Rotation: 7}},
+		{X, OakSign{Rotation: 1}, OakSign{Rotation: 15}},
+		{X, WhiteBanner{Rotation: 0}, WhiteBanner{Rotation: 0}},
+		{X, Vine{East: true}, Vine{West: true}},
+		{Z, CobblestoneWall{North: WallSideTall, East: WallSideLow}, CobblestoneWall{South: WallSideTall, East: WallSideLow}},
+		{X, OakFence{North: true, East: true}, OakFence{North: true, West: true}},
+		{X, OakDoor{Facing: North, Half: DoubleBlockHalfLower, Hinge: DoorHingeSideLeft}, OakDoor{Facing: North, Half: DoubleBlockHalfLower, Hinge: DoorHingeSideRight}},
+		{Z, Chest{Facing: North, Type: ChestTypeLeft}, Chest{Facing: South, Type: ChestTypeRight}},
+		{Y, OakWallSign{Facing: North}, OakWallSign{Facing: North}},
+	} {
+		if got := ToStateID[tt.from].Mirror(tt.axis); got != ToStateID[tt.to] {
+			t.Errorf("mirror %#v along %v: got %#v, want %#v", tt.from, tt.axis, StateList[got], tt.to)
+		}
+	}
+}
+
+func TestStateID_transformIdentity(t *testing.T) {
+	for i := range StateList {
+		s := StateID(i)
+		if got := s.Rotate(RotationClockwise90).Rotate(RotationClockwise180).Rotate(RotationClockwise90); got != s {
+			t.Fatalf("rotating %#v by 360 degrees: %#v", StateList[s], StateList[got])
+		}
+		if got := s.Rotate(RotationCounterclockwise90).Rotate(RotationClockwise90); got != s {
+			t.Fatalf("rotating %#v back: %#v", StateList[s], StateList[got])
+		}
+		for _, axis := range []Axis{X, Z} {
+			if got := s.Mirror(axis).Mirror(axis); got != s {
+				t.Fatalf("mirroring %#v along %v twice: %#v", StateList[s], axis, StateList[got])
+			}
+		}
+	}
+}
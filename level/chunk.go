@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"math/bits"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/Tnze/go-mc/level/block"
 	"github.com/Tnze/go-mc/nbt"
@@ -43,6 +45,23 @@ type Chunk struct {
 	HeightMaps  HeightMaps
 	BlockEntity []BlockEntity
 	Status      ChunkStatus
+
+	// The following data are only used by the server, and aren't sent to the client.
+	// They are kept by ChunkFromSave and ChunkToSave.
+	BlockTicks     []ScheduledTick
+	FluidTicks     []ScheduledTick
+	PostProcessing nbt.RawMessage // The positions of the blocks to be updated, by sections
+	Structures     nbt.RawMessage // The structure starts and references
+}
+
+// ScheduledTick is a block or fluid update which will happen later, like the growth of the sugarcanes.
+type ScheduledTick struct {
+	Type     string `nbt:"i"` // The ID of the block or fluid
+	X        int32  `nbt:"x"`
+	Y        int32  `nbt:"y"`
+	Z        int32  `nbt:"z"`
+	Delay    int32  `nbt:"t"` // In game ticks
+	Priority int32  `nbt:"p"` // The lower the earlier, from -3 to 3
 }
 
 func EmptyChunk(secs int) *Chunk {
@@ -70,9 +89,18 @@ func EmptyChunk(secs int) *Chunk {
 
 // ChunkFromSave convert save.Chunk to level.Chunk.
 func ChunkFromSave(c *save.Chunk) (*Chunk, error) {
-	secs := len(c.Sections)
+	// The sections only contain light data (one below and one above the chunk) are ignored.
+	var secs int
+	for _, v := range c.Sections {
+		if len(v.BlockStates.Palette) > 0 {
+			secs++
+		}
+	}
 	sections := make([]Section, secs)
 	for _, v := range c.Sections {
+		if len(v.BlockStates.Palette) == 0 {
+			continue
+		}
 		i := int32(v.Y) - c.YPos
 		if i < 0 || i >= int32(secs) {
 			return nil, fmt.Errorf("section Y value %d out of bounds", v.Y)
@@ -90,6 +118,7 @@ func ChunkFromSave(c *save.Chunk) (*Chunk, error) {
 		sections[i].SkyLight = v.SkyLight
 		sections[i].BlockLight = v.BlockLight
 	}
+	var chunk Chunk
 
 	blockEntities := make([]BlockEntity, len(c.BlockEntities))
 	for i, v := range c.BlockEntities {
@@ -107,23 +136,60 @@ func ChunkFromSave(c *save.Chunk) (*Chunk, error) {
 			return nil, errors.New("Packing a XZ(" + strconv.Itoa(x) + ", " + strconv.Itoa(z) + ") out of bound")
 		}
 		blockEntities[i].Y = int16(tmp.Y)
-		blockEntities[i].Type = block.EntityTypes[tmp.ID]
+		var ok bool
+		if blockEntities[i].Type, ok = block.EntityTypes[tmp.ID]; !ok {
+			return nil, fmt.Errorf("unknown block entity id: %v", tmp.ID)
+		}
 	}
 
+	var blockTicks, fluidTicks []ScheduledTick
+	if err := unmarshalOptional(c.BlockTicks, &blockTicks); err != nil {
+		return nil, fmt.Errorf("unmarshal block ticks fail: %v", err)
+	}
+	if err := unmarshalOptional(c.FluidTicks, &fluidTicks); err != nil {
+		return nil, fmt.Errorf("unmarshal fluid ticks fail: %v", err)
+	}
+
+	// The heightmaps not in the save are left nil, like the *_WG ones of the fully generated chunks.
 	bitsForHeight := bits.Len( /* chunk height in blocks */ uint(secs)*16 + 1)
-	return &Chunk{
-		Sections: sections,
-		HeightMaps: HeightMaps{
-			WorldSurface:           NewBitStorage(bitsForHeight, 16*16, c.Heightmaps["WORLD_SURFACE_WG"]),
-			WorldSurfaceWG:         NewBitStorage(bitsForHeight, 16*16, c.Heightmaps["WORLD_SURFACE"]),
-			OceanFloorWG:           NewBitStorage(bitsForHeight, 16*16, c.Heightmaps["OCEAN_FLOOR_WG"]),
-			OceanFloor:             NewBitStorage(bitsForHeight, 16*16, c.Heightmaps["OCEAN_FLOOR"]),
-			MotionBlocking:         NewBitStorage(bitsForHeight, 16*16, c.Heightmaps["MOTION_BLOCKING"]),
-			MotionBlockingNoLeaves: NewBitStorage(bitsForHeight, 16*16, c.Heightmaps["MOTION_BLOCKING_NO_LEAVES"]),
-		},
-		BlockEntity: blockEntities,
-		Status:      ChunkStatus(c.Status),
-	}, nil
+	heightmaps := [...]struct {
+		name string
+		dst  **BitStorage
+	}{
+		{"WORLD_SURFACE_WG", &chunk.HeightMaps.WorldSurfaceWG},
+		{"WORLD_SURFACE", &chunk.HeightMaps.WorldSurface},
+		{"OCEAN_FLOOR_WG", &chunk.HeightMaps.OceanFloorWG},
+		{"OCEAN_FLOOR", &chunk.HeightMaps.OceanFloor},
+		{"MOTION_BLOCKING", &chunk.HeightMaps.MotionBlocking},
+		{"MOTION_BLOCKING_NO_LEAVES", &chunk.HeightMaps.MotionBlockingNoLeaves},
+	}
+	for _, v := range heightmaps {
+		data, ok := c.Heightmaps[v.name]
+		if !ok {
+			continue
+		}
+		if want := calcBitStorageSize(bitsForHeight, 16*16); len(data) != want {
+			return nil, fmt.Errorf("heightmap %s length %d mismatch, want %d", v.name, len(data), want)
+		}
+		*v.dst = NewBitStorage(bitsForHeight, 16*16, data)
+	}
+
+	chunk.Sections = sections
+	chunk.BlockEntity = blockEntities
+	chunk.Status = ChunkStatus(strings.TrimPrefix(c.Status, "minecraft:"))
+	chunk.BlockTicks = blockTicks
+	chunk.FluidTicks = fluidTicks
+	chunk.PostProcessing = c.PostProcessing
+	chunk.Structures = c.Structures
+	return &chunk, nil
+}
+
+// unmarshalOptional decodes the data into v, which is left unchanged if the data doesn't exist.
+func unmarshalOptional(data nbt.RawMessage, v any) error {
+	if data.Type == nbt.TagEnd {
+		return nil
+	}
+	return data.Unmarshal(v)
 }
 
 func readStatesPalette(palette []save.BlockState, data []uint64) (paletteData *PaletteContainer[BlocksState], err error) {
@@ -169,10 +235,15 @@ func countNoneAirBlocks(sec *Section) (blockCount int16) {
 	return
 }
 
-// ChunkToSave convert level.Chunk to save.Chunk
+// ChunkToSave convert level.Chunk to save.Chunk.
+//
+// The position and the DataVersion of dst should be set by the caller.
+// The data not kept by level.Chunk, like the InhabitedTime and the light of the sections
+// above and below the world, are left unchanged in dst.
+// So a chunk loaded by ChunkFromSave can be saved back into the save.Chunk it's loaded from without loss.
 func ChunkToSave(c *Chunk, dst *save.Chunk) (err error) {
 	secs := len(c.Sections)
-	sections := make([]save.Section, secs)
+	sections := make([]save.Section, secs, secs+2)
 	for i, v := range c.Sections {
 		s := &sections[i]
 		states := &s.BlockStates
@@ -189,20 +260,130 @@ func ChunkToSave(c *Chunk, dst *save.Chunk) (err error) {
 		s.SkyLight = v.SkyLight
 		s.BlockLight = v.BlockLight
 	}
+	// Keep the sections out of the chunk, which only contain the light data.
+	for _, v := range dst.Sections {
+		if i := int32(v.Y) - dst.YPos; i < 0 || i >= int32(secs) {
+			sections = append(sections, v)
+		}
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].Y < sections[j].Y })
 	dst.Sections = sections
+
 	if dst.Heightmaps == nil {
 		dst.Heightmaps = make(map[string][]uint64)
 	}
-	dst.Heightmaps["WORLD_SURFACE_WG"] = c.HeightMaps.WorldSurfaceWG.Raw()
-	dst.Heightmaps["WORLD_SURFACE"] = c.HeightMaps.WorldSurface.Raw()
-	dst.Heightmaps["OCEAN_FLOOR_WG"] = c.HeightMaps.OceanFloorWG.Raw()
-	dst.Heightmaps["OCEAN_FLOOR"] = c.HeightMaps.OceanFloor.Raw()
-	dst.Heightmaps["MOTION_BLOCKING"] = c.HeightMaps.MotionBlocking.Raw()
-	dst.Heightmaps["MOTION_BLOCKING_NO_LEAVES"] = c.HeightMaps.MotionBlockingNoLeaves.Raw()
-	dst.Status = string(c.Status)
+	heightmaps := [...]struct {
+		name string
+		src  *BitStorage
+	}{
+		{"WORLD_SURFACE_WG", c.HeightMaps.WorldSurfaceWG},
+		{"WORLD_SURFACE", c.HeightMaps.WorldSurface},
+		{"OCEAN_FLOOR_WG", c.HeightMaps.OceanFloorWG},
+		{"OCEAN_FLOOR", c.HeightMaps.OceanFloor},
+		{"MOTION_BLOCKING", c.HeightMaps.MotionBlocking},
+		{"MOTION_BLOCKING_NO_LEAVES", c.HeightMaps.MotionBlockingNoLeaves},
+	}
+	for _, v := range heightmaps {
+		if v.src == nil {
+			delete(dst.Heightmaps, v.name)
+		} else {
+			dst.Heightmaps[v.name] = v.src.Raw()
+		}
+	}
+
+	dst.BlockEntities = make([]nbt.RawMessage, len(c.BlockEntity))
+	for i, v := range c.BlockEntity {
+		dst.BlockEntities[i], err = blockEntityToSave(dst.XPos, dst.ZPos, v)
+		if err != nil {
+			return
+		}
+	}
+	if dst.BlockTicks, err = marshalRaw(nonNil(c.BlockTicks)); err != nil {
+		return
+	}
+	if dst.FluidTicks, err = marshalRaw(nonNil(c.FluidTicks)); err != nil {
+		return
+	}
+	dst.PostProcessing = c.PostProcessing
+	if dst.PostProcessing.Type == nbt.TagEnd {
+		dst.PostProcessing = emptyList
+	}
+	dst.Structures = c.Structures
+	if dst.Structures.Type == nbt.TagEnd {
+		dst.Structures = emptyStructures
+	}
+
+	// The statuses are namespaced since 1.20.5, keep the format of the older chunks.
+	status := c.Status
+	if status == "" {
+		status = StatusFull
+	}
+	if dst.Status != "" && !strings.HasPrefix(dst.Status, "minecraft:") {
+		dst.Status = string(status)
+	} else {
+		dst.Status = "minecraft:" + string(status)
+	}
 	return
 }
 
+var (
+	emptyList       = mustMarshalRaw([]struct{}{})
+	emptyStructures = mustMarshalRaw(struct {
+		References struct{} `nbt:"References"`
+		Starts     struct{} `nbt:"starts"`
+	}{})
+)
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// marshalRaw encodes v into a nbt.RawMessage.
+func marshalRaw(v any) (raw nbt.RawMessage, err error) {
+	var buf bytes.Buffer
+	if err = nbt.NewEncoder(&buf).Encode(v, ""); err != nil {
+		return
+	}
+	_, err = nbt.NewDecoder(&buf).Decode(&raw)
+	return
+}
+
+func mustMarshalRaw(v any) nbt.RawMessage {
+	raw, err := marshalRaw(v)
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// blockEntityToSave sets the id and coordinates of the block entity data,
+// which are not included in the data received from the server.
+func blockEntityToSave(cx, cz int32, be BlockEntity) (nbt.RawMessage, error) {
+	if be.Type < 0 || int(be.Type) >= len(block.EntityList) {
+		return nbt.RawMessage{}, fmt.Errorf("unknown block entity type: %d", be.Type)
+	}
+	var data struct {
+		ID string `nbt:"id"`
+		X  int32  `nbt:"x"`
+		Y  int32  `nbt:"y"`
+		Z  int32  `nbt:"z"`
+
+		Rest map[string]nbt.RawMessage `nbt:",rest"`
+	}
+	if err := unmarshalOptional(be.Data, &data); err != nil {
+		return nbt.RawMessage{}, err
+	}
+	x, z := be.UnpackXZ()
+	data.ID = block.EntityList[be.Type].ID()
+	data.X = cx<<4 + int32(x)
+	data.Y = int32(be.Y)
+	data.Z = cz<<4 + int32(z)
+	return marshalRaw(data)
+}
+
 func writeStatesPalette(paletteData *PaletteContainer[BlocksState]) (palette []save.BlockState, data []uint64, err error) {
 	rawPalette := paletteData.palette.export()
 	palette = make([]save.BlockState, len(rawPalette))
@@ -221,6 +402,10 @@ func writeStatesPalette(paletteData *PaletteContainer[BlocksState]) (palette []s
 		if err != nil {
 			return
 		}
+		// The blocks without properties, whose data is an empty compound with only a TagEnd
+		if len(palette[i].Properties.Data) == 1 {
+			palette[i].Properties = nbt.RawMessage{}
+		}
 	}
 
 	data = make([]uint64, len(paletteData.data.Raw()))
@@ -251,19 +436,21 @@ func (c *Chunk) WriteTo(w io.Writer) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	// The light sections start from one section below the chunk, and end at one section above.
+	lightSecs := len(c.Sections) + 2
 	light := lightData{
-		SkyLightMask:   make(pk.BitSet, (16*16*16-1)>>6+1),
-		BlockLightMask: make(pk.BitSet, (16*16*16-1)>>6+1),
+		SkyLightMask:   make(pk.BitSet, (lightSecs-1)>>6+1),
+		BlockLightMask: make(pk.BitSet, (lightSecs-1)>>6+1),
 		SkyLight:       []pk.ByteArray{},
 		BlockLight:     []pk.ByteArray{},
 	}
 	for i, v := range c.Sections {
 		if v.SkyLight != nil {
-			light.SkyLightMask.Set(i, true)
+			light.SkyLightMask.Set(i+1, true)
 			light.SkyLight = append(light.SkyLight, v.SkyLight)
 		}
 		if v.BlockLight != nil {
-			light.BlockLightMask.Set(i, true)
+			light.BlockLightMask.Set(i+1, true)
 			light.BlockLight = append(light.BlockLight, v.BlockLight)
 		}
 	}
@@ -439,7 +626,6 @@ func bitSetRev(set pk.BitSet) pk.BitSet {
 
 func (l *lightData) WriteTo(w io.Writer) (int64, error) {
 	return pk.Tuple{
-		pk.Boolean(true), // Trust Edges
 		l.SkyLightMask,
 		l.BlockLightMask,
 		bitSetRev(l.SkyLightMask),
@@ -0,0 +1,207 @@
+package level
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/Tnze/go-mc/save"
+	"github.com/Tnze/go-mc/save/region"
+)
+
+// loadTestChunks returns the chunks in the region files of the vanilla world in save/testdata,
+// which are upgraded to the current version.
+func loadTestChunks(t *testing.T) []*save.Chunk {
+	files, err := filepath.Glob("../save/testdata/region/r.*.*.mca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks []*save.Chunk
+	for _, filename := range files {
+		r, err := region.Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x := 0; x < 32; x++ {
+			for z := 0; z < 32; z++ {
+				if !r.ExistSector(x, z) {
+					continue
+				}
+				data, err := r.ReadSector(x, z)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var c save.Chunk
+				if err := c.Load(data); err != nil {
+					t.Fatal(err)
+				}
+				if err := c.Upgrade(); err != nil {
+					t.Fatal(err)
+				}
+				chunks = append(chunks, &c)
+			}
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(chunks) == 0 {
+		t.Fatal("no chunk found")
+	}
+	return chunks
+}
+
+// chunkNBT returns the chunk in the generic form, for comparing.
+func chunkNBT(t *testing.T, c *save.Chunk) map[string]any {
+	t.Helper()
+	data, err := c.Data(save.CompressionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if _, err := nbt.NewDecoder(bytes.NewReader(data[1:])).Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestChunkFromSave_lossless(t *testing.T) {
+	for _, c := range loadTestChunks(t) {
+		want := chunkNBT(t, c)
+		lc, err := ChunkFromSave(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Save back into the chunk it's loaded from
+		if err := ChunkToSave(lc, c); err != nil {
+			t.Fatal(err)
+		}
+		got := chunkNBT(t, c)
+		assertSameNBT(t, c, want, got, "sections")
+
+		// The block states saved by older versions are upgraded, like the waterlogged leaves since 1.19,
+		// so the sections are compared after converted once.
+		lc, err = ChunkFromSave(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ChunkToSave(lc, c); err != nil {
+			t.Fatal(err)
+		}
+		assertSameNBT(t, c, got, chunkNBT(t, c))
+
+		// Save into a new chunk, the data kept by level.Chunk should be the same
+		dst := save.Chunk{DataVersion: c.DataVersion, XPos: c.XPos, YPos: c.YPos, ZPos: c.ZPos}
+		if err := ChunkToSave(lc, &dst); err != nil {
+			t.Fatal(err)
+		}
+		got = chunkNBT(t, &dst)
+		want = chunkNBT(t, c)
+		for _, k := range []string{"Heightmaps", "block_entities", "block_ticks", "fluid_ticks", "PostProcessing", "structures"} {
+			if !reflect.DeepEqual(want[k], got[k]) {
+				t.Errorf("chunk (%d, %d): %s mismatch in the new chunk", c.XPos, c.ZPos, k)
+			}
+		}
+		if got, want := dst.Status, "minecraft:"+c.Status; got != want {
+			t.Errorf("status: got %s, want %s", got, want)
+		}
+	}
+}
+
+// assertSameNBT checks if the two chunks in the generic form are the same, except the ignored tags.
+func assertSameNBT(t *testing.T, c *save.Chunk, want, got map[string]any, ignore ...string) {
+	t.Helper()
+	for k, v := range want {
+		if !slices.Contains(ignore, k) && !reflect.DeepEqual(v, got[k]) {
+			t.Errorf("chunk (%d, %d): %s mismatch", c.XPos, c.ZPos, k)
+		}
+	}
+	for k := range got {
+		if _, ok := want[k]; !ok {
+			t.Errorf("chunk (%d, %d): unexpected %s", c.XPos, c.ZPos, k)
+		}
+	}
+}
+
+func TestChunkToSave_blockEntity(t *testing.T) {
+	c := loadTestChunks(t)[0]
+	lc, err := ChunkFromSave(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A chest received from the server, whose data has no id or coordinates
+	items, err := marshalRaw(struct {
+		Items []struct {
+			ID    string `nbt:"id"`
+			Count int32  `nbt:"count"`
+			Slot  byte   `nbt:"Slot"`
+		}
+	}{Items: []struct {
+		ID    string `nbt:"id"`
+		Count int32  `nbt:"count"`
+		Slot  byte   `nbt:"Slot"`
+	}{{ID: "minecraft:diamond", Count: 64, Slot: 13}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chest := BlockEntity{Y: 70, Type: block.EntityTypes["minecraft:chest"], Data: items}
+	chest.PackXZ(3, 12)
+	// A sign without any data
+	sign := BlockEntity{Y: -10, Type: block.EntityTypes["minecraft:sign"]}
+	sign.PackXZ(15, 0)
+	lc.BlockEntity = append(lc.BlockEntity, chest, sign)
+	lc.BlockTicks = append(lc.BlockTicks, ScheduledTick{Type: "minecraft:sugar_cane", X: c.XPos << 4, Y: 64, Z: c.ZPos << 4, Delay: 3})
+
+	if err := ChunkToSave(lc, c); err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.Data(save.CompressionZlib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c2 save.Chunk
+	if err := c2.Load(data); err != nil {
+		t.Fatal(err)
+	}
+
+	n := len(c2.BlockEntities)
+	if n < 2 {
+		t.Fatalf("block entities lost: %d", n)
+	}
+	var saved struct {
+		ID    string `nbt:"id"`
+		X     int32  `nbt:"x"`
+		Y     int32  `nbt:"y"`
+		Z     int32  `nbt:"z"`
+		Items []struct {
+			ID    string `nbt:"id"`
+			Count int32  `nbt:"count"`
+		}
+	}
+	if err := c2.BlockEntities[n-2].Unmarshal(&saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.ID != "minecraft:chest" || saved.X != c.XPos<<4+3 || saved.Y != 70 || saved.Z != c.ZPos<<4+12 {
+		t.Errorf("chest: %+v", saved)
+	}
+	if len(saved.Items) != 1 || saved.Items[0].ID != "minecraft:diamond" || saved.Items[0].Count != 64 {
+		t.Errorf("chest items: %+v", saved.Items)
+	}
+
+	lc2, err := ChunkFromSave(&c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lc2.BlockEntity[len(lc2.BlockEntity)-1]; got.Type != sign.Type || got.XZ != sign.XZ || got.Y != sign.Y {
+		t.Errorf("sign: got %+v, want %+v", got, sign)
+	}
+	if !reflect.DeepEqual(lc2.BlockTicks, lc.BlockTicks) {
+		t.Errorf("block ticks mismatch")
+	}
+}
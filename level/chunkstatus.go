@@ -12,6 +12,7 @@ const (
 	StatusCarvers             ChunkStatus = "carvers"
 	StatusLiquidCarvers       ChunkStatus = "liquid_carvers"
 	StatusFeatures            ChunkStatus = "features"
+	StatusInitializeLight     ChunkStatus = "initialize_light"
 	StatusLight               ChunkStatus = "light"
 	StatusSpawn               ChunkStatus = "spawn"
 	StatusHeightmaps          ChunkStatus = "heightmaps"
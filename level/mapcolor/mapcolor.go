@@ -0,0 +1,131 @@
+// Package mapcolor contains the color palette of the map item, and renders map colors to images.
+//
+// A map stores a byte for each of its 128×128 pixels.
+// The byte is the base color ID multiplied by 4, plus the shade of the color.
+package mapcolor
+
+import (
+	"image"
+	"image/color"
+)
+
+// Size is the width and height of a map, in pixels.
+const Size = 128
+
+// Shade is the brightness modifier of a base color.
+type Shade byte
+
+const (
+	ShadeLow Shade = iota
+	ShadeNormal
+	ShadeHigh
+	ShadeLowest
+)
+
+// multiplier of each shade, divided by 255
+var shadeMultipliers = [...]uint32{180, 220, 255, 135}
+
+// BaseColors are the base colors of the map, indexed by the base color ID.
+// The ID 0 is the transparent color.
+var BaseColors = [...]uint32{
+	0x000000, // none
+	0x7FB238, // grass
+	0xF7E9A3, // sand
+	0xC7C7C7, // wool
+	0xFF0000, // fire
+	0xA0A0FF, // ice
+	0xA7A7A7, // metal
+	0x007C00, // plant
+	0xFFFFFF, // snow
+	0xA4A8B8, // clay
+	0x976D4D, // dirt
+	0x707070, // stone
+	0x4040FF, // water
+	0x8F7748, // wood
+	0xFFFCF5, // quartz
+	0xD87F33, // color_orange
+	0xB24CD8, // color_magenta
+	0x6699D8, // color_light_blue
+	0xE5E533, // color_yellow
+	0x7FCC19, // color_light_green
+	0xF27FA5, // color_pink
+	0x4C4C4C, // color_gray
+	0x999999, // color_light_gray
+	0x4C7F99, // color_cyan
+	0x7F3FB2, // color_purple
+	0x334CB2, // color_blue
+	0x664C33, // color_brown
+	0x667F33, // color_green
+	0x993333, // color_red
+	0x191919, // color_black
+	0xFAEE4D, // gold
+	0x5CDBD5, // diamond
+	0x4A80FF, // lapis
+	0x00D93A, // emerald
+	0x815631, // podzol
+	0x700200, // nether
+	0xD1B1A1, // terracotta_white
+	0x9F5224, // terracotta_orange
+	0x95576C, // terracotta_magenta
+	0x706C8A, // terracotta_light_blue
+	0xBA8524, // terracotta_yellow
+	0x677535, // terracotta_light_green
+	0xA04D4E, // terracotta_pink
+	0x392923, // terracotta_gray
+	0x876B62, // terracotta_light_gray
+	0x575C5C, // terracotta_cyan
+	0x7A4958, // terracotta_purple
+	0x4C3E5C, // terracotta_blue
+	0x4C3223, // terracotta_brown
+	0x4C522A, // terracotta_green
+	0x8E3C2E, // terracotta_red
+	0x251610, // terracotta_black
+	0xBD3031, // crimson_nylium
+	0x943F61, // crimson_stem
+	0x5C191D, // crimson_hyphae
+	0x167E86, // warped_nylium
+	0x3A8E8C, // warped_stem
+	0x562C3E, // warped_hyphae
+	0x14B485, // warped_wart_block
+	0x646464, // deepslate
+	0xD8AF93, // raw_iron
+	0x7FA796, // glow_lichen
+}
+
+// Palette is the vanilla map color palette, indexed by the byte stored in maps.
+var Palette = func() color.Palette {
+	p := make(color.Palette, len(BaseColors)*4)
+	for i, rgb := range BaseColors {
+		for s, m := range shadeMultipliers {
+			if i == 0 {
+				p[i*4+s] = color.RGBA{}
+				continue
+			}
+			p[i*4+s] = color.RGBA{
+				R: uint8((rgb >> 16 & 0xFF) * m / 255),
+				G: uint8((rgb >> 8 & 0xFF) * m / 255),
+				B: uint8((rgb & 0xFF) * m / 255),
+				A: 0xFF,
+			}
+		}
+	}
+	return p
+}()
+
+// ID returns the byte stored in maps of the base color and the shade.
+func ID(base int, shade Shade) byte {
+	return byte(base<<2 | int(shade&3))
+}
+
+// Render creates an image of the map colors.
+// The colors is a 128×128 array, indexed by x + z*128.
+// Unknown colors are rendered as transparent.
+func Render(colors []byte) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, Size, Size), Palette)
+	for i := 0; i < len(colors) && i < len(img.Pix); i++ {
+		if int(colors[i]) < len(Palette) {
+			img.Pix[i] = colors[i]
+		}
+	}
+	return img
+}
@@ -0,0 +1,30 @@
+package mapcolor
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestPalette(t *testing.T) {
+	// grass, normal shade
+	want := color.RGBA{R: 0x6D, G: 0x99, B: 0x30, A: 0xFF}
+	if got := Palette[ID(1, ShadeNormal)]; got != want {
+		t.Errorf("palette of grass: got %v, want %v", got, want)
+	}
+	if _, _, _, a := Palette[ID(0, ShadeHigh)].RGBA(); a != 0 {
+		t.Error("base color 0 should be transparent")
+	}
+}
+
+func TestRender(t *testing.T) {
+	colors := make([]byte, Size*Size)
+	colors[1+2*Size] = ID(12, ShadeHigh)
+	colors[3] = 0xFF // unknown
+	img := Render(colors)
+	if got, want := img.At(1, 2), Palette[ID(12, ShadeHigh)]; got != want {
+		t.Errorf("pixel (1, 2): got %v, want %v", got, want)
+	}
+	if _, _, _, a := img.At(3, 0).RGBA(); a != 0 {
+		t.Error("unknown colors should be transparent")
+	}
+}
@@ -0,0 +1,140 @@
+package msauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TokenCache stores the sessions by a key chosen by the user, e.g. the account email.
+type TokenCache interface {
+	// Load returns the cached session, or nil if not found.
+	Load(key string) (*Session, error)
+	Store(key string, s *Session) error
+}
+
+// FileCache stores the sessions in a JSON file.
+// It's safe for concurrent use by the goroutines of a process.
+type FileCache struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *FileCache) read() (map[string]*Session, error) {
+	sessions := make(map[string]*Session)
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return sessions, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &sessions)
+	return sessions, err
+}
+
+func (f *FileCache) Load(key string) (*Session, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sessions, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+	return sessions[key], nil
+}
+
+func (f *FileCache) Store(key string, s *Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sessions, err := f.read()
+	if err != nil {
+		return err
+	}
+	sessions[key] = s
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o700); err != nil {
+		return err
+	}
+	// the file contains the tokens, only the owner can read it
+	return os.WriteFile(f.Path, data, 0o600)
+}
+
+// expiryMargin is the time before the expiration that a token is considered expired.
+const expiryMargin = 5 * time.Minute
+
+// Refresh renews the Minecraft access token of the session with its Microsoft token.
+// The Microsoft token is refreshed too if it's expired.
+func (c *Client) Refresh(ctx context.Context, s *Session) (*Session, error) {
+	if s.Microsoft == nil {
+		return nil, errors.New("msauth: session has no microsoft token")
+	}
+	ms := s.Microsoft
+	if time.Now().Add(expiryMargin).After(ms.ExpiresAt) {
+		var err error
+		if ms, err = c.RefreshToken(ctx, ms.RefreshToken); err != nil {
+			return nil, err
+		}
+	}
+	return c.Login(ctx, ms)
+}
+
+// Authenticate returns a valid session of the key.
+//
+// The session is loaded from the Cache if possible, and refreshed if it's expired.
+// Otherwise, the device code flow is started and prompt is called to show the code to the user.
+// The new session is stored into the Cache.
+func (c *Client) Authenticate(ctx context.Context, key string, prompt func(dc *DeviceCode) error) (*Session, error) {
+	var s *Session
+	if c.Cache != nil {
+		var err error
+		if s, err = c.Cache.Load(key); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	switch {
+	case s != nil && !s.Expired(expiryMargin):
+		return s, nil
+	case s != nil && s.Microsoft != nil:
+		s, err = c.Refresh(ctx, s)
+		var oauthErr *OAuthError
+		if errors.As(err, &oauthErr) {
+			// the refresh token is expired or revoked, sign in again
+			s, err = c.deviceCodeLogin(ctx, prompt)
+		}
+	default:
+		s, err = c.deviceCodeLogin(ctx, prompt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Cache != nil {
+		if err := c.Cache.Store(key, s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (c *Client) deviceCodeLogin(ctx context.Context, prompt func(dc *DeviceCode) error) (*Session, error) {
+	dc, err := c.DeviceCode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := prompt(dc); err != nil {
+		return nil, err
+	}
+	ms, err := c.PollDeviceCode(ctx, dc)
+	if err != nil {
+		return nil, err
+	}
+	return c.Login(ctx, ms)
+}
@@ -0,0 +1,101 @@
+package msauth
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tnze/go-mc/bot"
+)
+
+// Session is the result of the authentication.
+type Session struct {
+	// Microsoft is the Microsoft token which is used to refresh the session.
+	Microsoft *MicrosoftToken `json:"microsoft,omitempty"`
+	// AccessToken is the Minecraft access token,
+	// used for joining servers and fetching the key pair by user.GetOrFetchKeyPair.
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Profile     Profile   `json:"profile"`
+}
+
+// Profile is the Minecraft game profile.
+type Profile struct {
+	ID   string `json:"id"` // UUID without dashes
+	Name string `json:"name"`
+}
+
+// Auth returns the bot.Auth of the session.
+func (s *Session) Auth() bot.Auth {
+	return bot.Auth{Name: s.Profile.Name, UUID: s.Profile.ID, AsTk: s.AccessToken}
+}
+
+// Expired reports whether the Minecraft access token expires in the given duration.
+func (s *Session) Expired(margin time.Duration) bool {
+	return time.Now().Add(margin).After(s.ExpiresAt)
+}
+
+// ServicesError is the error returned by the Minecraft services.
+// For example, the profile request fails with error "NOT_FOUND" if the account doesn't own the game.
+type ServicesError struct {
+	Path         string `json:"path"`
+	ErrorType    string `json:"errorType"`
+	Err          string `json:"error"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+func (e *ServicesError) Error() string {
+	return "msauth: " + e.Path + ": " + e.Err + ": " + e.ErrorMessage
+}
+
+func (e *ServicesError) ok() bool { return e.Path != "" && e.Err != "" }
+
+// LoginWithXbox logs into the Minecraft services with the XSTS token.
+// It returns the Minecraft access token and the time it expires at.
+func (c *Client) LoginWithXbox(ctx context.Context, xsts *XboxToken) (string, time.Time, error) {
+	payload := map[string]string{
+		"identityToken": "XBL3.0 x=" + xsts.UserHash + ";" + xsts.Token,
+	}
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	u := c.endpoint(func(e *Endpoints) string { return e.Services }) + "/authentication/login_with_xbox"
+	if err := c.postJSON(ctx, u, payload, &resp); err != nil {
+		return "", time.Time{}, err
+	}
+	return resp.AccessToken, expiresAt(resp.ExpiresIn), nil
+}
+
+// Profile fetches the game profile of the Minecraft access token.
+func (c *Client) Profile(ctx context.Context, accessToken string) (Profile, error) {
+	var p Profile
+	u := c.endpoint(func(e *Endpoints) string { return e.Services }) + "/minecraft/profile"
+	err := c.get(ctx, u, accessToken, &p)
+	return p, err
+}
+
+// Login does the Xbox Live and Minecraft authentication with the Microsoft token.
+func (c *Client) Login(ctx context.Context, ms *MicrosoftToken) (*Session, error) {
+	xbl, err := c.XboxUserAuth(ctx, ms.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	xsts, err := c.XSTSAuth(ctx, xbl)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, expires, err := c.LoginWithXbox(ctx, xsts)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := c.Profile(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		Microsoft:   ms,
+		AccessToken: accessToken,
+		ExpiresAt:   expires,
+		Profile:     profile,
+	}, nil
+}
@@ -0,0 +1,180 @@
+// Package msauth implements the Microsoft account authentication of Minecraft: Java Edition.
+//
+// The login is done in four steps:
+//
+//  1. Get a Microsoft OAuth 2.0 token, by the device code flow ([Client.DeviceCode]) or
+//     the authorization code flow ([Client.AuthCodeURL] and [Client.ExchangeCode]).
+//  2. Authenticate with Xbox Live using the Microsoft token.
+//  3. Get an XSTS token for the Minecraft services.
+//  4. Login to the Minecraft services with the XSTS token, and fetch the game profile.
+//
+// Step 2–4 are done by [Client.Login]. The resulting [Session] can be used with [bot.Auth]
+// and [user.GetOrFetchKeyPair]. [Client.Authenticate] does all the steps,
+// refreshes the tokens when they expire and keeps them in a [TokenCache].
+//
+// To use the package, you need the client ID of an Azure application
+// which is allowed to access the Minecraft services.
+//
+// [user.GetOrFetchKeyPair]: https://pkg.go.dev/github.com/Tnze/go-mc/yggdrasil/user#GetOrFetchKeyPair
+package msauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Endpoints are the URLs of the services used in the authentication.
+// They can be changed to test against a local stand-in.
+type Endpoints struct {
+	// Microsoft identity platform
+	DeviceCode string
+	Authorize  string
+	Token      string
+
+	XboxUserAuth string // Xbox Live user authentication
+	XSTSAuth     string // Xbox Secure Token Service
+	// Minecraft services, without the ending slash.
+	// Must be the same as the ServicesURL of the yggdrasil/user package for fetching the key pairs.
+	Services string
+}
+
+var DefaultEndpoints = Endpoints{
+	DeviceCode:   "https://login.microsoftonline.com/consumers/oauth2/v2.0/devicecode",
+	Authorize:    "https://login.microsoftonline.com/consumers/oauth2/v2.0/authorize",
+	Token:        "https://login.microsoftonline.com/consumers/oauth2/v2.0/token",
+	XboxUserAuth: "https://user.auth.xboxlive.com/user/authenticate",
+	XSTSAuth:     "https://xsts.auth.xboxlive.com/xsts/authorize",
+	Services:     "https://api.minecraftservices.com",
+}
+
+// DefaultScope is the OAuth scope required by the Xbox Live authentication.
+const DefaultScope = "XboxLive.signin offline_access"
+
+// Client is the configuration of the authentication.
+type Client struct {
+	// ClientID is the application (client) ID of the Azure application.
+	ClientID string
+	// RedirectURI is required by the authorization code flow.
+	RedirectURI string
+	// Scope of the Microsoft token. If empty, DefaultScope is used.
+	Scope string
+	// Endpoints of the services. If a field is empty, the one in DefaultEndpoints is used.
+	Endpoints Endpoints
+	// HTTPClient is used to send the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// Cache stores the sessions of Authenticate. If nil, sessions are not cached.
+	Cache TokenCache
+}
+
+func (c *Client) scope() string {
+	if c.Scope == "" {
+		return DefaultScope
+	}
+	return c.Scope
+}
+
+func (c *Client) endpoint(f func(e *Endpoints) string) string {
+	if u := f(&c.Endpoints); u != "" {
+		return u
+	}
+	return f(&DefaultEndpoints)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// HTTPError is returned when a service responds with an unexpected status code,
+// and the response body isn't an error known by the package.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("msauth: %s responded %d: %s", e.URL, e.StatusCode, e.Body)
+}
+
+// postForm sends a form and decodes the JSON response into resp.
+func (c *Client) postForm(ctx context.Context, u string, form url.Values, resp any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.do(req, resp)
+}
+
+// postJSON sends the payload as JSON and decodes the JSON response into resp.
+func (c *Client) postJSON(ctx context.Context, u string, payload, resp any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(req, resp)
+}
+
+// get sends a GET request with the bearer token and decodes the JSON response into resp.
+func (c *Client) get(ctx context.Context, u, token string, resp any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return c.do(req, resp)
+}
+
+// errorResponse is implemented by the error bodies of the services.
+// ok reports whether the body is really an error of the type.
+type errorResponse interface {
+	error
+	ok() bool
+}
+
+func (c *Client) do(req *http.Request, resp any) error {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "go-mc")
+	rawResp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer rawResp.Body.Close()
+	body, err := io.ReadAll(rawResp.Body)
+	if err != nil {
+		return err
+	}
+	if rawResp.StatusCode/100 == 2 {
+		if err := json.Unmarshal(body, resp); err != nil {
+			return fmt.Errorf("msauth: parse response of %s: %w", req.URL, err)
+		}
+		return nil
+	}
+	// try to decode the error defined by the service
+	for _, e := range []errorResponse{new(ServicesError), new(XboxError), new(OAuthError)} {
+		if json.Unmarshal(body, e) == nil && e.ok() {
+			return e
+		}
+	}
+	return &HTTPError{URL: req.URL.String(), StatusCode: rawResp.StatusCode, Body: string(body)}
+}
+
+// expiresAt converts the "expires_in" seconds to a time.
+func expiresAt(seconds int64) time.Time {
+	return time.Now().Add(time.Duration(seconds) * time.Second)
+}
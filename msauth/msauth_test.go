@@ -0,0 +1,145 @@
+package msauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// fakeServices is a local stand-in of all the services.
+func fakeServices(t *testing.T, pending int) *httptest.Server {
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/devicecode", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, 200, map[string]any{"user_code": "ABCD", "device_code": "dc", "interval": 1, "message": "go"})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		switch r.PostForm.Get("grant_type") {
+		case "urn:ietf:params:oauth:grant-type:device_code":
+			if pending > 0 {
+				pending--
+				reply(w, 400, map[string]string{"error": "authorization_pending"})
+				return
+			}
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh" {
+				reply(w, 400, map[string]string{"error": "invalid_grant"})
+				return
+			}
+		}
+		reply(w, 200, map[string]any{"access_token": "ms", "refresh_token": "refresh", "expires_in": 3600})
+	})
+	mux.HandleFunc("/xbl", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Properties struct{ RpsTicket string } }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Properties.RpsTicket != "d=ms" {
+			t.Errorf("unexpected RpsTicket: %q", req.Properties.RpsTicket)
+		}
+		reply(w, 200, map[string]any{"Token": "xbl", "DisplayClaims": map[string]any{"xui": []any{map[string]string{"uhs": "hash"}}}})
+	})
+	mux.HandleFunc("/xsts", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, 200, map[string]any{"Token": "xsts", "DisplayClaims": map[string]any{"xui": []any{map[string]string{"uhs": "hash"}}}})
+	})
+	mux.HandleFunc("/authentication/login_with_xbox", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ IdentityToken string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.IdentityToken != "XBL3.0 x=hash;xsts" {
+			t.Errorf("unexpected identityToken: %q", req.IdentityToken)
+		}
+		reply(w, 200, map[string]any{"access_token": "mc", "expires_in": 86400})
+	})
+	mux.HandleFunc("/minecraft/profile", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mc" {
+			reply(w, 401, map[string]string{"path": "/minecraft/profile", "error": "UNAUTHORIZED"})
+			return
+		}
+		reply(w, 200, Profile{ID: "0123456789abcdef0123456789abcdef", Name: "Steve"})
+	})
+	return httptest.NewServer(mux)
+}
+
+func testClient(url string) *Client {
+	return &Client{
+		ClientID: "client",
+		Endpoints: Endpoints{
+			DeviceCode:   url + "/devicecode",
+			Token:        url + "/token",
+			XboxUserAuth: url + "/xbl",
+			XSTSAuth:     url + "/xsts",
+			Services:     url,
+		},
+	}
+}
+
+func TestClient_Authenticate(t *testing.T) {
+	srv := fakeServices(t, 1)
+	defer srv.Close()
+
+	c := testClient(srv.URL)
+	c.Cache = &FileCache{Path: filepath.Join(t.TempDir(), "tokens.json")}
+	prompts := 0
+	prompt := func(dc *DeviceCode) error {
+		prompts++
+		if dc.UserCode != "ABCD" {
+			t.Errorf("unexpected user code: %q", dc.UserCode)
+		}
+		return nil
+	}
+
+	s, err := c.Authenticate(context.Background(), "steve", prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := s.Auth(); auth.Name != "Steve" || auth.AsTk != "mc" || auth.UUID != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("unexpected auth: %+v", auth)
+	}
+
+	// the second time is loaded from the cache
+	if _, err := c.Authenticate(context.Background(), "steve", prompt); err != nil {
+		t.Fatal(err)
+	}
+	if prompts != 1 {
+		t.Errorf("prompted %d times, want 1", prompts)
+	}
+
+	// an expired session is refreshed
+	s.ExpiresAt = s.ExpiresAt.AddDate(-1, 0, 0)
+	s.Microsoft.ExpiresAt = s.Microsoft.ExpiresAt.AddDate(-1, 0, 0)
+	if err := c.Cache.Store("steve", s); err != nil {
+		t.Fatal(err)
+	}
+	s, err = c.Authenticate(context.Background(), "steve", prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Expired(0) || prompts != 1 {
+		t.Errorf("session is not refreshed")
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	srv := fakeServices(t, 0)
+	defer srv.Close()
+	c := testClient(srv.URL)
+
+	_, err := c.RefreshToken(context.Background(), "revoked")
+	var oauthErr *OAuthError
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_grant" {
+		t.Errorf("refresh with revoked token: got %v", err)
+	}
+
+	_, err = c.Profile(context.Background(), "invalid")
+	var servicesErr *ServicesError
+	if !errors.As(err, &servicesErr) || servicesErr.Err != "UNAUTHORIZED" {
+		t.Errorf("profile with invalid token: got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package msauth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+// MicrosoftToken is the OAuth 2.0 token of the Microsoft account.
+type MicrosoftToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type tokenResp struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+func (t tokenResp) token() *MicrosoftToken {
+	return &MicrosoftToken{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		ExpiresAt:    expiresAt(t.ExpiresIn),
+	}
+}
+
+// OAuthError is the error returned by the Microsoft identity platform.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	return "msauth: " + e.Code + ": " + e.Description
+}
+
+func (e *OAuthError) ok() bool { return e.Code != "" }
+
+// DeviceCode is the response of the device authorization request.
+// Show the Message (or the UserCode and VerificationURI) to the user,
+// and call [Client.PollDeviceCode] to wait for the authorization.
+type DeviceCode struct {
+	UserCode        string `json:"user_code"`
+	DeviceCode      string `json:"device_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int64  `json:"expires_in"` // seconds
+	Interval        int64  `json:"interval"`   // seconds
+	Message         string `json:"message"`
+}
+
+// DeviceCode starts the device code flow.
+func (c *Client) DeviceCode(ctx context.Context) (*DeviceCode, error) {
+	var dc DeviceCode
+	err := c.postForm(ctx, c.endpoint(func(e *Endpoints) string { return e.DeviceCode }), url.Values{
+		"client_id": {c.ClientID},
+		"scope":     {c.scope()},
+	}, &dc)
+	if err != nil {
+		return nil, err
+	}
+	return &dc, nil
+}
+
+// PollDeviceCode waits until the user completes the authorization of the device code.
+// An *OAuthError is returned if the user declined it or the code is expired.
+func (c *Client) PollDeviceCode(ctx context.Context, dc *DeviceCode) (*MicrosoftToken, error) {
+	interval := time.Duration(dc.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	for {
+		var resp tokenResp
+		err := c.postForm(ctx, c.endpoint(func(e *Endpoints) string { return e.Token }), url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"client_id":   {c.ClientID},
+			"device_code": {dc.DeviceCode},
+		}, &resp)
+		var oauthErr *OAuthError
+		switch {
+		case err == nil:
+			return resp.token(), nil
+		case errors.As(err, &oauthErr) && oauthErr.Code == "authorization_pending":
+		case errors.As(err, &oauthErr) && oauthErr.Code == "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// AuthCodeURL returns the URL of the authorization code flow,
+// which the user should open in the browser.
+// After the user signed in, the browser is redirected to the RedirectURI with the code and the state.
+func (c *Client) AuthCodeURL(state string) string {
+	return c.endpoint(func(e *Endpoints) string { return e.Authorize }) + "?" + url.Values{
+		"client_id":     {c.ClientID},
+		"response_type": {"code"},
+		"redirect_uri":  {c.RedirectURI},
+		"response_mode": {"query"},
+		"scope":         {c.scope()},
+		"state":         {state},
+	}.Encode()
+}
+
+// ExchangeCode redeems the code of the authorization code flow for the token.
+func (c *Client) ExchangeCode(ctx context.Context, code string) (*MicrosoftToken, error) {
+	var resp tokenResp
+	err := c.postForm(ctx, c.endpoint(func(e *Endpoints) string { return e.Token }), url.Values{
+		"grant_type":   {"authorization_code"},
+		"client_id":    {c.ClientID},
+		"code":         {code},
+		"redirect_uri": {c.RedirectURI},
+		"scope":        {c.scope()},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.token(), nil
+}
+
+// RefreshToken gets a new token with the refresh token.
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*MicrosoftToken, error) {
+	var resp tokenResp
+	err := c.postForm(ctx, c.endpoint(func(e *Endpoints) string { return e.Token }), url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {c.ClientID},
+		"refresh_token": {refreshToken},
+		"scope":         {c.scope()},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	t := resp.token()
+	if t.RefreshToken == "" {
+		// the refresh token may not be rotated
+		t.RefreshToken = refreshToken
+	}
+	return t, nil
+}
@@ -0,0 +1,99 @@
+package msauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// XboxToken is the token issued by Xbox Live user authentication or XSTS.
+type XboxToken struct {
+	Token    string
+	UserHash string // uhs
+	NotAfter time.Time
+}
+
+type xboxAuthResp struct {
+	NotAfter      time.Time
+	Token         string
+	DisplayClaims struct {
+		Xui []struct {
+			Uhs string `json:"uhs"`
+		} `json:"xui"`
+	}
+}
+
+func (r *xboxAuthResp) token() (*XboxToken, error) {
+	if len(r.DisplayClaims.Xui) == 0 {
+		return nil, errors.New("msauth: xbox live response has no user hash")
+	}
+	return &XboxToken{Token: r.Token, UserHash: r.DisplayClaims.Xui[0].Uhs, NotAfter: r.NotAfter}, nil
+}
+
+// XboxError is the error returned by XSTS.
+type XboxError struct {
+	XErr     int64
+	Message  string
+	Redirect string
+}
+
+// Known values of XboxError.XErr
+const (
+	XErrNoXboxAccount  = 2148916233 // The account doesn't have an Xbox account.
+	XErrBanned         = 2148916235 // Xbox Live is not available in the country.
+	XErrAdultRequired  = 2148916236 // Adult verification is needed (South Korea).
+	XErrAdultRequired2 = 2148916237
+	XErrChildAccount   = 2148916238 // The account is a child and must be added to a family.
+)
+
+func (e *XboxError) Error() string {
+	switch e.XErr {
+	case XErrNoXboxAccount:
+		return "msauth: the account doesn't have an Xbox account"
+	case XErrBanned:
+		return "msauth: Xbox Live is not available in the country of the account"
+	case XErrAdultRequired, XErrAdultRequired2:
+		return "msauth: the account needs adult verification"
+	case XErrChildAccount:
+		return "msauth: the account is a child and must be added to a family"
+	}
+	return fmt.Sprintf("msauth: xbox live error %d: %s", e.XErr, e.Message)
+}
+
+func (e *XboxError) ok() bool { return e.XErr != 0 }
+
+// XboxUserAuth authenticates with Xbox Live by the Microsoft access token.
+func (c *Client) XboxUserAuth(ctx context.Context, msAccessToken string) (*XboxToken, error) {
+	payload := map[string]any{
+		"Properties": map[string]any{
+			"AuthMethod": "RPS",
+			"SiteName":   "user.auth.xboxlive.com",
+			"RpsTicket":  "d=" + msAccessToken,
+		},
+		"RelyingParty": "http://auth.xboxlive.com",
+		"TokenType":    "JWT",
+	}
+	var resp xboxAuthResp
+	if err := c.postJSON(ctx, c.endpoint(func(e *Endpoints) string { return e.XboxUserAuth }), payload, &resp); err != nil {
+		return nil, err
+	}
+	return resp.token()
+}
+
+// XSTSAuth gets the XSTS token for the Minecraft services.
+func (c *Client) XSTSAuth(ctx context.Context, xbl *XboxToken) (*XboxToken, error) {
+	payload := map[string]any{
+		"Properties": map[string]any{
+			"SandboxId":  "RETAIL",
+			"UserTokens": []string{xbl.Token},
+		},
+		"RelyingParty": "rp://api.minecraftservices.com/",
+		"TokenType":    "JWT",
+	}
+	var resp xboxAuthResp
+	if err := c.postJSON(ctx, c.endpoint(func(e *Endpoints) string { return e.XSTSAuth }), payload, &resp); err != nil {
+		return nil, err
+	}
+	return resp.token()
+}
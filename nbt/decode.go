@@ -383,8 +383,10 @@ func (d *Decoder) unmarshal(val reflect.Value, tagType byte) error {
 				var f *field
 				if i, ok := fields.nameIndex[tn]; ok {
 					f = &fields.list[i]
-				} else {
+				} else if fields.rest == nil {
 					// Fall back to linear search.
+					// The tags kept by the ",rest" field must match the names exactly,
+					// or they would be written back with the names of the other fields.
 					for i := range fields.list {
 						ff := &fields.list[i]
 						if strings.EqualFold(ff.name, tn) {
@@ -414,6 +416,10 @@ func (d *Decoder) unmarshal(val reflect.Value, tagType byte) error {
 					if err != nil {
 						return fmt.Errorf("fail to decode tag %q: %w", tn, err)
 					}
+				} else if fields.rest != nil {
+					if err := d.unmarshalRest(val, fields.rest, tt, tn); err != nil {
+						return err
+					}
 				} else if d.disallowUnknownFields {
 					return fmt.Errorf("unknown field %q", tn)
 				} else if err := d.rawRead(tt); err != nil {
@@ -627,6 +633,28 @@ func (d *Decoder) rawRead(tagType byte) error {
 	return nil
 }
 
+// unmarshalRest decodes the unknown tag into the map field tagged with ",rest".
+func (d *Decoder) unmarshalRest(val reflect.Value, index []int, tagType byte, tagName string) error {
+	for _, i := range index {
+		if val.Kind() == reflect.Pointer {
+			if val.IsNil() {
+				val.Set(reflect.New(val.Type().Elem()))
+			}
+			val = val.Elem()
+		}
+		val = val.Field(i)
+	}
+	if val.IsNil() {
+		val.Set(reflect.MakeMap(val.Type()))
+	}
+	v := reflect.New(val.Type().Elem())
+	if err := d.unmarshal(v.Elem(), tagType); err != nil {
+		return fmt.Errorf("fail to decode tag %q: %w", tagName, err)
+	}
+	val.SetMapIndex(reflect.ValueOf(tagName).Convert(val.Type().Key()), v.Elem())
+	return nil
+}
+
 func (d *Decoder) readTag() (tagType byte, tagName string, err error) {
 	tagType, err = d.r.ReadByte()
 	if err != nil {
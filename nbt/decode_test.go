@@ -463,6 +463,55 @@ func TestDecoder_Decode_ErrorUnknownField(t *testing.T) {
 	}
 }
 
+func TestDecoder_Decode_rest(t *testing.T) {
+	data := []byte{
+		TagCompound, 0, 1, 'S',
+		TagByte, 0, 1, 'A', 1,
+		TagByte, 0, 1, 'B', 2,
+		TagString, 0, 1, 'C', 0, 2, 'h', 'i',
+		TagEnd,
+	}
+	var v struct {
+		A    byte                  `nbt:"A"`
+		Rest map[string]RawMessage `nbt:",rest"`
+	}
+	d := NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if _, err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != 1 || len(v.Rest) != 2 || v.Rest["B"].Type != TagByte || v.Rest["C"].String() != "hi" {
+		t.Errorf("unexpected result: %+v", v)
+	}
+
+	// the unknown tags are written back
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(v, "S"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("round trip mismatch:\ngot  % 02x\nwant % 02x", buf.Bytes(), data)
+	}
+}
+
+func TestDecoder_Decode_restCase(t *testing.T) {
+	data := []byte{
+		TagCompound, 0, 1, 'S',
+		TagByte, 0, 5, 'C', 'o', 'u', 'n', 't', 5,
+		TagEnd,
+	}
+	var v struct {
+		Count int32                 `nbt:"count"`
+		Rest  map[string]RawMessage `nbt:",rest"`
+	}
+	if _, err := NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Count != 0 || v.Rest["Count"].Type != TagByte {
+		t.Errorf("the tag differing in case isn't kept in the rest: %+v", v)
+	}
+}
+
 func TestDecoder_Decode_keysWithComma(t *testing.T) {
 	data := []byte{
 		TagCompound, 0, 1, 'S',
@@ -8,6 +8,7 @@ import (
 	"io"
 	"math"
 	"reflect"
+	"sort"
 	"strconv"
 	"unsafe"
 )
@@ -163,7 +164,7 @@ func (e *Encoder) writeValue(val reflect.Value, tagType byte) error {
 
 		for i := 0; i < val.Len(); i++ {
 			arrType, arrVal := getTagType(val.Index(i))
-			err := e.writeValue(arrVal, arrType)
+			err := e.marshal(arrVal, arrType)
 			if err != nil {
 				return err
 			}
@@ -211,7 +212,7 @@ func (e *Encoder) writeValue(val reflect.Value, tagType byte) error {
 					v = v.Field(i)
 				}
 
-				if t.omitEmpty && isEmptyValue(v) {
+				if t.omitEmpty && isEmptyValue(v) || t.omitZero && v.IsZero() {
 					continue
 				}
 				typ, v := getTagType(v)
@@ -235,6 +236,11 @@ func (e *Encoder) writeValue(val reflect.Value, tagType byte) error {
 					return err
 				}
 			}
+			if fields.rest != nil {
+				if err := e.writeRest(val, fields); err != nil {
+					return err
+				}
+			}
 		case reflect.Map:
 			r := val.MapRange()
 			for r.Next() {
@@ -410,6 +416,45 @@ func isEmptyValue(v reflect.Value) bool {
 		return v.Float() == 0
 	case reflect.Interface, reflect.Pointer:
 		return v.IsNil()
+	case reflect.Struct:
+		// A RawMessage without any tag
+		if m, ok := v.Interface().(RawMessage); ok {
+			return m.Type == TagEnd
+		}
 	}
 	return false
 }
+
+// writeRest writes the tags in the map field tagged with ",rest",
+// except the ones with the same name as other fields.
+// The tags are sorted by name, so that the output is stable.
+func (e *Encoder) writeRest(val reflect.Value, fields structFields) error {
+	for _, i := range fields.rest {
+		if val.Kind() == reflect.Pointer {
+			if val.IsNil() {
+				return nil
+			}
+			val = val.Elem()
+		}
+		val = val.Field(i)
+	}
+	keys := val.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	for _, k := range keys {
+		tagName := k.String()
+		if _, ok := fields.nameIndex[tagName]; ok {
+			continue
+		}
+		tagType, tagValue := getTagType(val.MapIndex(k))
+		if tagType == TagEnd {
+			return fmt.Errorf("encoding %q error: unsupport type %v", tagName, tagValue.Type())
+		}
+		if err := writeTag(e.w, tagType, tagName); err != nil {
+			return err
+		}
+		if err := e.marshal(tagValue, tagType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -281,9 +281,10 @@ func TestEncoder_Encode_textMarshaler(t *testing.T) {
 
 func TestEncoder_Encode_omitempty(t *testing.T) {
 	type Struct struct {
-		S string `nbt:"test,omitempty"`
-		B []byte `nbt:",omitempty"`
-		I int32  `nbt:",omitempty"`
+		S string     `nbt:"test,omitempty"`
+		B []byte     `nbt:",omitempty"`
+		I int32      `nbt:",omitempty"`
+		R RawMessage `nbt:",omitempty"`
 	}
 
 	tests := []struct {
@@ -304,12 +305,14 @@ func TestEncoder_Encode_omitempty(t *testing.T) {
 				S: "ab",
 				B: []byte{4, 5},
 				I: 9,
+				R: RawMessage{Type: TagByte, Data: []byte{1}},
 			},
 			want: []byte{
 				TagCompound, 0x00, 0x00,
 				TagString, 0x00, 4, 't', 'e', 's', 't', 0, 2, 'a', 'b',
 				TagByteArray, 0x00, 1, 'B', 0x00, 0x00, 0, 2, 4, 5,
 				TagInt, 0x00, 1, 'I', 0x00, 0x00, 0x00, 0x09,
+				TagByte, 0x00, 1, 'R', 1,
 				TagEnd,
 			},
 		},
@@ -326,3 +329,47 @@ func TestEncoder_Encode_omitempty(t *testing.T) {
 		})
 	}
 }
+
+func TestEncoder_Encode_omitzero(t *testing.T) {
+	type Struct struct {
+		A [2]int32         `nbt:",omitzero"`
+		S struct{ X byte } `nbt:",omitzero"`
+		E [2]int32         `nbt:",omitempty"`
+	}
+	data, err := Marshal(Struct{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		TagCompound, 0x00, 0x00,
+		TagIntArray, 0x00, 1, 'E', 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0,
+		TagEnd,
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("Marshal(Struct{}) got = % 02x, want % 02x", data, want)
+	}
+	data, err = Marshal(Struct{A: [2]int32{0, 1}, E: [2]int32{0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte{TagIntArray, 0x00, 1, 'A'}) {
+		t.Errorf("non-zero array is omitted: % 02x", data)
+	}
+}
+
+func TestEncoder_Encode_marshalerArray(t *testing.T) {
+	elem := RawMessage{Type: TagString, Data: []byte{0, 2, 'a', 'b'}}
+	data, err := Marshal([]RawMessage{elem, elem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		TagList, 0x00, 0x00,
+		TagString, 0, 0, 0, 2,
+		0, 2, 'a', 'b',
+		0, 2, 'a', 'b',
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("Marshal([]RawMessage) got = % 02x, want % 02x", data, want)
+	}
+}
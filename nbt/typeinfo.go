@@ -10,6 +10,7 @@ import (
 type structFields struct {
 	list      []field
 	nameIndex map[string]int // index of the previous slice.
+	rest      []int          // index of the field storing the unknown tags, nil if none.
 }
 
 type field struct {
@@ -19,6 +20,7 @@ type field struct {
 	index     []int
 	typ       reflect.Type
 	omitEmpty bool
+	omitZero  bool
 	asList    bool
 }
 
@@ -54,6 +56,7 @@ func typeFields(t reflect.Type) (tInfo structFields) {
 
 	// Fields found.
 	var fields []field
+	var rest []int
 
 	for len(next) > 0 {
 		current, next = next, current[:0]
@@ -105,17 +108,28 @@ func typeFields(t reflect.Type) (tInfo structFields) {
 				}
 
 				// parse options
-				var omitEmpty, asList bool
+				var omitEmpty, omitZero, asList, isRest bool
 				for opts != "" {
 					var name string
 					name, opts, _ = strings.Cut(opts, ",")
 					switch name {
 					case "omitempty":
 						omitEmpty = true
+					case "omitzero":
+						omitZero = true
 					case "list":
 						asList = true
+					case "rest":
+						isRest = true
 					}
 				}
+				if isRest {
+					// The shallowest one is used
+					if rest == nil && ft.Kind() == reflect.Map && ft.Key().Kind() == reflect.String {
+						rest = index
+					}
+					continue
+				}
 				// Deprecated: use `nbt:",list"` instead.
 				if sf.Tag.Get("nbt_type") == "list" {
 					asList = true
@@ -133,6 +147,7 @@ func typeFields(t reflect.Type) (tInfo structFields) {
 						index:     index,
 						typ:       ft,
 						omitEmpty: omitEmpty,
+						omitZero:  omitZero,
 						asList:    asList,
 					}
 
@@ -211,6 +226,7 @@ func typeFields(t reflect.Type) (tInfo structFields) {
 	return structFields{
 		list:      fields,
 		nameIndex: nameIndex,
+		rest:      rest,
 	}
 }
 
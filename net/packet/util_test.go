@@ -54,6 +54,18 @@ func TestAry_ReadFrom(t *testing.T) {
 	}
 }
 
+func TestAry_ReadFrom_reuse(t *testing.T) {
+	ary := []pk.String{"a", "b", "c"}
+	bin := []byte{0, 0, 0, 1, 4, 'T', 'n', 'z', 'e'}
+	data := pk.Ary[pk.Int]{Ary: &ary}
+	if _, err := data.ReadFrom(bytes.NewReader(bin)); err != nil {
+		t.Fatal(err)
+	}
+	if len(ary) != 1 || ary[0] != "Tnze" {
+		t.Errorf("the slice with enough capacity isn't resized: %q", ary)
+	}
+}
+
 func TestAry_WriteTo(t *testing.T) {
 	var buf bytes.Buffer
 	want := []byte{
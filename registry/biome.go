@@ -0,0 +1,53 @@
+package registry
+
+import "github.com/Tnze/go-mc/nbt"
+
+// Biome is an element of the minecraft:worldgen/biome registry.
+// Only the climate and the effects are synchronized to the client.
+type Biome struct {
+	HasPrecipitation    bool         `nbt:"has_precipitation"`
+	Temperature         float32      `nbt:"temperature"`
+	TemperatureModifier string       `nbt:"temperature_modifier,omitempty"` // "none" or "frozen"
+	Downfall            float32      `nbt:"downfall"`
+	Effects             BiomeEffects `nbt:"effects"`
+}
+
+type BiomeEffects struct {
+	FogColor           int32  `nbt:"fog_color"`
+	WaterColor         int32  `nbt:"water_color"`
+	WaterFogColor      int32  `nbt:"water_fog_color"`
+	SkyColor           int32  `nbt:"sky_color"`
+	FoliageColor       int32  `nbt:"foliage_color,omitempty"` // 0 if computed from the climate
+	GrassColor         int32  `nbt:"grass_color,omitempty"`   // 0 if computed from the climate
+	GrassColorModifier string `nbt:"grass_color_modifier,omitempty"`
+
+	Particle       *BiomeParticle       `nbt:"particle,omitempty"`
+	AmbientSound   *SoundEvent          `nbt:"ambient_sound,omitempty"`
+	MoodSound      *BiomeMoodSound      `nbt:"mood_sound,omitempty"`
+	AdditionsSound *BiomeAdditionsSound `nbt:"additions_sound,omitempty"`
+	Music          *BiomeMusic          `nbt:"music,omitempty"`
+}
+
+type BiomeParticle struct {
+	Options     nbt.RawMessage `nbt:"options"` // The particle type and its options, e.g. {type: "minecraft:white_ash"}
+	Probability float32        `nbt:"probability"`
+}
+
+type BiomeMoodSound struct {
+	Sound             SoundEvent `nbt:"sound"`
+	TickDelay         int32      `nbt:"tick_delay"`
+	BlockSearchExtent int32      `nbt:"block_search_extent"`
+	Offset            float64    `nbt:"offset"`
+}
+
+type BiomeAdditionsSound struct {
+	Sound      SoundEvent `nbt:"sound"`
+	TickChance float64    `nbt:"tick_chance"`
+}
+
+type BiomeMusic struct {
+	Sound               SoundEvent `nbt:"sound"`
+	MinDelay            int32      `nbt:"min_delay"`
+	MaxDelay            int32      `nbt:"max_delay"`
+	ReplaceCurrentMusic bool       `nbt:"replace_current_music"`
+}
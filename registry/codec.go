@@ -1,26 +1,46 @@
 package registry
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/registryid"
 	"github.com/Tnze/go-mc/nbt"
 	pk "github.com/Tnze/go-mc/net/packet"
 )
 
 type Registries struct {
-	ChatType        Registry[ChatType]       `registry:"minecraft:chat_type"`
-	DamageType      Registry[DamageType]     `registry:"minecraft:damage_type"`
-	DimensionType   Registry[Dimension]      `registry:"minecraft:dimension_type"`
-	TrimMaterial    Registry[nbt.RawMessage] `registry:"minecraft:trim_material"`
-	TrimPattern     Registry[nbt.RawMessage] `registry:"minecraft:trim_pattern"`
-	WorldGenBiome   Registry[nbt.RawMessage] `registry:"minecraft:worldgen/biome"`
-	Wolfvariant     Registry[nbt.RawMessage] `registry:"minecraft:wolf_variant"`
-	PaintingVariant Registry[nbt.RawMessage] `registry:"minecraft:painting_variant"`
-	BannerPattern   Registry[nbt.RawMessage] `registry:"minecraft:banner_pattern"`
-	Enchantment     Registry[nbt.RawMessage] `registry:"minecraft:enchantment"`
-	JukeboxSong     Registry[nbt.RawMessage] `registry:"minecraft:jukebox_song"`
+	ChatType        Registry[ChatType]        `registry:"minecraft:chat_type"`
+	DamageType      Registry[DamageType]      `registry:"minecraft:damage_type"`
+	DimensionType   Registry[Dimension]       `registry:"minecraft:dimension_type"`
+	TrimMaterial    Registry[TrimMaterial]    `registry:"minecraft:trim_material"`
+	TrimPattern     Registry[TrimPattern]     `registry:"minecraft:trim_pattern"`
+	WorldGenBiome   Registry[Biome]           `registry:"minecraft:worldgen/biome"`
+	Wolfvariant     Registry[WolfVariant]     `registry:"minecraft:wolf_variant"`
+	PaintingVariant Registry[PaintingVariant] `registry:"minecraft:painting_variant"`
+	BannerPattern   Registry[BannerPattern]   `registry:"minecraft:banner_pattern"`
+	Enchantment     Registry[Enchantment]     `registry:"minecraft:enchantment"`
+	JukeboxSong     Registry[JukeboxSong]     `registry:"minecraft:jukebox_song"`
+
+	// The builtin registries aren't sent by RegistryData, their entries are listed in [registryid].
+	// The values of the entries are their keys. Only the tags are synchronized,
+	// and can be queried like Block.IsInTag(b.ID(), "minecraft:mineable/pickaxe").
+	Block      Registry[string] `registry:"minecraft:block,builtin"`
+	Item       Registry[string] `registry:"minecraft:item,builtin"`
+	Fluid      Registry[string] `registry:"minecraft:fluid,builtin"`
+	EntityType Registry[string] `registry:"minecraft:entity_type,builtin"`
+	GameEvent  Registry[string] `registry:"minecraft:game_event,builtin"`
+
+	// Others holds the synchronized registries which aren't listed above, with their entries kept as raw NBT.
+	Others map[string]*Registry[nbt.RawMessage]
+	// Builtins holds the builtin registries which aren't listed above, see [Registries.Builtin].
+	Builtins map[string]*Registry[string]
 }
 
 func NewNetworkCodec() Registries {
@@ -28,15 +48,33 @@ func NewNetworkCodec() Registries {
 		ChatType:        NewRegistry[ChatType](),
 		DamageType:      NewRegistry[DamageType](),
 		DimensionType:   NewRegistry[Dimension](),
-		TrimMaterial:    NewRegistry[nbt.RawMessage](),
-		TrimPattern:     NewRegistry[nbt.RawMessage](),
-		WorldGenBiome:   NewRegistry[nbt.RawMessage](),
-		Wolfvariant:     NewRegistry[nbt.RawMessage](),
-		PaintingVariant: NewRegistry[nbt.RawMessage](),
-		BannerPattern:   NewRegistry[nbt.RawMessage](),
-		Enchantment:     NewRegistry[nbt.RawMessage](),
-		JukeboxSong:     NewRegistry[nbt.RawMessage](),
+		TrimMaterial:    NewRegistry[TrimMaterial](),
+		TrimPattern:     NewRegistry[TrimPattern](),
+		WorldGenBiome:   NewRegistry[Biome](),
+		Wolfvariant:     NewRegistry[WolfVariant](),
+		PaintingVariant: NewRegistry[PaintingVariant](),
+		BannerPattern:   NewRegistry[BannerPattern](),
+		Enchantment:     NewRegistry[Enchantment](),
+		JukeboxSong:     NewRegistry[JukeboxSong](),
+
+		Block:      NewBuiltinRegistry(registryid.Block),
+		Item:       NewBuiltinRegistry(registryid.Item),
+		Fluid:      NewBuiltinRegistry(registryid.Fluid),
+		EntityType: NewBuiltinRegistry(registryid.EntityType),
+		GameEvent:  NewBuiltinRegistry(registryid.GameEvent),
+
+		Others:   make(map[string]*Registry[nbt.RawMessage]),
+		Builtins: make(map[string]*Registry[string]),
+	}
+}
+
+// NewBuiltinRegistry creates a registry whose entries are keys, with the IDs of their indices.
+func NewBuiltinRegistry(keys []string) Registry[string] {
+	r := NewRegistry[string]()
+	for _, key := range keys {
+		r.Put(key, key)
 	}
+	return r
 }
 
 type ChatType struct {
@@ -74,23 +112,257 @@ type Dimension struct {
 	MonsterSpawnBlockLightLimit int32          `nbt:"monster_spawn_block_light_limit"`
 }
 
+type TrimMaterial struct {
+	AssetName              string            `nbt:"asset_name"`
+	Ingredient             string            `nbt:"ingredient"`
+	ItemModelIndex         float32           `nbt:"item_model_index"`
+	OverrideArmorMaterials map[string]string `nbt:"override_armor_materials,omitempty"` // armor material -> asset name
+	Description            chat.Message      `nbt:"description"`
+}
+
+type TrimPattern struct {
+	AssetID      string       `nbt:"asset_id"`
+	TemplateItem string       `nbt:"template_item"`
+	Description  chat.Message `nbt:"description"`
+	Decal        bool         `nbt:"decal"`
+}
+
+type WolfVariant struct {
+	WildTexture  string    `nbt:"wild_texture"`
+	TameTexture  string    `nbt:"tame_texture"`
+	AngryTexture string    `nbt:"angry_texture"`
+	Biomes       HolderSet `nbt:"biomes"`
+}
+
+type PaintingVariant struct {
+	AssetID string `nbt:"asset_id"`
+	Width   int32  `nbt:"width"`  // in blocks
+	Height  int32  `nbt:"height"` // in blocks
+}
+
+type BannerPattern struct {
+	AssetID        string `nbt:"asset_id"`
+	TranslationKey string `nbt:"translation_key"`
+}
+
+type JukeboxSong struct {
+	SoundEvent       SoundEvent   `nbt:"sound_event"`
+	Description      chat.Message `nbt:"description"`
+	LengthInSeconds  float32      `nbt:"length_in_seconds"`
+	ComparatorOutput int32        `nbt:"comparator_output"`
+}
+
+// Clone returns a copy of all registries, see [Registry.Clone].
+func (c *Registries) Clone() Registries {
+	var clone Registries
+	srcVal := reflect.ValueOf(c).Elem()
+	dstVal := reflect.ValueOf(&clone).Elem()
+	for i := 0; i < srcVal.NumField(); i++ {
+		if r, ok := srcVal.Field(i).Addr().Interface().(cloner); ok {
+			dstVal.Field(i).Set(reflect.ValueOf(r.clone()))
+		}
+	}
+	if c.Others != nil {
+		clone.Others = make(map[string]*Registry[nbt.RawMessage], len(c.Others))
+		for id, r := range c.Others {
+			r := r.Clone()
+			clone.Others[id] = &r
+		}
+	}
+	if c.Builtins != nil {
+		clone.Builtins = make(map[string]*Registry[string], len(c.Builtins))
+		for id, r := range c.Builtins {
+			r := r.Clone()
+			clone.Builtins[id] = &r
+		}
+	}
+	return clone
+}
+
+type cloner interface{ clone() any }
+
+func (r *Registry[E]) clone() any { return r.Clone() }
+
 type RegistryCodec interface {
-	pk.FieldDecoder
+	pk.Field
+	WriteKnownTo(w io.Writer, known []KnownPack) (int64, error)
+	KnownPacks() []KnownPack
 	ReadTagsFrom(r io.Reader) (int64, error)
+	WriteTagsTo(w io.Writer) (int64, error)
 }
 
+// Registry returns the registry by id, including the builtin ones, c.Others and c.Builtins.
+// It returns nil if not found.
 func (c *Registries) Registry(id string) RegistryCodec {
+	var registry RegistryCodec
+	c.rangeFields(func(registryID string, builtin bool, r RegistryCodec) {
+		if registryID == id {
+			registry = r
+		}
+	})
+	if registry != nil {
+		return registry
+	}
+	if r, ok := c.Others[id]; ok {
+		return r
+	}
+	if r, ok := c.Builtins[id]; ok {
+		return r
+	}
+	return nil
+}
+
+// Other returns the registry in c.Others by id, it's created if not exists.
+// This is for the registries which c doesn't have a typed field for.
+func (c *Registries) Other(id string) *Registry[nbt.RawMessage] {
+	if r, ok := c.Others[id]; ok {
+		return r
+	}
+	if c.Others == nil {
+		c.Others = make(map[string]*Registry[nbt.RawMessage])
+	}
+	r := NewRegistry[nbt.RawMessage]()
+	c.Others[id] = &r
+	return &r
+}
+
+// Builtin returns the builtin registry in c.Builtins by id.
+// It's created with the entries in [registryid.Registries] if not exists,
+// and returns nil if id isn't a builtin registry.
+func (c *Registries) Builtin(id string) *Registry[string] {
+	if r, ok := c.Builtins[id]; ok {
+		return r
+	}
+	keys, ok := registryid.Registries[id]
+	if !ok {
+		return nil
+	}
+	if c.Builtins == nil {
+		c.Builtins = make(map[string]*Registry[string])
+	}
+	r := NewBuiltinRegistry(keys)
+	c.Builtins[id] = &r
+	return &r
+}
+
+// Range calls f for each synchronized registry in c, in the order of the fields,
+// then the registries in c.Others in the order of their IDs.
+func (c *Registries) Range(f func(id string, r RegistryCodec)) {
+	c.rangeFields(func(id string, builtin bool, r RegistryCodec) {
+		if !builtin {
+			f(id, r)
+		}
+	})
+	others := make([]string, 0, len(c.Others))
+	for id := range c.Others {
+		others = append(others, id)
+	}
+	slices.Sort(others)
+	for _, id := range others {
+		f(id, c.Others[id])
+	}
+}
+
+// RangeBuiltin calls f for each builtin registry in c, in the order of the fields,
+// then the registries in c.Builtins in the order of their IDs.
+func (c *Registries) RangeBuiltin(f func(id string, r RegistryCodec)) {
+	c.rangeFields(func(id string, builtin bool, r RegistryCodec) {
+		if builtin {
+			f(id, r)
+		}
+	})
+	builtins := make([]string, 0, len(c.Builtins))
+	for id := range c.Builtins {
+		builtins = append(builtins, id)
+	}
+	slices.Sort(builtins)
+	for _, id := range builtins {
+		f(id, c.Builtins[id])
+	}
+}
+
+func (c *Registries) rangeFields(f func(id string, builtin bool, r RegistryCodec)) {
 	codecVal := reflect.ValueOf(c).Elem()
 	codecTyp := codecVal.Type()
 	numField := codecVal.NumField()
 	for i := 0; i < numField; i++ {
-		registryID, ok := codecTyp.Field(i).Tag.Lookup("registry")
+		tag, ok := codecTyp.Field(i).Tag.Lookup("registry")
 		if !ok {
 			continue
 		}
-		if registryID == id {
-			return codecVal.Field(i).Addr().Interface().(RegistryCodec)
+		registryID, opt, _ := strings.Cut(tag, ",")
+		f(registryID, opt == "builtin", codecVal.Field(i).Addr().Interface().(RegistryCodec))
+	}
+}
+
+// KnownPacks returns all data packs which the entries of the registries are loaded from.
+func (c *Registries) KnownPacks() []KnownPack {
+	var packs []KnownPack
+	c.Range(func(id string, r RegistryCodec) {
+		for _, v := range r.KnownPacks() {
+			if !slices.Contains(packs, v) {
+				packs = append(packs, v)
+			}
 		}
+	})
+	return packs
+}
+
+// ReadTagsFrom reads the tags of all registries, in the format of UpdateTags packet.
+// The tags of the builtin registries not in c are bound to c.Builtins.
+func (c *Registries) ReadTagsFrom(r io.Reader) (int64, error) {
+	var count pk.VarInt
+	n, err := count.ReadFrom(r)
+	if err != nil {
+		return n, err
 	}
-	return nil
+	var registryID pk.Identifier
+	for i := 0; i < int(count); i++ {
+		n1, err := registryID.ReadFrom(r)
+		n += n1
+		if err != nil {
+			return n, err
+		}
+		registry := c.Registry(string(registryID))
+		if registry == nil {
+			if builtin := c.Builtin(string(registryID)); builtin != nil {
+				registry = builtin
+			}
+		}
+		if registry == nil {
+			return n, errors.New("unknown registry: " + string(registryID))
+		}
+		n2, err := registry.ReadTagsFrom(r)
+		n += n2
+		if err != nil {
+			return n, fmt.Errorf("read tags of %s: %w", registryID, err)
+		}
+	}
+	return n, nil
+}
+
+// WriteTagsTo writes the tags of all registries, in the format of UpdateTags packet.
+func (c *Registries) WriteTagsTo(w io.Writer) (int64, error) {
+	var tags bytes.Buffer
+	var count int
+	var err error
+	writeTags := func(id string, r RegistryCodec) {
+		if err != nil {
+			return
+		}
+		count++
+		_, _ = pk.Identifier(id).WriteTo(&tags)
+		_, err = r.WriteTagsTo(&tags)
+	}
+	c.Range(writeTags)
+	c.RangeBuiltin(writeTags)
+	if err != nil {
+		return 0, err
+	}
+	n, err := pk.VarInt(count).WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+	n1, err := tags.WriteTo(w)
+	return n + n1, err
 }
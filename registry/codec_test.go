@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+func TestRegistries_typed(t *testing.T) {
+	r := Vanilla()
+
+	_, sharpness := r.Enchantment.Get("minecraft:sharpness")
+	if sharpness == nil || len(sharpness.Effects.Damage) != 1 {
+		t.Fatalf("unexpected sharpness: %+v", sharpness)
+	}
+	if damage := sharpness.Effects.Damage[0].Effect.Process(5, nil, 1); damage != 4 {
+		t.Errorf("damage of sharpness V: got %v, want 4", damage)
+	}
+	if tag, ok := sharpness.ExclusiveSet.Tag(); !ok || tag != "minecraft:exclusive_set/damage" {
+		t.Errorf("exclusive set of sharpness: %v", sharpness.ExclusiveSet)
+	}
+
+	_, unbreaking := r.Enchantment.Get("minecraft:unbreaking")
+	if chance := unbreaking.Effects.ItemDamage[1].Effect.Chance.Calculate(3); chance != 0.75 {
+		t.Errorf("chance of unbreaking III: got %v, want 0.75", chance)
+	}
+
+	_, plains := r.WorldGenBiome.Get("minecraft:plains")
+	if plains == nil || plains.Temperature != 0.8 || plains.Effects.SkyColor != 7907327 {
+		t.Errorf("unexpected plains: %+v", plains)
+	}
+
+	_, kebab := r.PaintingVariant.Get("minecraft:kebab")
+	if kebab == nil || kebab.Width != 1 || kebab.Height != 1 {
+		t.Errorf("unexpected kebab: %+v", kebab)
+	}
+}
+
+func TestRegistries_roundTrip(t *testing.T) {
+	src := Vanilla()
+	dst := NewNetworkCodec()
+	for _, id := range []string{"minecraft:worldgen/biome", "minecraft:enchantment", "minecraft:jukebox_song", "minecraft:wolf_variant"} {
+		var buf bytes.Buffer
+		if _, err := src.Registry(id).WriteTo(&buf); err != nil {
+			t.Fatalf("write %s: %v", id, err)
+		}
+		if _, err := dst.Registry(id).ReadFrom(&buf); err != nil {
+			t.Fatalf("read %s: %v", id, err)
+		}
+	}
+	for i := 0; i < src.Enchantment.Len(); i++ {
+		if want, got := src.Enchantment.GetByID(int32(i)), dst.Enchantment.GetByID(int32(i)); !reflect.DeepEqual(want, got) {
+			t.Errorf("enchantment %d: got %+v, want %+v", i, got, want)
+		}
+	}
+	for i := 0; i < src.WorldGenBiome.Len(); i++ {
+		if want, got := src.WorldGenBiome.GetByID(int32(i)), dst.WorldGenBiome.GetByID(int32(i)); !reflect.DeepEqual(want, got) {
+			t.Errorf("biome %d: got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestRegistries_Other(t *testing.T) {
+	src := NewRegistry[nbt.RawMessage]()
+	src.Put("go-mc:example", nbt.RawMessage{Type: nbt.TagString, Data: []byte{0, 2, 'h', 'i'}})
+	var buf bytes.Buffer
+	if _, err := src.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewNetworkCodec()
+	if r.Registry("go-mc:other") != nil {
+		t.Fatal("unknown registry shouldn't exist")
+	}
+	if _, err := r.Other("go-mc:other").ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, v := r.Other("go-mc:other").Get("go-mc:example"); v == nil || v.String() != "hi" {
+		t.Errorf("unexpected entry: %v", v)
+	}
+
+	var ids []string
+	r.Range(func(id string, _ RegistryCodec) { ids = append(ids, id) })
+	if ids[len(ids)-1] != "go-mc:other" {
+		t.Errorf("go-mc:other isn't ranged: %v", ids)
+	}
+	clone := r.Clone()
+	if clone.Registry("go-mc:other") == nil || clone.Others["go-mc:other"] == r.Others["go-mc:other"] {
+		t.Error("go-mc:other isn't cloned")
+	}
+	clone.Other("go-mc:other").Put("go-mc:added", nbt.RawMessage{})
+	if id, _ := r.Other("go-mc:other").Get("go-mc:added"); id != -1 {
+		t.Errorf("entry put into the clone is in the original: %d", id)
+	}
+}
+
+func TestRegistries_tags(t *testing.T) {
+	src := Vanilla()
+	if !src.Block.IsInTag("minecraft:obsidian", "minecraft:needs_diamond_tool") {
+		t.Error("obsidian isn't in minecraft:needs_diamond_tool")
+	}
+	if src.Block.IsInTag("minecraft:stone", "minecraft:needs_diamond_tool") {
+		t.Error("stone is in minecraft:needs_diamond_tool")
+	}
+	// IDs beyond the initial capacity of the registry
+	stick, _ := src.Item.Get("minecraft:stick")
+	if err := src.Item.BindTags("go-mc:sticks", []int32{stick}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Item.BindTags("go-mc:invalid", []int32{int32(src.Item.Len())}); err == nil {
+		t.Error("invalid id is bound")
+	}
+	instrument := src.Builtin("minecraft:instrument")
+	if err := instrument.BindTags("minecraft:goat_horns", []int32{0, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := src.WriteTagsTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dst := Vanilla()
+	dst.Fluid.ClearTags()
+	dst.DamageType.ClearTags()
+	if _, err := dst.ReadTagsFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !dst.Item.IsInTag("minecraft:stick", "go-mc:sticks") {
+		t.Errorf("go-mc:sticks: got %v, want [%d]", dst.Item.TagIDs("go-mc:sticks"), stick)
+	}
+	if !dst.Fluid.IsInTag("minecraft:flowing_water", "minecraft:water") {
+		t.Error("flowing water isn't in minecraft:water")
+	}
+	if len(dst.Builtin("minecraft:instrument").Tag("minecraft:goat_horns")) != 2 {
+		t.Error("tags of builtin registries not listed in Registries are lost")
+	}
+	if !slices.Equal(dst.DamageType.Tags(), src.DamageType.Tags()) {
+		t.Errorf("tags of damage type: got %v, want %v", dst.DamageType.Tags(), src.DamageType.Tags())
+	}
+}
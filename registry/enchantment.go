@@ -0,0 +1,238 @@
+package registry
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// Enchantment is an element of the minecraft:enchantment registry.
+type Enchantment struct {
+	Description    chat.Message       `nbt:"description"`
+	ExclusiveSet   HolderSet          `nbt:"exclusive_set,omitempty"`
+	SupportedItems HolderSet          `nbt:"supported_items"`
+	PrimaryItems   HolderSet          `nbt:"primary_items,omitempty"`
+	Weight         int32              `nbt:"weight"`
+	MaxLevel       int32              `nbt:"max_level"`
+	MinCost        EnchantmentCost    `nbt:"min_cost"`
+	MaxCost        EnchantmentCost    `nbt:"max_cost"`
+	AnvilCost      int32              `nbt:"anvil_cost"`
+	Slots          []string           `nbt:"slots"`
+	Effects        EnchantmentEffects `nbt:"effects"`
+}
+
+type EnchantmentCost struct {
+	Base               int32 `nbt:"base"`
+	PerLevelAboveFirst int32 `nbt:"per_level_above_first"`
+}
+
+// Calculate returns the cost of the enchantment at level.
+func (c EnchantmentCost) Calculate(level int32) int32 {
+	return c.Base + c.PerLevelAboveFirst*(level-1)
+}
+
+// EnchantmentEffects is the effect components of an enchantment.
+//
+// The requirements of the effects are loot item conditions,
+// and the effects which act on entities or locations are various,
+// so they are kept as raw NBT.
+type EnchantmentEffects struct {
+	DamageProtection          []ConditionalEffect[ValueEffect]            `nbt:"minecraft:damage_protection,omitempty"`
+	DamageImmunity            []ConditionalEffect[struct{}]               `nbt:"minecraft:damage_immunity,omitempty"`
+	Damage                    []ConditionalEffect[ValueEffect]            `nbt:"minecraft:damage,omitempty"`
+	SmashDamagePerFallenBlock []ConditionalEffect[ValueEffect]            `nbt:"minecraft:smash_damage_per_fallen_block,omitempty"`
+	Knockback                 []ConditionalEffect[ValueEffect]            `nbt:"minecraft:knockback,omitempty"`
+	ArmorEffectiveness        []ConditionalEffect[ValueEffect]            `nbt:"minecraft:armor_effectiveness,omitempty"`
+	PostAttack                []TargetedConditionalEffect[nbt.RawMessage] `nbt:"minecraft:post_attack,omitempty"`
+	HitBlock                  []ConditionalEffect[nbt.RawMessage]         `nbt:"minecraft:hit_block,omitempty"`
+	ItemDamage                []ConditionalEffect[ValueEffect]            `nbt:"minecraft:item_damage,omitempty"`
+	Attributes                []AttributeEffect                           `nbt:"minecraft:attributes,omitempty"`
+	EquipmentDrops            []TargetedConditionalEffect[ValueEffect]    `nbt:"minecraft:equipment_drops,omitempty"`
+	LocationChanged           []ConditionalEffect[nbt.RawMessage]         `nbt:"minecraft:location_changed,omitempty"`
+	Tick                      []ConditionalEffect[nbt.RawMessage]         `nbt:"minecraft:tick,omitempty"`
+	AmmoUse                   []ConditionalEffect[ValueEffect]            `nbt:"minecraft:ammo_use,omitempty"`
+	ProjectilePiercing        []ConditionalEffect[ValueEffect]            `nbt:"minecraft:projectile_piercing,omitempty"`
+	ProjectileSpawned         []ConditionalEffect[nbt.RawMessage]         `nbt:"minecraft:projectile_spawned,omitempty"`
+	ProjectileSpread          []ConditionalEffect[ValueEffect]            `nbt:"minecraft:projectile_spread,omitempty"`
+	ProjectileCount           []ConditionalEffect[ValueEffect]            `nbt:"minecraft:projectile_count,omitempty"`
+	TridentReturnAcceleration []ConditionalEffect[ValueEffect]            `nbt:"minecraft:trident_return_acceleration,omitempty"`
+	FishingTimeReduction      []ConditionalEffect[ValueEffect]            `nbt:"minecraft:fishing_time_reduction,omitempty"`
+	FishingLuckBonus          []ConditionalEffect[ValueEffect]            `nbt:"minecraft:fishing_luck_bonus,omitempty"`
+	BlockExperience           []ConditionalEffect[ValueEffect]            `nbt:"minecraft:block_experience,omitempty"`
+	MobExperience             []ConditionalEffect[ValueEffect]            `nbt:"minecraft:mob_experience,omitempty"`
+	RepairWithXP              []ConditionalEffect[ValueEffect]            `nbt:"minecraft:repair_with_xp,omitempty"`
+	CrossbowChargeTime        *ValueEffect                                `nbt:"minecraft:crossbow_charge_time,omitempty"`
+	CrossbowChargingSounds    []CrossbowSounds                            `nbt:"minecraft:crossbow_charging_sounds,omitempty"`
+	TridentSound              []SoundEvent                                `nbt:"minecraft:trident_sound,omitempty"`
+	PreventEquipmentDrop      *struct{}                                   `nbt:"minecraft:prevent_equipment_drop,omitempty"`
+	PreventArmorChange        *struct{}                                   `nbt:"minecraft:prevent_armor_change,omitempty"`
+	TridentSpinAttackStrength *ValueEffect                                `nbt:"minecraft:trident_spin_attack_strength,omitempty"`
+}
+
+// ConditionalEffect is an effect which only applies if the Requirements are met.
+type ConditionalEffect[E any] struct {
+	Effect       E               `nbt:"effect"`
+	Requirements *nbt.RawMessage `nbt:"requirements,omitempty"`
+}
+
+// TargetedConditionalEffect is a ConditionalEffect applied to the Affected entity,
+// when the Enchanted entity is involved. Both are one of "attacker", "damaging_entity" or "victim".
+type TargetedConditionalEffect[E any] struct {
+	Enchanted    string          `nbt:"enchanted"`
+	Affected     string          `nbt:"affected,omitempty"`
+	Effect       E               `nbt:"effect"`
+	Requirements *nbt.RawMessage `nbt:"requirements,omitempty"`
+}
+
+type AttributeEffect struct {
+	ID        string          `nbt:"id"`
+	Attribute string          `nbt:"attribute"`
+	Amount    LevelBasedValue `nbt:"amount"`
+	Operation string          `nbt:"operation"`
+}
+
+type CrossbowSounds struct {
+	Start *SoundEvent `nbt:"start,omitempty"`
+	Mid   *SoundEvent `nbt:"mid,omitempty"`
+	End   *SoundEvent `nbt:"end,omitempty"`
+}
+
+// ValueEffect modifies a value, such as the damage or the experience, by the level of the enchantment.
+type ValueEffect struct {
+	Type    string           `nbt:"type"`
+	Value   *LevelBasedValue `nbt:"value,omitempty"`   // minecraft:add and minecraft:set
+	Factor  *LevelBasedValue `nbt:"factor,omitempty"`  // minecraft:multiply
+	Chance  *LevelBasedValue `nbt:"chance,omitempty"`  // minecraft:remove_binomial
+	Effects []ValueEffect    `nbt:"effects,omitempty"` // minecraft:all_of
+}
+
+// Process applies the effect at level on value.
+// The random source is only used by minecraft:remove_binomial.
+func (v ValueEffect) Process(level int32, random *rand.Rand, value float32) float32 {
+	switch strings.TrimPrefix(v.Type, "minecraft:") {
+	case "add":
+		return value + v.Value.Calculate(level)
+	case "set":
+		return v.Value.Calculate(level)
+	case "multiply":
+		return value * v.Factor.Calculate(level)
+	case "remove_binomial":
+		chance := v.Chance.Calculate(level)
+		var removed float32
+		for i := 0; float32(i) < value; i++ {
+			if random.Float32() < chance {
+				removed++
+			}
+		}
+		return value - removed
+	case "all_of":
+		for _, e := range v.Effects {
+			value = e.Process(level, random, value)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// LevelBasedValue is a number computed from the level of an enchantment.
+// It's encoded as a TagFloat if Type is empty, otherwise a TagCompound.
+type LevelBasedValue struct {
+	Type     string  `nbt:"type"` // Empty for a constant
+	Constant float32 `nbt:"-"`
+
+	// minecraft:linear
+	Base               float32 `nbt:"base"`
+	PerLevelAboveFirst float32 `nbt:"per_level_above_first"`
+	// minecraft:levels_squared
+	Added float32 `nbt:"added"`
+	// minecraft:clamped
+	Value *LevelBasedValue `nbt:"value"`
+	Min   float32          `nbt:"min"`
+	Max   float32          `nbt:"max"`
+	// minecraft:fraction
+	Numerator   *LevelBasedValue `nbt:"numerator"`
+	Denominator *LevelBasedValue `nbt:"denominator"`
+	// minecraft:lookup
+	Values   []float32        `nbt:"values"`
+	Fallback *LevelBasedValue `nbt:"fallback"`
+}
+
+// Calculate returns the value at level.
+func (v *LevelBasedValue) Calculate(level int32) float32 {
+	if v == nil {
+		return 0
+	}
+	switch strings.TrimPrefix(v.Type, "minecraft:") {
+	case "":
+		return v.Constant
+	case "linear":
+		return v.Base + v.PerLevelAboveFirst*float32(level-1)
+	case "levels_squared":
+		return float32(level*level) + v.Added
+	case "clamped":
+		return min(max(v.Value.Calculate(level), v.Min), v.Max)
+	case "fraction":
+		denominator := v.Denominator.Calculate(level)
+		if denominator == 0 {
+			return 0
+		}
+		return v.Numerator.Calculate(level) / denominator
+	case "lookup":
+		if level > 0 && int(level) <= len(v.Values) {
+			return v.Values[level-1]
+		}
+		return v.Fallback.Calculate(level)
+	default:
+		return 0
+	}
+}
+
+func (v LevelBasedValue) TagType() byte {
+	if v.Type == "" {
+		return nbt.TagFloat
+	}
+	return nbt.TagCompound
+}
+
+func (v LevelBasedValue) MarshalNBT(w io.Writer) error {
+	var data any
+	switch strings.TrimPrefix(v.Type, "minecraft:") {
+	case "":
+		data = v.Constant
+	case "linear":
+		data = map[string]any{"type": v.Type, "base": v.Base, "per_level_above_first": v.PerLevelAboveFirst}
+	case "levels_squared":
+		data = map[string]any{"type": v.Type, "added": v.Added}
+	case "clamped":
+		data = map[string]any{"type": v.Type, "value": v.Value, "min": v.Min, "max": v.Max}
+	case "fraction":
+		data = map[string]any{"type": v.Type, "numerator": v.Numerator, "denominator": v.Denominator}
+	case "lookup":
+		data = map[string]any{"type": v.Type, "values": v.Values, "fallback": v.Fallback}
+	default:
+		return errors.New("unknown level based value type: " + v.Type)
+	}
+	return marshalPayload(w, data)
+}
+
+func (v *LevelBasedValue) UnmarshalNBT(tagType byte, r nbt.DecoderReader) error {
+	decoder := payloadDecoder(tagType, r)
+	*v = LevelBasedValue{}
+	switch tagType {
+	case nbt.TagByte, nbt.TagShort, nbt.TagInt, nbt.TagLong, nbt.TagFloat, nbt.TagDouble:
+		_, err := decoder.Decode(&v.Constant)
+		return err
+	case nbt.TagCompound:
+		type levelBasedValue LevelBasedValue
+		_, err := decoder.Decode((*levelBasedValue)(v))
+		return err
+	default:
+		return errors.New("unknown level based value type: '" + strconv.FormatUint(uint64(tagType), 16) + "'")
+	}
+}
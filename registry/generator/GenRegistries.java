@@ -0,0 +1,112 @@
+package pers.tnze.gomc.gen;
+
+import net.minecraft.SharedConstants;
+import net.minecraft.core.LayeredRegistryAccess;
+import net.minecraft.core.Registry;
+import net.minecraft.core.RegistryAccess;
+import net.minecraft.nbt.*;
+import net.minecraft.resources.RegistryDataLoader;
+import net.minecraft.resources.ResourceKey;
+import net.minecraft.resources.ResourceLocation;
+import net.minecraft.server.Bootstrap;
+import net.minecraft.server.RegistryLayer;
+import net.minecraft.server.packs.PackType;
+import net.minecraft.server.packs.repository.PackRepository;
+import net.minecraft.server.packs.repository.ServerPacksSource;
+import net.minecraft.server.packs.resources.CloseableResourceManager;
+import net.minecraft.server.packs.resources.MultiPackResourceManager;
+import net.minecraft.core.registries.BuiltInRegistries;
+import net.minecraft.core.registries.Registries;
+import net.minecraft.core.RegistrySynchronization;
+import net.minecraft.tags.TagLoader;
+
+import java.io.DataOutput;
+import java.io.DataOutputStream;
+import java.io.FileOutputStream;
+import java.util.Collection;
+import java.util.List;
+import java.util.Map;
+import java.util.Set;
+import java.util.zip.GZIPOutputStream;
+
+// Generates vanilla.nbt, which stores the synchronized registries of the vanilla data pack, and their tags:
+//
+//	{
+//	    "minecraft:dimension_type": {
+//	        entries: [{name: "minecraft:overworld", element: {...}}, ...],
+//	        tags: {"minecraft:xxx": ["minecraft:overworld", ...], ...}
+//	    },
+//	    ...
+//	}
+//
+// The entries are in the order of their network IDs, and the tags are flattened.
+// The builtin registries, such as minecraft:block, only have their tags stored.
+public class GenRegistries {
+
+    public static void main(String[] args) throws Exception {
+        System.out.println("program start!");
+        SharedConstants.tryDetectVersion();
+        Bootstrap.bootStrap();
+
+        PackRepository packs = ServerPacksSource.createVanillaTrustedRepository();
+        packs.reload();
+        packs.setSelected(List.of("vanilla"));
+        try (CloseableResourceManager resources = new MultiPackResourceManager(PackType.SERVER_DATA, packs.openAllSelected())) {
+            LayeredRegistryAccess<RegistryLayer> layers = RegistryLayer.createRegistryAccess();
+            RegistryAccess.Frozen worldgen = RegistryDataLoader.load(
+                    resources,
+                    layers.getAccessForLoading(RegistryLayer.WORLDGEN),
+                    RegistryDataLoader.WORLDGEN_REGISTRIES
+            );
+            RegistryAccess.Frozen access = layers.replaceFrom(RegistryLayer.WORLDGEN, worldgen).compositeAccess();
+
+            CompoundTag root = new CompoundTag();
+            // Pretend the client knows nothing, so all data are packed.
+            RegistrySynchronization.packRegistries(NbtOps.INSTANCE, access, Set.of(), (key, entries) -> {
+                ListTag list = new ListTag();
+                for (RegistrySynchronization.PackedRegistryEntry entry : entries) {
+                    CompoundTag e = new CompoundTag();
+                    e.putString("name", entry.id().toString());
+                    e.put("element", entry.data().orElseThrow());
+                    list.add(e);
+                }
+                CompoundTag registry = new CompoundTag();
+                registry.put("entries", list);
+                registry.put("tags", genTags(resources, access.registryOrThrow(key)));
+                root.put(key.location().toString(), registry);
+            });
+            // The builtin registries are not synchronized, only their tags are stored.
+            for (Registry<?> registry : BuiltInRegistries.REGISTRY) {
+                CompoundTag tags = genTags(resources, registry);
+                if (!tags.isEmpty()) {
+                    CompoundTag compound = new CompoundTag();
+                    compound.put("tags", tags);
+                    root.put(registry.key().location().toString(), compound);
+                }
+            }
+
+            try (FileOutputStream f = new FileOutputStream("vanilla.nbt")) {
+                try (GZIPOutputStream g = new GZIPOutputStream(f)) {
+                    DataOutput writer = new DataOutputStream(g);
+                    NbtIo.writeUnnamedTag(root, writer);
+                }
+            }
+        }
+    }
+
+    private static <T> CompoundTag genTags(CloseableResourceManager resources, Registry<T> registry) {
+        ResourceKey<? extends Registry<T>> key = registry.key();
+        TagLoader<T> loader = new TagLoader<>(registry::getOptional, Registries.tagsDirPath(key));
+        Map<ResourceLocation, Collection<T>> tags = loader.loadAndBuild(resources);
+
+        CompoundTag compound = new CompoundTag();
+        for (Map.Entry<ResourceLocation, Collection<T>> tag : tags.entrySet()) {
+            ListTag values = new ListTag();
+            for (T value : tag.getValue()) {
+                values.add(StringTag.valueOf(registry.getKey(value).toString()));
+            }
+            compound.put(tag.getKey().toString(), values);
+        }
+        return compound;
+    }
+}
@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// HolderSet is a set of registry entries.
+// In NBT, it's either a tag prefixed with '#', a single ID, or a list of IDs.
+type HolderSet []string
+
+// Tag returns the tag name if the set refers to a tag.
+func (h HolderSet) Tag() (string, bool) {
+	if len(h) == 1 && strings.HasPrefix(h[0], "#") {
+		return h[0][1:], true
+	}
+	return "", false
+}
+
+func (h HolderSet) TagType() byte {
+	if len(h) == 1 {
+		return nbt.TagString
+	}
+	return nbt.TagList
+}
+
+func (h HolderSet) MarshalNBT(w io.Writer) error {
+	if len(h) == 1 {
+		return marshalPayload(w, h[0])
+	}
+	return marshalPayload(w, []string(h))
+}
+
+func (h *HolderSet) UnmarshalNBT(tagType byte, r nbt.DecoderReader) error {
+	decoder := payloadDecoder(tagType, r)
+	switch tagType {
+	case nbt.TagString:
+		var id string
+		if _, err := decoder.Decode(&id); err != nil {
+			return err
+		}
+		*h = HolderSet{id}
+		return nil
+	case nbt.TagList:
+		var ids []string
+		if _, err := decoder.Decode(&ids); err != nil {
+			return err
+		}
+		*h = ids
+		return nil
+	default:
+		return errors.New("unknown holder set type: '" + strconv.FormatUint(uint64(tagType), 16) + "'")
+	}
+}
+
+// SoundEvent is a sound, which is either referenced by its ID,
+// or defined directly with a fixed range.
+type SoundEvent struct {
+	SoundID    string  `nbt:"sound_id"`
+	FixedRange float32 `nbt:"range,omitempty"` // 0 if the range depends on the volume
+}
+
+func (s SoundEvent) TagType() byte {
+	if s.FixedRange == 0 {
+		return nbt.TagString
+	}
+	return nbt.TagCompound
+}
+
+func (s SoundEvent) MarshalNBT(w io.Writer) error {
+	if s.FixedRange == 0 {
+		return marshalPayload(w, s.SoundID)
+	}
+	type soundEvent SoundEvent
+	return marshalPayload(w, soundEvent(s))
+}
+
+func (s *SoundEvent) UnmarshalNBT(tagType byte, r nbt.DecoderReader) error {
+	decoder := payloadDecoder(tagType, r)
+	switch tagType {
+	case nbt.TagString:
+		*s = SoundEvent{}
+		_, err := decoder.Decode(&s.SoundID)
+		return err
+	case nbt.TagCompound:
+		type soundEvent SoundEvent
+		*s = SoundEvent{}
+		_, err := decoder.Decode((*soundEvent)(s))
+		return err
+	default:
+		return errors.New("unknown sound event type: '" + strconv.FormatUint(uint64(tagType), 16) + "'")
+	}
+}
+
+// marshalPayload writes v without the tag type, which is written by the caller.
+func marshalPayload(w io.Writer, v any) error {
+	var buf bytes.Buffer
+	encoder := nbt.NewEncoder(&buf)
+	encoder.NetworkFormat(true)
+	if err := encoder.Encode(v, ""); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes()[1:])
+	return err
+}
+
+// payloadDecoder returns a decoder which reads the payload of a tag typed tagType from r.
+func payloadDecoder(tagType byte, r nbt.DecoderReader) *nbt.Decoder {
+	tagReader := bytes.NewReader([]byte{tagType})
+	decoder := nbt.NewDecoder(io.MultiReader(tagReader, r))
+	decoder.NetworkFormat(true) // TagType directly followed the body
+	return decoder
+}
@@ -1,13 +1,15 @@
 package registry
 
 import (
-	"errors"
 	"io"
-	"strconv"
+	"slices"
 
 	pk "github.com/Tnze/go-mc/net/packet"
 )
 
+// ReadFrom reads the entries of the registry from RegistryData packet.
+// The entries without data are from the data packs known by both sides,
+// their data are taken from the entries with the same key in the registry before reading.
 func (reg *Registry[E]) ReadFrom(r io.Reader) (int64, error) {
 	var length pk.VarInt
 	n, err := length.ReadFrom(r)
@@ -15,6 +17,7 @@ func (reg *Registry[E]) ReadFrom(r io.Reader) (int64, error) {
 		return n, err
 	}
 
+	known := *reg
 	reg.Clear()
 
 	var key pk.Identifier
@@ -39,6 +42,11 @@ func (reg *Registry[E]) ReadFrom(r io.Reader) (int64, error) {
 				return n + n1 + n2 + n3, err
 			}
 			reg.Put(string(key), data)
+		} else if id, ok := known.keys[string(key)]; ok {
+			reg.PutKnown(string(key), known.values[id], known.packs[id])
+		} else {
+			// The entry must still be added to keep the IDs of others correct.
+			reg.Put(string(key), data)
 		}
 
 		n += n1 + n2 + n3
@@ -53,41 +61,101 @@ func (reg *Registry[E]) ReadTagsFrom(r io.Reader) (int64, error) {
 		return n, err
 	}
 
+	// The tags of the registry are all replaced
+	reg.ClearTags()
 	var tag pk.Identifier
-	var length pk.VarInt
+	var ids []pk.VarInt
 	for i := 0; i < int(count); i++ {
-		var n1, n2, n3 int64
-
-		n1, err = tag.ReadFrom(r)
+		n1, err := pk.Tuple{&tag, pk.Array(&ids)}.ReadFrom(r)
+		n += n1
 		if err != nil {
-			return n + n1, err
+			return n, err
 		}
-
-		n2, err = length.ReadFrom(r)
-		if err != nil {
-			return n + n1 + n2, err
+		values := make([]int32, len(ids))
+		for i, id := range ids {
+			values[i] = int32(id)
 		}
+		if err := reg.BindTags(string(tag), values); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
 
-		n += n1 + n2
-		values := make([]*E, length)
-
-		var id pk.VarInt
-		for i := 0; i < int(length); i++ {
-			n3, err = id.ReadFrom(r)
-			if err != nil {
-				return n + n3, err
-			}
-
-			if id < 0 || int(id) >= len(reg.values) {
-				err = errors.New("invalid id: " + strconv.Itoa(int(id)))
-				return n + n3, err
-			}
+// WriteTo writes the entries of the registry with their data, in the format of RegistryData packet.
+func (reg *Registry[E]) WriteTo(w io.Writer) (int64, error) {
+	return reg.WriteKnownTo(w, nil)
+}
 
-			values[i] = &reg.values[id]
-			n += n3
+// WriteKnownTo writes the entries of the registry in the format of RegistryData packet.
+// The data of the entries loaded from the known packs are omitted, the client will use its own.
+func (reg *Registry[E]) WriteKnownTo(w io.Writer, known []KnownPack) (int64, error) {
+	keys := make([]string, len(reg.values))
+	for k, id := range reg.keys {
+		keys[id] = k
+	}
+	n, err := pk.VarInt(len(keys)).WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+	for id, key := range keys {
+		pack, ok := reg.KnownPack(int32(id))
+		hasData := !ok || !slices.Contains(known, pack)
+		n1, err := pk.Tuple{
+			pk.Identifier(key),
+			pk.Boolean(hasData),
+			pk.Opt{
+				Has:   hasData,
+				Field: pk.NBT(&reg.values[id]),
+			},
+		}.WriteTo(w)
+		n += n1
+		if err != nil {
+			return n, err
 		}
+	}
+	return n, nil
+}
 
-		reg.tags[string(tag)] = values
+// WriteTagsTo writes the tags of the registry, in the format of UpdateTags packet.
+func (reg *Registry[E]) WriteTagsTo(w io.Writer) (int64, error) {
+	n, err := pk.VarInt(len(reg.tags)).WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+	for tag, values := range reg.tags {
+		ids := make([]pk.VarInt, len(values))
+		for i, id := range values {
+			ids[i] = pk.VarInt(id)
+		}
+		n1, err := pk.Tuple{pk.Identifier(tag), pk.Array(ids)}.WriteTo(w)
+		n += n1
+		if err != nil {
+			return n, err
+		}
 	}
 	return n, nil
 }
+
+// KnownPack identifies a data pack in the known packs negotiation of the configuration phase.
+type KnownPack struct {
+	Namespace string
+	ID        string
+	Version   string
+}
+
+func (k KnownPack) WriteTo(w io.Writer) (int64, error) {
+	return pk.Tuple{
+		pk.String(k.Namespace),
+		pk.String(k.ID),
+		pk.String(k.Version),
+	}.WriteTo(w)
+}
+
+func (k *KnownPack) ReadFrom(r io.Reader) (int64, error) {
+	return pk.Tuple{
+		(*pk.String)(&k.Namespace),
+		(*pk.String)(&k.ID),
+		(*pk.String)(&k.Version),
+	}.ReadFrom(r)
+}
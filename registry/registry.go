@@ -1,28 +1,35 @@
 package registry
 
-import "slices"
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+)
 
 type Registry[E any] struct {
-	keys    map[string]int32
-	values  []E
-	indices map[*E]int32
-	tags    map[string][]*E
+	keys   map[string]int32
+	values []E
+	packs  []KnownPack        // the data pack each entry comes from, zero value if not from a known pack
+	tags   map[string][]int32 // the IDs of the entries in each tag
 }
 
 func NewRegistry[E any]() Registry[E] {
 	return Registry[E]{
-		keys:    make(map[string]int32),
-		values:  make([]E, 0, 256),
-		indices: make(map[*E]int32),
-		tags:    make(map[string][]*E),
+		keys:   make(map[string]int32),
+		values: make([]E, 0, 256),
+		packs:  make([]KnownPack, 0, 256),
+		tags:   make(map[string][]int32),
 	}
 }
 
 func (r *Registry[E]) Clear() {
 	r.keys = make(map[string]int32)
-	r.values = r.values[:0]
-	r.indices = make(map[*E]int32)
-	r.tags = make(map[string][]*E)
+	// Allocate a new slice instead of reusing the old one,
+	// the values might be shared with other registries by Clone.
+	r.values = make([]E, 0, 256)
+	r.packs = make([]KnownPack, 0, 256)
+	r.tags = make(map[string][]int32)
 }
 
 func (r *Registry[E]) Get(key string) (int32, *E) {
@@ -41,32 +48,109 @@ func (r *Registry[E]) GetByID(id int32) *E {
 }
 
 func (r *Registry[E]) Put(key string, data E) (id int32, val *E) {
+	return r.PutKnown(key, data, KnownPack{})
+}
+
+// PutKnown adds an entry which is loaded from the data pack.
+// When the client also knows the pack, the data of the entry is not sent to it.
+func (r *Registry[E]) PutKnown(key string, data E, pack KnownPack) (id int32, val *E) {
 	id = int32(len(r.values))
 	r.keys[key] = id
 	r.values = append(r.values, data)
+	r.packs = append(r.packs, pack)
 	val = &r.values[id]
-	r.indices[val] = id
 	return
 }
 
+// KnownPack returns the data pack which the entry is loaded from.
+// The ok is false if the entry doesn't exist or isn't from a known pack.
+func (r *Registry[E]) KnownPack(id int32) (pack KnownPack, ok bool) {
+	if id < 0 || id >= int32(len(r.packs)) {
+		return KnownPack{}, false
+	}
+	return r.packs[id], r.packs[id] != (KnownPack{})
+}
+
+// KnownPacks returns all data packs which the entries are loaded from.
+func (r *Registry[E]) KnownPacks() []KnownPack {
+	var packs []KnownPack
+	for _, v := range r.packs {
+		if v != (KnownPack{}) && !slices.Contains(packs, v) {
+			packs = append(packs, v)
+		}
+	}
+	return packs
+}
+
+func (r *Registry[E]) Len() int {
+	return len(r.values)
+}
+
+// Clone returns a copy of the registry.
+// The entries are shared with the original registry and must not be modified,
+// but the keys and tags are copied, so that new entries can be put and the tags bound independently.
+func (r *Registry[E]) Clone() Registry[E] {
+	tags := make(map[string][]int32, len(r.tags))
+	for k, v := range r.tags {
+		tags[k] = v
+	}
+	return Registry[E]{
+		keys:   maps.Clone(r.keys),
+		values: slices.Clip(r.values),
+		packs:  slices.Clip(r.packs),
+		tags:   tags,
+	}
+}
+
 // Tags
 
+// Tag returns the entries in the tag.
 func (r *Registry[E]) Tag(tag string) []*E {
+	ids := r.tags[tag]
+	values := make([]*E, len(ids))
+	for i, id := range ids {
+		values[i] = &r.values[id]
+	}
+	return values
+}
+
+// TagIDs returns the IDs of the entries in the tag.
+func (r *Registry[E]) TagIDs(tag string) []int32 {
 	return slices.Clone(r.tags[tag])
 }
 
+// Tags returns the names of all tags.
+func (r *Registry[E]) Tags() []string {
+	tags := make([]string, 0, len(r.tags))
+	for tag := range r.tags {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+// IsInTag reports whether the entry key is in the tag.
+func (r *Registry[E]) IsInTag(key, tag string) bool {
+	id, ok := r.keys[key]
+	return ok && r.IsIDInTag(id, tag)
+}
+
+// IsIDInTag reports whether the entry of the id is in the tag.
+func (r *Registry[E]) IsIDInTag(id int32, tag string) bool {
+	return slices.Contains(r.tags[tag], id)
+}
+
 func (r *Registry[E]) ClearTags() {
-	r.tags = make(map[string][]*E)
-}
-
-// func (r *Registry[E]) BindTags(tag string, ids []int32) error {
-// 	values := make([]*E, len(ids))
-// 	for i, id := range ids {
-// 		if id < 0 || id >= int32(len(r.values)) {
-// 			return errors.New("invalid id: " + strconv.Itoa(int(id)))
-// 		}
-// 		values[i] = &r.values[id]
-// 	}
-// 	r.tags[tag] = values
-// 	return nil
-// }
+	r.tags = make(map[string][]int32)
+}
+
+// BindTags sets the entries of the tag by their IDs, replacing the old ones.
+func (r *Registry[E]) BindTags(tag string, ids []int32) error {
+	for _, id := range ids {
+		if id < 0 || id >= int32(len(r.values)) {
+			return errors.New("invalid id: " + strconv.Itoa(int(id)))
+		}
+	}
+	r.tags[tag] = slices.Clone(ids)
+	return nil
+}
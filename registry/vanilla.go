@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"fmt"
+	"sync"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// CorePack is the vanilla data pack of the supported version.
+// The entries returned by Vanilla are all loaded from it.
+var CorePack = KnownPack{Namespace: "minecraft", ID: "core", Version: "1.21.1"}
+
+// This file stores all synchronized registries of the vanilla data pack and their tags into a TAG_Compound with gzip compressed.
+// The tags of the builtin registries are also stored, without their entries.
+// It's generated by generator/GenRegistries.java.
+//
+//go:embed vanilla.nbt
+var vanillaData []byte
+
+var vanilla = sync.OnceValue(func() Registries {
+	r, err := loadVanilla()
+	if err != nil {
+		panic(err)
+	}
+	return r
+})
+
+// Vanilla returns the registries of the vanilla data pack.
+// The entries are shared between all calls and must not be modified, see [Registry.Clone].
+func Vanilla() Registries {
+	r := vanilla()
+	return r.Clone()
+}
+
+type snapshotRegistry struct {
+	Entries []struct {
+		Name    string         `nbt:"name"`
+		Element nbt.RawMessage `nbt:"element"`
+	} `nbt:"entries"`
+	Tags map[string][]string `nbt:"tags"`
+}
+
+func loadVanilla() (Registries, error) {
+	z, err := gzip.NewReader(bytes.NewReader(vanillaData))
+	if err != nil {
+		return Registries{}, err
+	}
+	var snapshot map[string]snapshotRegistry
+	if _, err := nbt.NewDecoder(z).Decode(&snapshot); err != nil {
+		return Registries{}, err
+	}
+
+	registries := NewNetworkCodec()
+	registries.Range(func(id string, r RegistryCodec) {
+		if err != nil {
+			return
+		}
+		data, ok := snapshot[id]
+		if !ok {
+			err = fmt.Errorf("registry %s not found in the vanilla data", id)
+			return
+		}
+		err = r.(snapshotLoader).loadSnapshot(data, CorePack)
+		if err != nil {
+			err = fmt.Errorf("load registry %s: %w", id, err)
+		}
+	})
+	// Only the tags are stored for the builtin registries
+	for id := range snapshot {
+		if registries.Registry(id) == nil {
+			registries.Builtin(id)
+		}
+	}
+	registries.RangeBuiltin(func(id string, r RegistryCodec) {
+		if data, ok := snapshot[id]; ok && err == nil {
+			err = r.(snapshotLoader).loadSnapshot(data, CorePack)
+			if err != nil {
+				err = fmt.Errorf("load tags of %s: %w", id, err)
+			}
+		}
+	})
+	return registries, err
+}
+
+type snapshotLoader interface {
+	loadSnapshot(data snapshotRegistry, pack KnownPack) error
+}
+
+func (r *Registry[E]) loadSnapshot(data snapshotRegistry, pack KnownPack) error {
+	for _, v := range data.Entries {
+		var value E
+		if err := v.Element.Unmarshal(&value); err != nil {
+			return fmt.Errorf("entry %s: %w", v.Name, err)
+		}
+		r.PutKnown(v.Name, value, pack)
+	}
+	for tag, keys := range data.Tags {
+		ids := make([]int32, len(keys))
+		for i, key := range keys {
+			id, ok := r.keys[key]
+			if !ok {
+				return fmt.Errorf("tag %s: unknown entry %s", tag, key)
+			}
+			ids[i] = id
+		}
+		r.tags[tag] = ids
+	}
+	return nil
+}
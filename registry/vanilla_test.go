@@ -0,0 +1,119 @@
+package registry
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestVanilla(t *testing.T) {
+	r := Vanilla()
+
+	id, overworld := r.DimensionType.Get("minecraft:overworld")
+	if overworld == nil {
+		t.Fatal("minecraft:overworld not found")
+	}
+	if overworld.MinY != -64 || overworld.Height != 384 || !overworld.HasSkylight {
+		t.Errorf("unexpected overworld: %+v", overworld)
+	}
+	if pack, ok := r.DimensionType.KnownPack(id); !ok || pack != CorePack {
+		t.Errorf("overworld is from %v, want %v", pack, CorePack)
+	}
+	if packs := r.KnownPacks(); len(packs) != 1 || packs[0] != CorePack {
+		t.Errorf("known packs: %v", packs)
+	}
+	r.Range(func(id string, c RegistryCodec) {
+		if c.(interface{ Len() int }).Len() == 0 {
+			t.Errorf("registry %s is empty", id)
+		}
+	})
+	if fire := r.DamageType.Tag("minecraft:is_fire"); len(fire) == 0 {
+		t.Error("tag minecraft:is_fire is empty")
+	}
+}
+
+func TestVanilla_tags(t *testing.T) {
+	// The numbers of tags in each registry of the vanilla data pack
+	want := map[string]int{
+		"minecraft:chat_type":        0,
+		"minecraft:damage_type":      32,
+		"minecraft:dimension_type":   0,
+		"minecraft:trim_material":    0,
+		"minecraft:trim_pattern":     0,
+		"minecraft:worldgen/biome":   70,
+		"minecraft:wolf_variant":     0,
+		"minecraft:painting_variant": 1,
+		"minecraft:banner_pattern":   9,
+		"minecraft:enchantment":      22,
+		"minecraft:jukebox_song":     0,
+
+		"minecraft:block":                  184,
+		"minecraft:item":                   147,
+		"minecraft:fluid":                  2,
+		"minecraft:entity_type":            34,
+		"minecraft:game_event":             5,
+		"minecraft:instrument":             3,
+		"minecraft:point_of_interest_type": 3,
+		"minecraft:cat_variant":            2,
+	}
+	r := Vanilla()
+	checkCount := func(id string, c RegistryCodec) {
+		if got := len(c.(interface{ Tags() []string }).Tags()); got != want[id] {
+			t.Errorf("registry %s has %d tags, want %d", id, got, want[id])
+		}
+	}
+	r.Range(checkCount)
+	r.RangeBuiltin(checkCount)
+	if !r.WorldGenBiome.IsInTag("minecraft:plains", "minecraft:has_structure/village_plains") {
+		t.Error("minecraft:plains isn't in minecraft:has_structure/village_plains")
+	}
+	if !r.Block.IsInTag("minecraft:stone", "minecraft:mineable/pickaxe") {
+		t.Error("minecraft:stone isn't in minecraft:mineable/pickaxe")
+	}
+	if !r.Item.IsInTag("minecraft:oak_log", "minecraft:logs") || !r.Item.IsInTag("minecraft:crimson_stem", "minecraft:logs") {
+		t.Errorf("item tag minecraft:logs isn't filled: %v", r.Item.TagIDs("minecraft:logs"))
+	}
+}
+
+func TestRegistry_WriteKnownTo(t *testing.T) {
+	src := Vanilla()
+	_, overworld := src.DimensionType.Get("minecraft:overworld")
+	custom := *overworld
+	custom.MinY, custom.Height = 16, 32
+	_, _ = src.DimensionType.Put("go-mc:custom", custom)
+
+	var full, known bytes.Buffer
+	if _, err := src.DimensionType.WriteTo(&full); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.DimensionType.WriteKnownTo(&known, []KnownPack{CorePack}); err != nil {
+		t.Fatal(err)
+	}
+	if known.Len() >= full.Len() {
+		t.Errorf("data of known entries are not omitted: %d >= %d", known.Len(), full.Len())
+	}
+
+	// The client knowing the pack gets all entries back
+	dst := Vanilla()
+	if _, err := dst.DimensionType.ReadFrom(bytes.NewReader(known.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if dst.DimensionType.Len() != src.DimensionType.Len() {
+		t.Fatalf("got %d entries, want %d", dst.DimensionType.Len(), src.DimensionType.Len())
+	}
+	for _, key := range []string{"minecraft:the_nether", "go-mc:custom"} {
+		id1, want := src.DimensionType.Get(key)
+		id2, got := dst.DimensionType.Get(key)
+		if id1 != id2 || got.MinY != want.MinY || got.Height != want.Height {
+			t.Errorf("entry %s: got %d %+v, want %d %+v", key, id2, got, id1, want)
+		}
+	}
+
+	// The IDs are kept even if the client doesn't know the entries
+	empty := NewNetworkCodec()
+	if _, err := empty.DimensionType.ReadFrom(bytes.NewReader(known.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := empty.DimensionType.Get("go-mc:custom"); int(id) != src.DimensionType.Len()-1 {
+		t.Errorf("go-mc:custom has id %d", id)
+	}
+}
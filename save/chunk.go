@@ -1,28 +1,26 @@
 package save
 
 import (
-	"bytes"
-	"compress/gzip"
-	"compress/zlib"
-	"errors"
+	"fmt"
 	"io"
 
 	"github.com/Tnze/go-mc/nbt"
+	"github.com/Tnze/go-mc/save/region"
 )
 
 // Chunk is 16* chunk
 type Chunk struct {
-	BlockEntities  []nbt.RawMessage `nbt:"block_entities"`
-	BlockTicks     nbt.RawMessage   `nbt:"block_ticks"`
-	CarvingMasks   map[string][]uint64
+	BlockEntities  []nbt.RawMessage    `nbt:"block_entities"`
+	BlockTicks     nbt.RawMessage      `nbt:"block_ticks"`
+	CarvingMasks   map[string][]uint64 `nbt:"CarvingMasks,omitempty"`
 	DataVersion    int32
-	Entities       []nbt.RawMessage    `nbt:"entities"`
+	Entities       []nbt.RawMessage    `nbt:"entities,omitempty"`
 	FluidTicks     nbt.RawMessage      `nbt:"fluid_ticks"`
 	Heightmaps     map[string][]uint64 // keys: "WORLD_SURFACE_WG", "WORLD_SURFACE", "WORLD_SURFACE_IGNORE_SNOW", "OCEAN_FLOOR_WG", "OCEAN_FLOOR", "MOTION_BLOCKING", "MOTION_BLOCKING_NO_LEAVES"
 	InhabitedTime  int64
 	IsLightOn      byte `nbt:"isLightOn"`
 	LastUpdate     int64
-	Lights         []nbt.RawMessage
+	Lights         []nbt.RawMessage `nbt:"Lights,omitempty"`
 	PostProcessing nbt.RawMessage
 	Sections       []Section `nbt:"sections"`
 	Status         string
@@ -30,88 +28,64 @@ type Chunk struct {
 	XPos           int32          `nbt:"xPos"`
 	YPos           int32          `nbt:"yPos"`
 	ZPos           int32          `nbt:"zPos"`
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
 }
 
 type Section struct {
 	Y           int8
 	BlockStates PaletteContainer[BlockState] `nbt:"block_states"`
 	Biomes      PaletteContainer[BiomeState] `nbt:"biomes"`
-	SkyLight    []byte
-	BlockLight  []byte
+	SkyLight    []byte                       `nbt:"SkyLight,omitempty"`
+	BlockLight  []byte                       `nbt:"BlockLight,omitempty"`
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
 }
 
 type PaletteContainer[T any] struct {
 	Palette []T      `nbt:"palette"`
-	Data    []uint64 `nbt:"data"`
+	Data    []uint64 `nbt:"data,omitempty"` // Omitted if there is only one entry in the palette
 }
 
 type BlockState struct {
 	Name       string
-	Properties nbt.RawMessage
+	Properties nbt.RawMessage `nbt:"Properties,omitempty"`
 }
 
 type BiomeState string
 
-// Load read column data from []byte
+// Load read column data from []byte.
+// The first byte is the compression type, which is one of the Compression constants.
 func (c *Chunk) Load(data []byte) (err error) {
-	var r io.Reader = bytes.NewReader(data[1:])
-
-	switch data[0] {
-	default:
-		err = errors.New("unknown compression")
-	case 1:
-		r, err = gzip.NewReader(r)
-	case 2:
-		r, err = zlib.NewReader(r)
-	case 3:
-		// none compression
-	}
-	if err != nil {
-		return err
-	}
-
-	d := nbt.NewDecoder(r)
-	// d.DisallowUnknownFields()
-	_, err = d.Decode(c)
-	return
+	return loadNBT(data, c)
 }
 
+// Data encodes the chunk and compresses it with the compressingType,
+// which is one of the Compression constants except CompressionCustom.
 func (c *Chunk) Data(compressingType byte) ([]byte, error) {
-	var buff bytes.Buffer
+	return dataNBT(c, compressingType, "")
+}
 
-	buff.WriteByte(compressingType)
-	var w io.Writer
-	switch compressingType {
-	default:
-		return nil, errors.New("unknown compression")
-	case 1:
-		w = gzip.NewWriter(&buff)
-	case 2:
-		w = zlib.NewWriter(&buff)
-	case 3:
-		w = &buff
-	}
-	err := nbt.NewEncoder(w).Encode(c, "")
-	return buff.Bytes(), err
+// DataCustom encodes the chunk and compresses it with the Compressor registered as id.
+func (c *Chunk) DataCustom(id string) ([]byte, error) {
+	return dataNBT(c, CompressionCustom, id)
 }
 
-type Entities struct {
-	Pos, Motion  [3]float64
-	Rotation     [3]float32
-	FallDistance float32
-	Fire, Air    int16
+// CheckChunk returns a region.CheckFunc for the region (rx, rz),
+// which checks if the chunks can be decompressed and decoded, and are at the right positions.
+func CheckChunk(rx, rz int) region.CheckFunc {
+	return func(x, z int, data []byte) error {
+		var c Chunk
+		if err := c.Load(data); err != nil {
+			return err
+		}
+		if cx, cz := rx*32+x, rz*32+z; int(c.XPos) != cx || int(c.ZPos) != cz {
+			return fmt.Errorf("chunk position mismatch: (%d, %d) is stored at (%d, %d)", c.XPos, c.ZPos, cx, cz)
+		}
+		return nil
+	}
+}
 
-	OnGround       bool
-	Invulnerable   bool
-	PortalCooldown int32
-	UUID           [4]int32
+type nopCloser struct{ io.Writer }
 
-	CustomName        string
-	CustomNameVisible bool
-	Silent            bool
-	NoGravity         bool
-	Glowing           bool
-	TicksFrozen       int32
-	HasVisualFire     bool
-	Tags              []string
-}
+func (nopCloser) Close() error { return nil }
@@ -1,6 +1,8 @@
 package save
 
 import (
+	"compress/zlib"
+	"io"
 	"path/filepath"
 	"testing"
 
@@ -44,6 +46,55 @@ func TestColumn(t *testing.T) {
 	}
 }
 
+func TestChunk_Data(t *testing.T) {
+	r, err := region.Open("testdata/region/r.0.0.mca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := r.ReadSector(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Chunk
+	if err := c.Load(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// A custom compressor which is simply zlib
+	RegisterCompressor("go-mc:test", Compressor{
+		NewReader: func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil },
+	})
+
+	for _, compression := range []byte{CompressionGzip, CompressionZlib, CompressionNone, CompressionLZ4, CompressionCustom} {
+		var data []byte
+		if compression == CompressionCustom {
+			data, err = c.DataCustom("go-mc:test")
+		} else {
+			data, err = c.Data(compression)
+		}
+		if err != nil {
+			t.Fatalf("compression %d: %v", compression, err)
+		}
+		if data[0] != compression {
+			t.Errorf("compression %d: wrong type %d", compression, data[0])
+		}
+
+		var c2 Chunk
+		if err := c2.Load(data); err != nil {
+			t.Fatalf("compression %d: %v", compression, err)
+		}
+		if c2.XPos != c.XPos || c2.ZPos != c.ZPos || len(c2.Sections) != len(c.Sections) {
+			t.Errorf("compression %d: chunk mismatch", compression)
+		}
+	}
+
+	if _, err := c.DataCustom("go-mc:unknown"); err == nil {
+		t.Error("unknown custom compression should fail")
+	}
+}
+
 func BenchmarkColumn_Load(b *testing.B) {
 	// Test how many times we load a chunk
 	var c Chunk
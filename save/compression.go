@@ -0,0 +1,155 @@
+package save
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/Tnze/go-mc/save/lz4"
+)
+
+// The compression types of the chunks stored in region files,
+// which is the first byte of the data returned by region.Region.ReadSector.
+const (
+	CompressionGzip byte = 1
+	CompressionZlib byte = 2
+	CompressionNone byte = 3
+	CompressionLZ4  byte = 4 // Since 1.20.5
+	// CompressionCustom is followed by the ID of the Compressor, see RegisterCompressor.
+	CompressionCustom byte = 127
+
+	// CompressionExternal is set on the compression type if the chunk is stored in a .mcc file.
+	// The region package reads the .mcc file and clears this bit.
+	CompressionExternal byte = 128
+)
+
+// Compressor is a compression algorithm used by CompressionCustom.
+type Compressor struct {
+	NewReader func(r io.Reader) (io.Reader, error)
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+}
+
+var (
+	compressorsLock sync.RWMutex
+	compressors     = make(map[string]Compressor)
+)
+
+// RegisterCompressor makes a custom compression algorithm available by the id, like "mymod:zstd".
+// The chunks compressed by it can be read by Chunk.Load, and written by Chunk.DataCustom.
+func RegisterCompressor(id string, c Compressor) {
+	compressorsLock.Lock()
+	defer compressorsLock.Unlock()
+	compressors[id] = c
+}
+
+func getCompressor(id string) (Compressor, error) {
+	compressorsLock.RLock()
+	defer compressorsLock.RUnlock()
+	c, ok := compressors[id]
+	if !ok {
+		return c, errors.New("unknown custom compression: " + id)
+	}
+	return c, nil
+}
+
+// decompress returns the reader of the data, whose first byte is the compression type.
+func decompress(data []byte) (io.Reader, error) {
+	if len(data) == 0 {
+		return nil, errors.New("no compression type")
+	}
+	r := bytes.NewReader(data[1:])
+	switch data[0] {
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZlib:
+		return zlib.NewReader(r)
+	case CompressionNone:
+		return r, nil
+	case CompressionLZ4:
+		return lz4.NewReader(r), nil
+	case CompressionCustom:
+		// The ID is a string encoded by Java's DataOutput.writeUTF
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return nil, err
+		}
+		id := make([]byte, length)
+		if _, err := io.ReadFull(r, id); err != nil {
+			return nil, err
+		}
+		c, err := getCompressor(string(id))
+		if err != nil {
+			return nil, err
+		}
+		return c.NewReader(r)
+	}
+	if data[0]&CompressionExternal != 0 {
+		return nil, errors.New("the chunk is stored in an external .mcc file")
+	}
+	return nil, errors.New("unknown compression: " + strconv.Itoa(int(data[0])))
+}
+
+// compress writes the compression type into buff, and returns the writer compressing data into buff.
+// The id is only used by CompressionCustom.
+func compress(buff *bytes.Buffer, compressionType byte, id string) (io.WriteCloser, error) {
+	buff.WriteByte(compressionType)
+	switch compressionType {
+	case CompressionGzip:
+		return gzip.NewWriter(buff), nil
+	case CompressionZlib:
+		return zlib.NewWriter(buff), nil
+	case CompressionNone:
+		return nopCloser{buff}, nil
+	case CompressionLZ4:
+		return lz4.NewWriter(buff), nil
+	case CompressionCustom:
+		if id == "" {
+			return nil, errors.New("custom compression requires an id")
+		}
+		c, err := getCompressor(id)
+		if err != nil {
+			return nil, err
+		}
+		if len(id) > 0xFFFF {
+			return nil, errors.New("custom compression id too long")
+		}
+		_ = binary.Write(buff, binary.BigEndian, uint16(len(id)))
+		buff.WriteString(id)
+		return c.NewWriter(buff)
+	}
+	return nil, errors.New("unknown compression: " + strconv.Itoa(int(compressionType)))
+}
+
+// loadNBT decompresses the data and decodes it into v.
+func loadNBT(data []byte, v any) error {
+	r, err := decompress(data)
+	if err != nil {
+		return err
+	}
+	d := nbt.NewDecoder(r)
+	// d.DisallowUnknownFields()
+	_, err = d.Decode(v)
+	return err
+}
+
+// dataNBT encodes v and compresses it with the compressionType.
+func dataNBT(v any, compressionType byte, id string) ([]byte, error) {
+	var buff bytes.Buffer
+	w, err := compress(&buff, compressionType, id)
+	if err != nil {
+		return nil, err
+	}
+	err = nbt.NewEncoder(w).Encode(v, "")
+	if err != nil {
+		return nil, err
+	}
+	// flush the compressor
+	err = w.Close()
+	return buff.Bytes(), err
+}
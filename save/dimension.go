@@ -1,5 +1,7 @@
 package save
 
+import "github.com/Tnze/go-mc/nbt"
+
 // https://minecraft.fandom.com/wiki/Custom_dimension
 type DimensionType struct {
 	Ultrawarm bool `nbt:"ultrawarm"`
@@ -35,6 +37,8 @@ type WorldGenSettings struct {
 	GenerateFeatures bool                          `nbt:"generate_features"`
 	Seed             int64                         `nbt:"seed"`
 	Dimensions       map[string]DimensionGenerator `nbt:"dimensions"`
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
 }
 
 type DimensionGenerator struct {
@@ -0,0 +1,96 @@
+package save
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/Tnze/go-mc/save/region"
+)
+
+// EntityChunk is a chunk in the entities/*.mca region files, which stores the entities in the chunk since 1.17.
+type EntityChunk struct {
+	DataVersion int32
+	Position    [2]int32 // The chunk coordinates
+	Entities    []Entity
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+// Load read entity chunk data from []byte.
+// The first byte is the compression type, which is one of the Compression constants.
+func (c *EntityChunk) Load(data []byte) error {
+	return loadNBT(data, c)
+}
+
+// Data encodes the entity chunk and compresses it with the compressingType.
+func (c *EntityChunk) Data(compressingType byte) ([]byte, error) {
+	return dataNBT(c, compressingType, "")
+}
+
+// Entity is the common data of all entities.
+// The data specific to the entity type, like the health of mobs or the items of item frames,
+// is kept in the Unknown field.
+type Entity struct {
+	ID             string     `nbt:"id,omitempty"` // Omitted for the player
+	Pos            [3]float64 `nbt:"Pos"`
+	Motion         [3]float64 `nbt:"Motion"`
+	Rotation       [2]float32 `nbt:"Rotation"` // Yaw and pitch
+	FallDistance   float32    `nbt:"FallDistance"`
+	Fire           int16      `nbt:"Fire"`
+	Air            int16      `nbt:"Air"`
+	OnGround       bool       `nbt:"OnGround"`
+	Invulnerable   bool       `nbt:"Invulnerable"`
+	PortalCooldown int32      `nbt:"PortalCooldown"`
+	UUID           [4]int32   `nbt:"UUID"`
+
+	CustomName        string   `nbt:"CustomName,omitempty"` // In JSON text format
+	CustomNameVisible bool     `nbt:"CustomNameVisible,omitempty"`
+	Silent            bool     `nbt:"Silent,omitempty"`
+	NoGravity         bool     `nbt:"NoGravity,omitempty"`
+	Glowing           bool     `nbt:"Glowing,omitempty"`
+	TicksFrozen       int32    `nbt:"TicksFrozen,omitempty"`
+	HasVisualFire     bool     `nbt:"HasVisualFire,omitempty"`
+	Tags              []string `nbt:"Tags,omitempty"`
+	Passengers        []Entity `nbt:"Passengers,omitempty"`
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+// Entities is the previous name of Entity.
+//
+// Deprecated: use Entity instead.
+type Entities = Entity
+
+// EntityUUID returns the UUID of the entity.
+func (e *Entity) EntityUUID() (id uuid.UUID) {
+	for i, v := range e.UUID {
+		id[i*4] = byte(v >> 24)
+		id[i*4+1] = byte(v >> 16)
+		id[i*4+2] = byte(v >> 8)
+		id[i*4+3] = byte(v)
+	}
+	return
+}
+
+// ChunkPos returns the coordinates of the chunk where the entity is in.
+func (e *Entity) ChunkPos() (cx, cz int) {
+	return int(math.Floor(e.Pos[0])) >> 4, int(math.Floor(e.Pos[2])) >> 4
+}
+
+// CheckEntityChunk returns a region.CheckFunc for the entities region (rx, rz),
+// which checks if the chunks can be decompressed and decoded, and are at the right positions.
+func CheckEntityChunk(rx, rz int) region.CheckFunc {
+	return func(x, z int, data []byte) error {
+		var c EntityChunk
+		if err := c.Load(data); err != nil {
+			return err
+		}
+		if cx, cz := rx*32+x, rz*32+z; int(c.Position[0]) != cx || int(c.Position[1]) != cz {
+			return fmt.Errorf("chunk position mismatch: (%d, %d) is stored at (%d, %d)", c.Position[0], c.Position[1], cx, cz)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,95 @@
+package save
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Tnze/go-mc/save/region"
+)
+
+// forEachChunk calls f with every chunk in the region files matching the pattern.
+func forEachChunk(t *testing.T, pattern string, f func(rx, rz, x, z int, data []byte)) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range files {
+		var rx, rz int
+		if _, err := fmt.Sscanf(filepath.Base(filename), "r.%d.%d.mca", &rx, &rz); err != nil {
+			t.Fatal(err)
+		}
+		r, err := region.Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x := 0; x < 32; x++ {
+			for z := 0; z < 32; z++ {
+				if !r.ExistSector(x, z) {
+					continue
+				}
+				data, err := r.ReadSector(x, z)
+				if err != nil {
+					t.Fatalf("read %s sec (%d, %d) fail: %v", filepath.Base(filename), x, z, err)
+				}
+				f(rx, rz, x, z, data)
+			}
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertLossless checks if v encodes to the same NBT as the data.
+func assertLossless(t *testing.T, data []byte, v interface{ Data(byte) ([]byte, error) }) {
+	t.Helper()
+	data2, err := v.Data(CompressionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got any
+	if err := loadNBT(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadNBT(data2, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("round trip mismatch:\nwant %v\ngot  %v", want, got)
+	}
+}
+
+func TestEntityChunk(t *testing.T) {
+	var count int
+	forEachChunk(t, "testdata/entities/r.*.*.mca", func(rx, rz, x, z int, data []byte) {
+		if err := CheckEntityChunk(rx, rz)(x, z, data); err != nil {
+			t.Errorf("check chunk (%d, %d): %v", x, z, err)
+		}
+		var c EntityChunk
+		if err := c.Load(data); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range c.Entities {
+			if e.ID == "" {
+				t.Errorf("entity without id in chunk %v", c.Position)
+			}
+			if cx, cz := e.ChunkPos(); cx != int(c.Position[0]) || cz != int(c.Position[1]) {
+				t.Errorf("entity %s at chunk (%d, %d) is stored in chunk %v", e.ID, cx, cz, c.Position)
+			}
+			count++
+		}
+		assertLossless(t, data, &c)
+	})
+	if count == 0 {
+		t.Error("no entity found")
+	}
+}
+
+func TestEntity_EntityUUID(t *testing.T) {
+	e := Entity{UUID: [4]int32{1492530542, -1291040751, -1946364118, -1628856717}}
+	if got, want := e.EntityUUID().String(), "58f6356e-b30c-4811-8bfc-d72a9ee99e73"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -1,13 +1,25 @@
 package save
 
 import (
+	"compress/gzip"
 	"io"
 
 	"github.com/Tnze/go-mc/nbt"
 )
 
+const (
+	// DataVersion is the data version of Minecraft 1.21, the game version that go-mc supports.
+	DataVersion = 3953
+	// VersionName is the name of the game version of DataVersion.
+	VersionName = "1.21"
+)
+
+// Level is the content of level.dat.
+// The tags unknown to go-mc are kept in the Unknown fields, so that they're written back by WriteLevel.
 type Level struct {
 	Data LevelData
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
 }
 
 type LevelData struct {
@@ -24,41 +36,45 @@ type LevelData struct {
 	CustomBossEvents             map[string]CustomBossEvent
 	DataPacks                    struct {
 		Enabled, Disabled []string
+
+		Unknown map[string]nbt.RawMessage `nbt:",rest"`
 	}
 	DataVersion      int32
 	DayTime          int64
 	Difficulty       byte
 	DifficultyLocked bool
-	DimensionData    struct {
+	DimensionData    *struct { // Before 1.16
 		TheEnd struct {
 			DragonFight struct {
-				Gateways         []int32
+				Gateways         []int32 `nbt:",list"`
 				DragonKilled     byte
 				PreviouslyKilled byte
 			}
 		} `nbt:"1"`
-	}
+	} `nbt:",omitempty"`
 	DragonFight struct {
-		Gateways           []int32
+		Gateways           []int32 `nbt:",list"`
 		DragonKilled       bool
 		NeedsStateScanning bool
 		PreviouslyKilled   bool
-	}
+
+		Unknown map[string]nbt.RawMessage `nbt:",rest"`
+	} `nbt:",omitzero"` // Since 1.16
 	GameRules              map[string]string
-	WorldGenSettings       WorldGenSettings
+	WorldGenSettings       WorldGenSettings `nbt:",omitzero"` // Since 1.16
 	GameType               int32
 	HardCore               bool `nbt:"hardcore"`
 	Initialized            bool `nbt:"initialized"`
 	LastPlayed             int64
 	LevelName              string
-	MapFeatures            bool
-	Player                 map[string]any
-	Raining                bool  `nbt:"raining"`
-	RainTime               int32 `nbt:"rainTime"`
-	RandomSeed             int64
+	MapFeatures            bool        `nbt:",omitempty"` // Before 1.16
+	Player                 *PlayerData `nbt:",omitempty"` // The player of a singleplayer world
+	Raining                bool        `nbt:"raining"`
+	RainTime               int32       `nbt:"rainTime"`
+	RandomSeed             int64       `nbt:",omitempty"` // Before 1.16
 	ScheduledEvents        []nbt.RawMessage
 	ServerBrands           []string
-	SizeOnDisk             int64
+	SizeOnDisk             int64 `nbt:",omitempty"`
 	SpawnAngle             float32
 	SpawnX, SpawnY, SpawnZ int32
 	Thundering             bool  `nbt:"thundering"`
@@ -69,12 +85,16 @@ type LevelData struct {
 		Name     string
 		Series   string
 		Snapshot byte
+
+		Unknown map[string]nbt.RawMessage `nbt:",rest"`
 	}
-	StorageVersion             int32 `nbt:"version"`
-	WanderingTraderId          []int32
+	StorageVersion             int32   `nbt:"version"`
+	WanderingTraderId          []int32 `nbt:",omitempty"`
 	WanderingTraderSpawnChance int32
 	WanderingTraderSpawnDelay  int32
 	WasModded                  bool
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
 }
 
 type CustomBossEvent struct {
@@ -88,11 +108,25 @@ type CustomBossEvent struct {
 	Overlay        string
 	PlayBossMusic  bool
 	Visible        bool
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
 }
 
+// ReadLevel reads the uncompressed level.dat from r.
 func ReadLevel(r io.Reader) (data Level, err error) {
-	decoder := nbt.NewDecoder(r)
-	decoder.DisallowUnknownFields()
-	_, err = decoder.Decode(&data)
+	_, err = nbt.NewDecoder(r).Decode(&data)
 	return
 }
+
+// WriteLevel writes the level.dat into w, which is gzip-compressed as vanilla does.
+func WriteLevel(w io.Writer, data Level) error {
+	return writeGzip(w, data)
+}
+
+func writeGzip(w io.Writer, v any) error {
+	zw := gzip.NewWriter(w)
+	if err := nbt.NewEncoder(zw).Encode(v, ""); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -1,9 +1,13 @@
 package save
 
 import (
+	"bytes"
 	"compress/gzip"
 	"os"
+	"reflect"
 	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
 )
 
 func TestLevel(t *testing.T) {
@@ -33,3 +37,44 @@ func TestLevel(t *testing.T) {
 	//	t.Errorf("player data parse error: get %v, want %v", data, want)
 	//}
 }
+
+func TestWriteLevel(t *testing.T) {
+	raw, err := os.ReadFile("testdata/level.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadLevel(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Data.SpawnX = 100
+
+	var buf bytes.Buffer
+	if err := WriteLevel(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare with the whole original file, including the singleplayer player in the format of 1.18
+	want, got := readGzipNBT(t, raw), readGzipNBT(t, buf.Bytes())
+	want["Data"].(map[string]any)["SpawnX"] = int32(100)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("level.dat round trip mismatch:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+// readGzipNBT decodes the gzip-compressed NBT without any type information.
+func readGzipNBT(t *testing.T, data []byte) map[string]any {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]any
+	if _, err := nbt.NewDecoder(r).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
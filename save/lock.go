@@ -0,0 +1,55 @@
+package save
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// The locks held by this process.
+// The file locks are per-process on some systems, which don't prevent the same world from being opened twice in a process.
+var (
+	sessionsLock sync.Mutex
+	sessions     = make(map[string]bool)
+)
+
+// lockSession creates and locks the session.lock in the same way as vanilla,
+// and writes a snowman into it.
+func lockSession(name string) (*os.File, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+	sessionsLock.Lock()
+	defer sessionsLock.Unlock()
+	if sessions[abs] {
+		return nil, ErrLocked
+	}
+
+	f, err := os.OpenFile(abs, os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if err := f.Truncate(0); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if _, err := f.WriteString("☃"); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	sessions[abs] = true
+	return f, nil
+}
+
+func unlockSession(f *os.File) error {
+	sessionsLock.Lock()
+	defer sessionsLock.Unlock()
+	delete(sessions, f.Name())
+	// The lock is released by closing the file
+	return f.Close()
+}
@@ -0,0 +1,35 @@
+package save
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+	errorLockViolation      = syscall.Errno(33)
+)
+
+// lockFile acquires the exclusive lock of the whole file by LockFileEx, which is what Java's FileChannel.tryLock uses.
+func lockFile(f *os.File) error {
+	var overlapped syscall.Overlapped
+	r, _, err := procLockFileEx.Call(
+		f.Fd(),
+		lockfileExclusiveLock|lockfileFailImmediately,
+		0,
+		0xFFFFFFFF, 0x7FFFFFFF,
+		uintptr(unsafe.Pointer(&overlapped)),
+	)
+	if r != 0 {
+		return nil
+	}
+	if errors.Is(err, errorLockViolation) {
+		return ErrLocked
+	}
+	return err
+}
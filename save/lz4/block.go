@@ -0,0 +1,127 @@
+package lz4
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	minMatch     = 4
+	lastLiterals = 5  // The last 5 bytes of a block are always literals
+	mfLimit      = 12 // The last match must start at least 12 bytes before the end of a block
+	hashLog      = 14
+	maxOffset    = 1<<16 - 1
+)
+
+var errCorrupted = errors.New("lz4: corrupted block")
+
+// compressBlock appends the compressed src to dst, with a greedy matching of 4-byte sequences.
+func compressBlock(dst, src []byte) []byte {
+	var table [1 << hashLog]int32 // the positions of the sequences, plus 1
+	var anchor, i int
+	for limit := len(src) - mfLimit; i < limit; {
+		seq := binary.LittleEndian.Uint32(src[i:])
+		h := seq * prime1 >> (32 - hashLog)
+		ref := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if ref < 0 || i-ref > maxOffset || binary.LittleEndian.Uint32(src[ref:]) != seq {
+			i++
+			continue
+		}
+
+		matchLen := minMatch
+		for i+matchLen < len(src)-lastLiterals && src[ref+matchLen] == src[i+matchLen] {
+			matchLen++
+		}
+		dst = appendSequence(dst, src[anchor:i], i-ref, matchLen)
+		i += matchLen
+		anchor = i
+	}
+	// the last literals
+	litLen := len(src) - anchor
+	dst = append(dst, byte(min(litLen, 15)<<4))
+	dst = appendLength(dst, litLen)
+	return append(dst, src[anchor:]...)
+}
+
+func appendSequence(dst, literals []byte, offset, matchLen int) []byte {
+	litLen, matchLen := len(literals), matchLen-minMatch
+	dst = append(dst, byte(min(litLen, 15)<<4|min(matchLen, 15)))
+	dst = appendLength(dst, litLen)
+	dst = append(dst, literals...)
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(offset))
+	return appendLength(dst, matchLen)
+}
+
+// appendLength appends the bytes of the length which doesn't fit in the 4 bits of the token.
+func appendLength(dst []byte, n int) []byte {
+	if n < 15 {
+		return dst
+	}
+	for n -= 15; n >= 255; n -= 255 {
+		dst = append(dst, 255)
+	}
+	return append(dst, byte(n))
+}
+
+// decompressBlock decompresses src into dst, which must have the length of the original data.
+func decompressBlock(dst, src []byte) error {
+	var i, j int
+	for {
+		if i >= len(src) {
+			return errCorrupted
+		}
+		token := src[i]
+		i++
+
+		litLen, ok := readLength(src, &i, int(token>>4))
+		if !ok || litLen > len(src)-i || litLen > len(dst)-j {
+			return errCorrupted
+		}
+		j += copy(dst[j:], src[i:i+litLen])
+		i += litLen
+		if i == len(src) {
+			break // the last sequence has no match
+		}
+
+		if len(src)-i < 2 {
+			return errCorrupted
+		}
+		offset := int(binary.LittleEndian.Uint16(src[i:]))
+		i += 2
+		matchLen, ok := readLength(src, &i, int(token&0xF))
+		if !ok {
+			return errCorrupted
+		}
+		matchLen += minMatch
+		if offset == 0 || offset > j || matchLen > len(dst)-j {
+			return errCorrupted
+		}
+		// the match may overlap with the bytes being written
+		for k := 0; k < matchLen; k++ {
+			dst[j+k] = dst[j-offset+k]
+		}
+		j += matchLen
+	}
+	if j != len(dst) {
+		return errCorrupted
+	}
+	return nil
+}
+
+func readLength(src []byte, i *int, n int) (int, bool) {
+	if n != 15 {
+		return n, true
+	}
+	for {
+		if *i >= len(src) {
+			return 0, false
+		}
+		b := src[*i]
+		*i++
+		n += int(b)
+		if b != 255 {
+			return n, true
+		}
+	}
+}
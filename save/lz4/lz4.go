@@ -0,0 +1,218 @@
+// Package lz4 implements the LZ4 block stream used by Minecraft region files since 1.20.5,
+// which is the format of LZ4BlockOutputStream in lz4-java rather than the standard LZ4 frame format.
+//
+// The stream is a sequence of blocks, each of which starts with a header:
+//
+//	magic "LZ4Block" | token | compressed length | original length | checksum
+//
+// and ends with an empty block.
+package lz4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	headerLength = len(magic) + 1 + 4 + 4 + 4
+
+	methodRaw = 0x10
+	methodLZ4 = 0x20
+
+	compressionLevelBase = 10
+	// DefaultBlockSize is the block size used by Minecraft.
+	DefaultBlockSize = 1 << 16
+
+	checksumSeed = 0x9747b28c
+)
+
+var magic = [8]byte{'L', 'Z', '4', 'B', 'l', 'o', 'c', 'k'}
+
+var ErrChecksum = errors.New("lz4: checksum mismatch")
+
+// checksum is the masked XXH32 of the block, the same as StreamingXXHash32.asChecksum().
+func checksum(data []byte) uint32 {
+	return xxhash32(data, checksumSeed) & 0xFFFFFFF
+}
+
+// Writer compresses the data written to it into blocks.
+// Close must be called to flush the last block and write the end mark.
+type Writer struct {
+	w     io.Writer
+	level byte
+	buf   []byte
+	out   []byte
+	err   error
+}
+
+// NewWriter creates a Writer with the DefaultBlockSize.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w:     w,
+		level: compressionLevel(DefaultBlockSize),
+		buf:   make([]byte, 0, DefaultBlockSize),
+	}
+}
+
+func compressionLevel(blockSize int) byte {
+	var level byte
+	for 1<<(level+compressionLevelBase) < blockSize {
+		level++
+	}
+	return level
+}
+
+func (w *Writer) Write(p []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	for len(p) > 0 {
+		m := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+m]
+		n += m
+		p = p[m:]
+		if len(w.buf) == cap(w.buf) {
+			if err := w.flushBlock(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *Writer) flushBlock() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	w.out = compressBlock(append(w.out[:0], make([]byte, headerLength)...), w.buf)
+	method, data := byte(methodLZ4), w.out[headerLength:]
+	if len(data) >= len(w.buf) {
+		// store the data directly if it's not compressible
+		method, data = methodRaw, w.buf
+		w.out = append(w.out[:headerLength], data...)
+	}
+	w.writeHeader(w.out, method, len(data), len(w.buf), checksum(w.buf))
+	w.buf = w.buf[:0]
+	_, w.err = w.w.Write(w.out)
+	return w.err
+}
+
+func (w *Writer) writeHeader(header []byte, method byte, compressedLen, originalLen int, checksum uint32) {
+	copy(header, magic[:])
+	header[len(magic)] = method | w.level
+	binary.LittleEndian.PutUint32(header[len(magic)+1:], uint32(compressedLen))
+	binary.LittleEndian.PutUint32(header[len(magic)+5:], uint32(originalLen))
+	binary.LittleEndian.PutUint32(header[len(magic)+9:], checksum)
+}
+
+// Close flushes the buffered data and writes the end mark.
+// It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.flushBlock(); err != nil {
+		return err
+	}
+	var end [headerLength]byte
+	w.writeHeader(end[:], methodRaw, 0, 0, 0)
+	if _, err := w.w.Write(end[:]); err != nil {
+		w.err = err
+		return err
+	}
+	w.err = errors.New("lz4: write to closed writer")
+	return nil
+}
+
+// Reader decompresses the blocks read from the underlying reader.
+type Reader struct {
+	r    io.Reader
+	buf  []byte
+	data []byte
+	rest []byte // the decompressed data not yet read
+	eof  bool
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.rest) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		if err := r.readBlock(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.rest)
+	r.rest = r.rest[n:]
+	return n, nil
+}
+
+func (r *Reader) readBlock() error {
+	var header [headerLength]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if !bytes.Equal(header[:len(magic)], magic[:]) {
+		return errors.New("lz4: invalid magic")
+	}
+	token := header[len(magic)]
+	method, level := token&0xF0, token&0x0F
+	compressedLen := int(int32(binary.LittleEndian.Uint32(header[len(magic)+1:])))
+	originalLen := int(int32(binary.LittleEndian.Uint32(header[len(magic)+5:])))
+	sum := binary.LittleEndian.Uint32(header[len(magic)+9:])
+
+	if originalLen > 1<<(compressionLevelBase+level) || originalLen < 0 || compressedLen < 0 ||
+		originalLen == 0 && compressedLen != 0 ||
+		originalLen != 0 && compressedLen == 0 ||
+		method == methodRaw && originalLen != compressedLen {
+		return errCorrupted
+	}
+	if originalLen == 0 {
+		if sum != 0 {
+			return errCorrupted
+		}
+		r.eof = true
+		return nil
+	}
+
+	r.buf = grow(r.buf, compressedLen)
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	switch method {
+	case methodRaw:
+		r.data = append(r.data[:0], r.buf...)
+	case methodLZ4:
+		r.data = grow(r.data, originalLen)
+		if err := decompressBlock(r.data, r.buf); err != nil {
+			return err
+		}
+	default:
+		return errors.New("lz4: unknown compression method")
+	}
+	if checksum(r.data) != sum {
+		return ErrChecksum
+	}
+	r.rest = r.data
+	return nil
+}
+
+// grow returns a slice of length n, reusing b if possible.
+func grow(b []byte, n int) []byte {
+	if cap(b) < n {
+		return make([]byte, n)
+	}
+	return b[:n]
+}
@@ -0,0 +1,73 @@
+package lz4
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestXXHash32(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		seed uint32
+		want uint32
+	}{
+		{"", 0, 0x02CC5D05},
+		{"a", 0, 0x550D7456},
+		{"abc", 0, 0x32D153FF},
+		{"Nobody inspects the spammish repetition", 0, 0xE2293B2F},
+	} {
+		if got := xxhash32([]byte(test.data), test.seed); got != test.want {
+			t.Errorf("xxhash32(%q, %d) = %08x, want %08x", test.data, test.seed, got, test.want)
+		}
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	random := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(random)
+	repeated := bytes.Repeat([]byte("minecraft:stone minecraft:dirt "), 10000)
+
+	for _, data := range [][]byte{
+		nil,
+		[]byte("hello"),
+		repeated,
+		random,
+		append(repeated[:DefaultBlockSize-3:DefaultBlockSize-3], random...),
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if len(data) == len(repeated) && buf.Len() > len(data)/10 {
+			t.Errorf("data not compressed: %d -> %d", len(data), buf.Len())
+		}
+
+		got, err := io.ReadAll(NewReader(&buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("round trip of %d bytes mismatch, got %d bytes", len(data), len(got))
+		}
+	}
+}
+
+func TestReader_checksum(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, _ = w.Write([]byte("minecraft:overworld"))
+	_ = w.Close()
+
+	data := buf.Bytes()
+	data[headerLength] ^= 1 // the first byte of the raw block
+	if _, err := io.ReadAll(NewReader(bytes.NewReader(data))); !errors.Is(err, ErrChecksum) {
+		t.Errorf("read corrupted data: got %v, want ErrChecksum", err)
+	}
+}
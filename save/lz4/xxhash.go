@@ -0,0 +1,57 @@
+package lz4
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	prime1 uint32 = 2654435761
+	prime2 uint32 = 2246822519
+	prime3 uint32 = 3266489917
+	prime4 uint32 = 668265263
+	prime5 uint32 = 374761393
+)
+
+// xxhash32 returns the XXH32 hash of data, which is the checksum of the blocks.
+func xxhash32(data []byte, seed uint32) uint32 {
+	n := len(data)
+	var h uint32
+	if len(data) >= 16 {
+		v1 := seed + prime1 + prime2
+		v2 := seed + prime2
+		v3 := seed
+		v4 := seed - prime1
+		for ; len(data) >= 16; data = data[16:] {
+			v1 = xxhashRound(v1, binary.LittleEndian.Uint32(data[0:]))
+			v2 = xxhashRound(v2, binary.LittleEndian.Uint32(data[4:]))
+			v3 = xxhashRound(v3, binary.LittleEndian.Uint32(data[8:]))
+			v4 = xxhashRound(v4, binary.LittleEndian.Uint32(data[12:]))
+		}
+		h = bits.RotateLeft32(v1, 1) + bits.RotateLeft32(v2, 7) + bits.RotateLeft32(v3, 12) + bits.RotateLeft32(v4, 18)
+	} else {
+		h = seed + prime5
+	}
+	h += uint32(n)
+
+	for ; len(data) >= 4; data = data[4:] {
+		h += binary.LittleEndian.Uint32(data) * prime3
+		h = bits.RotateLeft32(h, 17) * prime4
+	}
+	for _, b := range data {
+		h += uint32(b) * prime5
+		h = bits.RotateLeft32(h, 11) * prime1
+	}
+
+	h ^= h >> 15
+	h *= prime2
+	h ^= h >> 13
+	h *= prime3
+	h ^= h >> 16
+	return h
+}
+
+func xxhashRound(acc, input uint32) uint32 {
+	acc += input * prime2
+	return bits.RotateLeft32(acc, 13) * prime1
+}
@@ -0,0 +1,45 @@
+package save
+
+import (
+	"io"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// MapData is the content of the data/map_<id>.dat file.
+type MapData struct {
+	Data        MapDataContent `nbt:"data"`
+	DataVersion int32
+}
+
+type MapDataContent struct {
+	Scale             byte   `nbt:"scale"`
+	Dimension         string `nbt:"dimension"`
+	TrackingPosition  bool   `nbt:"trackingPosition"`
+	UnlimitedTracking bool   `nbt:"unlimitedTracking"`
+	Locked            bool   `nbt:"locked"`
+	XCenter           int32  `nbt:"xCenter"`
+	ZCenter           int32  `nbt:"zCenter"`
+	Banners           []MapBanner
+	Frames            []MapFrame
+	// Colors are the 128×128 colors of the map, indexed by x + z*128.
+	// See the level/mapcolor package for the palette.
+	Colors []byte `nbt:"colors"`
+}
+
+type MapBanner struct {
+	Color string
+	Name  string `nbt:",omitempty"` // JSON text component
+	Pos   struct{ X, Y, Z int32 }
+}
+
+type MapFrame struct {
+	EntityID int32 `nbt:"EntityId"`
+	Pos      struct{ X, Y, Z int32 }
+	Rotation int32
+}
+
+func ReadMapData(r io.Reader) (data MapData, err error) {
+	_, err = nbt.NewDecoder(r).Decode(&data)
+	return
+}
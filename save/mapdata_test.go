@@ -0,0 +1,34 @@
+package save
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+func TestMapData(t *testing.T) {
+	var want MapData
+	want.DataVersion = DataVersion
+	want.Data.Scale = 2
+	want.Data.Dimension = "minecraft:overworld"
+	want.Data.XCenter, want.Data.ZCenter = 64, -64
+	want.Data.Banners = []MapBanner{{Color: "red", Name: `"Home"`}}
+	want.Data.Frames = []MapFrame{}
+	want.Data.Colors = make([]byte, 128*128)
+	want.Data.Colors[129] = 42
+
+	var buf bytes.Buffer
+	if err := nbt.NewEncoder(&buf).Encode(want, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadMapData(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Data.Scale != 2 || data.Data.XCenter != 64 || data.Data.ZCenter != -64 ||
+		len(data.Data.Banners) != 1 || data.Data.Banners[0].Name != `"Home"` ||
+		!bytes.Equal(data.Data.Colors, want.Data.Colors) {
+		t.Errorf("map data round trip: got %+v", data.Data)
+	}
+}
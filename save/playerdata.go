@@ -6,10 +6,12 @@ import (
 	"github.com/Tnze/go-mc/nbt"
 )
 
+// PlayerData is the content of playerdata/<uuid>.dat, in the format of the DataVersion.
+// The tags unknown to go-mc are kept in the Unknown fields, so that they're written back by WritePlayerData.
 type PlayerData struct {
 	DataVersion int32
 
-	Dimension    string
+	Dimension    string `nbt:",omitempty"` // Since 1.16
 	Pos          [3]float64
 	Motion       [3]float64
 	Rotation     [2]float32
@@ -17,7 +19,7 @@ type PlayerData struct {
 	FallFlying   byte
 	OnGround     byte
 
-	UUID [4]int32
+	UUID [4]int32 `nbt:",omitzero"` // Since 1.16
 
 	PlayerGameType  int32 `nbt:"playerGameType"`
 	Air             int16
@@ -46,10 +48,7 @@ type PlayerData struct {
 	FoodSaturationLevel float32 `nbt:"foodSaturationLevel"`
 	FoodTickTimer       int32   `nbt:"foodTickTimer"`
 
-	Attributes []struct {
-		Base float64
-		Name string
-	}
+	Attributes []Attribute `nbt:"attributes,omitempty"` // Since 1.20.5, the older "Attributes" are kept in Unknown
 
 	Abilities struct {
 		FlySpeed     float32 `nbt:"flySpeed"`
@@ -59,6 +58,8 @@ type PlayerData struct {
 		Invulnerable byte    `nbt:"invulnerable"`
 		MayBuild     byte    `nbt:"mayBuild"`
 		MayFly       byte    `nbt:"mayfly"`
+
+		Unknown map[string]nbt.RawMessage `nbt:",rest"`
 	} `nbt:"abilities"`
 
 	RecipeBook struct {
@@ -66,17 +67,47 @@ type PlayerData struct {
 		IsFurnaceFilteringCraftable byte `nbt:"isFurnaceFilteringCraftable"`
 		IsFurnaceGUIOpen            byte `nbt:"isFurnaceGuiOpen"`
 		IsGUIOpen                   byte `nbt:"isGuiOpen"`
+
+		Unknown map[string]nbt.RawMessage `nbt:",rest"`
 	} `nbt:"recipeBook"`
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+// Attribute is the base value and the modifiers of an attribute of the entity.
+type Attribute struct {
+	ID        string              `nbt:"id"`
+	Base      float64             `nbt:"base"`
+	Modifiers []AttributeModifier `nbt:"modifiers,omitempty"`
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
 }
 
+type AttributeModifier struct {
+	ID        string  `nbt:"id"`
+	Amount    float64 `nbt:"amount"`
+	Operation string  `nbt:"operation"` // "add_value", "add_multiplied_base" or "add_multiplied_total"
+}
+
+// Item is an item stack in the inventory.
+// Since 1.20.5, the data of the item is stored in the Components.
+// The "Count" and "tag" of the older items are kept in Unknown.
 type Item struct {
-	Count byte
-	Slot  byte
-	ID    string         `nbt:"id"`
-	Tag   map[string]any `nbt:"tag"`
+	ID         string                    `nbt:"id"`
+	Count      int32                     `nbt:"count,omitempty"`
+	Slot       byte                      `nbt:"Slot"`
+	Components map[string]nbt.RawMessage `nbt:"components,omitempty"`
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
 }
 
+// ReadPlayerData reads the uncompressed player data from r.
 func ReadPlayerData(r io.Reader) (data PlayerData, err error) {
 	_, err = nbt.NewDecoder(r).Decode(&data)
 	return
 }
+
+// WritePlayerData writes the player data into w, which is gzip-compressed as vanilla does.
+func WritePlayerData(w io.Writer, data PlayerData) error {
+	return writeGzip(w, data)
+}
@@ -1,9 +1,13 @@
 package save
 
 import (
+	"bytes"
 	"compress/gzip"
 	"os"
+	"reflect"
 	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
 )
 
 func TestPlayerData(t *testing.T) {
@@ -33,3 +37,122 @@ func TestPlayerData(t *testing.T) {
 	//	t.Errorf("player data parse error: get %v, want %v", data, want)
 	//}
 }
+
+func TestWritePlayerData(t *testing.T) {
+	// A player in the format of 1.21, with some tags unknown to PlayerData
+	player := map[string]any{
+		"DataVersion": int32(DataVersion),
+		"Dimension":   "minecraft:overworld",
+		"Pos":         []float64{1.5, 64, -2.5},
+		"Health":      float32(20),
+		"attributes": []map[string]any{{
+			"id":   "minecraft:generic.movement_speed",
+			"base": 0.1,
+			"modifiers": []map[string]any{{
+				"id": "minecraft:sprinting", "amount": 0.3, "operation": "add_multiplied_total",
+			}},
+		}},
+		"Inventory": []map[string]any{{
+			"id": "minecraft:diamond_sword", "count": int32(1), "Slot": byte(0),
+			"components": map[string]any{"minecraft:damage": int32(10)},
+		}},
+		"warden_spawn_tracker": map[string]any{"warning_level": int32(1)},
+		"recipeBook":           map[string]any{"recipes": []string{"minecraft:torch"}, "isGuiOpen": byte(1)},
+	}
+	var buf bytes.Buffer
+	if err := nbt.NewEncoder(&buf).Encode(player, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadPlayerData(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Inventory) != 1 || data.Inventory[0].Count != 1 || data.Attributes[0].Modifiers[0].Amount != 0.3 {
+		t.Errorf("player data parse error: %+v", data)
+	}
+	data.Inventory[0].Count = 2
+
+	buf.Reset()
+	if err := WritePlayerData(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	got := readGzipNBT(t, buf.Bytes())
+	if v := got["warden_spawn_tracker"]; !reflect.DeepEqual(v, map[string]any{"warning_level": int32(1)}) {
+		t.Errorf("unknown tag not preserved: %v", v)
+	}
+	if v := got["recipeBook"].(map[string]any)["recipes"]; !reflect.DeepEqual(v, []any{"minecraft:torch"}) {
+		t.Errorf("unknown tag of the recipe book not preserved: %v", v)
+	}
+	item := got["Inventory"].([]any)[0].(map[string]any)
+	if item["count"] != int32(2) || !reflect.DeepEqual(item["components"], map[string]any{"minecraft:damage": int32(10)}) {
+		t.Errorf("item mismatch: %v", item)
+	}
+}
+
+func TestWritePlayerData_legacy(t *testing.T) {
+	// A player in the format of 1.19.2, before the items and attributes are renamed in 1.20.5,
+	// and without the Dimension and UUID which are added in 1.16
+	player := map[string]any{
+		"DataVersion": int32(3120),
+		"Pos":         []float64{1.5, 64, -2.5},
+		"Attributes": []map[string]any{{
+			"Name": "minecraft:generic.movement_speed",
+			"Base": 0.1,
+		}},
+		"Inventory": []map[string]any{{
+			"id": "minecraft:diamond_sword", "Count": byte(5), "Slot": byte(0),
+			"tag": map[string]any{"Damage": int32(10)},
+		}},
+	}
+	var buf bytes.Buffer
+	if err := nbt.NewEncoder(&buf).Encode(player, ""); err != nil {
+		t.Fatal(err)
+	}
+	var want map[string]any
+	if err := nbt.Unmarshal(buf.Bytes(), &want); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadPlayerData(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if err := WritePlayerData(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	got := readGzipNBT(t, buf.Bytes())
+	for _, k := range []string{"Dimension", "UUID", "attributes"} {
+		if v, ok := got[k]; ok {
+			t.Errorf("absent tag %s is written: %v", k, v)
+		}
+	}
+	for _, k := range []string{"Attributes", "Inventory"} {
+		if !reflect.DeepEqual(got[k], want[k]) {
+			t.Errorf("legacy %s mismatch: got %v, want %v", k, got[k], want[k])
+		}
+	}
+}
+
+func TestWritePlayerData_file(t *testing.T) {
+	raw, err := os.ReadFile("testdata/playerdata/58f6356e-b30c-4811-8bfc-d72a9ee99e73.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadPlayerData(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePlayerData(&buf, data); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := readGzipNBT(t, raw), readGzipNBT(t, buf.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Errorf("player data round trip mismatch:\ngot  %v\nwant %v", got, want)
+	}
+}
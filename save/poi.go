@@ -0,0 +1,86 @@
+package save
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/Tnze/go-mc/save/region"
+)
+
+// POIChunk is a chunk in the poi/*.mca region files, which stores the points of interest,
+// such as the villager workstations, beds and nether portals.
+type POIChunk struct {
+	DataVersion int32
+	Sections    map[string]POISection // By the section Y, like "-4" and "0"
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+type POISection struct {
+	Valid   bool
+	Records []POIRecord
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+// POIRecord is a point of interest.
+type POIRecord struct {
+	Type        string   `nbt:"type"` // An ID of the minecraft:point_of_interest_type registry, like "minecraft:armorer"
+	Pos         [3]int32 `nbt:"pos"`
+	FreeTickets int32    `nbt:"free_tickets"` // The number of the villagers can still claim the point
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+// Load read POI chunk data from []byte.
+// The first byte is the compression type, which is one of the Compression constants.
+func (c *POIChunk) Load(data []byte) error {
+	return loadNBT(data, c)
+}
+
+// Data encodes the POI chunk and compresses it with the compressingType.
+func (c *POIChunk) Data(compressingType byte) ([]byte, error) {
+	return dataNBT(c, compressingType, "")
+}
+
+// Section returns a copy of the section at the section Y, and reports whether it exists.
+// Modifications of the section should be saved by SetSection.
+func (c *POIChunk) Section(y int) (POISection, bool) {
+	s, ok := c.Sections[strconv.Itoa(y)]
+	return s, ok
+}
+
+// SetSection sets the section at the section Y.
+func (c *POIChunk) SetSection(y int, s POISection) {
+	if c.Sections == nil {
+		c.Sections = make(map[string]POISection)
+	}
+	c.Sections[strconv.Itoa(y)] = s
+}
+
+// Records returns the records in all valid sections, from the bottom to the top.
+func (c *POIChunk) Records() []POIRecord {
+	ys := make([]int, 0, len(c.Sections))
+	for k, s := range c.Sections {
+		if y, err := strconv.Atoi(k); err == nil && s.Valid {
+			ys = append(ys, y)
+		}
+	}
+	sort.Ints(ys)
+
+	var records []POIRecord
+	for _, y := range ys {
+		records = append(records, c.Sections[strconv.Itoa(y)].Records...)
+	}
+	return records
+}
+
+// CheckPOIChunk returns a region.CheckFunc for the poi regions,
+// which checks if the chunks can be decompressed and decoded.
+func CheckPOIChunk() region.CheckFunc {
+	return func(x, z int, data []byte) error {
+		var c POIChunk
+		return c.Load(data)
+	}
+}
@@ -0,0 +1,53 @@
+package save
+
+import (
+	"testing"
+)
+
+func TestPOIChunk(t *testing.T) {
+	var count int
+	forEachChunk(t, "testdata/poi/r.*.*.mca", func(rx, rz, x, z int, data []byte) {
+		var c POIChunk
+		if err := c.Load(data); err != nil {
+			t.Fatal(err)
+		}
+		cx, cz := rx*32+x, rz*32+z
+		for _, r := range c.Records() {
+			if r.Type == "" {
+				t.Errorf("record without type in chunk (%d, %d)", cx, cz)
+			}
+			if int(r.Pos[0])>>4 != cx || int(r.Pos[2])>>4 != cz {
+				t.Errorf("record %s at %v is stored in chunk (%d, %d)", r.Type, r.Pos, cx, cz)
+			}
+			count++
+		}
+		assertLossless(t, data, &c)
+	})
+	if count == 0 {
+		t.Error("no point of interest found")
+	}
+}
+
+func TestPOIChunk_SetSection(t *testing.T) {
+	var c POIChunk
+	if _, ok := c.Section(-1); ok {
+		t.Error("section of an empty chunk should not exist")
+	}
+	c.SetSection(2, POISection{Valid: true, Records: []POIRecord{{Type: "minecraft:home", Pos: [3]int32{1, 40, 2}}}})
+	c.SetSection(-1, POISection{Valid: true, Records: []POIRecord{{Type: "minecraft:nether_portal", Pos: [3]int32{1, -10, 2}}}})
+	c.SetSection(0, POISection{Valid: false, Records: []POIRecord{{Type: "minecraft:armorer", Pos: [3]int32{1, 5, 2}}}})
+	if s, ok := c.Section(2); !ok || len(s.Records) != 1 {
+		t.Errorf("section 2: %v", s)
+	}
+	records := c.Records()
+	if len(records) != 2 || records[0].Type != "minecraft:nether_portal" || records[1].Type != "minecraft:home" {
+		t.Errorf("records: %v", records)
+	}
+
+	s, _ := c.Section(0)
+	s.Valid = true
+	c.SetSection(0, s)
+	if records := c.Records(); len(records) != 3 || records[1].Type != "minecraft:armorer" {
+		t.Errorf("records after section 0 updated: %v", records)
+	}
+}
@@ -0,0 +1,334 @@
+package region
+
+import (
+	"container/list"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// ErrClosed is returned when using a closed Cache.
+var ErrClosed = errors.New("region cache closed")
+
+// Cache reads and writes the chunks in the region files of a folder, like "world/region".
+// It's safe for concurrent use.
+//
+// Up to size region files are kept open, the least recently used ones are closed when more are needed.
+// Chunks in the same region can be read concurrently, while writes to a region are serialized.
+//
+// The chunks can also be written asynchronously by WriteChunkAsync,
+// which are queued and written by a background goroutine.
+// The queued chunks are visible to ReadChunk before written,
+// and are written to the disk by Flush or Close.
+type Cache struct {
+	dir  string
+	size int
+
+	lock    sync.Mutex
+	list    *list.List // of *cacheEntry, the front is the most recently used
+	index   map[[2]int]*list.Element
+	closed  bool
+	closing sync.WaitGroup // the running operations
+
+	queueLock sync.Mutex
+	queueCond *sync.Cond
+	queue     map[[2]int]queuedChunk
+	version   uint64
+	running   bool  // the background goroutine is running
+	queueErr  error // the first error of the background writes since the last Flush
+}
+
+type cacheEntry struct {
+	pos  [2]int
+	refs int // the number of the operations using the region, which prevents it from being closed
+
+	lock sync.RWMutex // the write lock is held when opening or writing the region
+	r    *Region      // nil if the file doesn't exist, or not opened yet
+	done bool         // r is opened or confirmed nonexistent
+}
+
+type queuedChunk struct {
+	data    []byte
+	version uint64
+}
+
+// NewCache creates a Cache of the region files in dir, which keeps up to size files open.
+// The folder is created when writing the first chunk if it doesn't exist.
+func NewCache(dir string, size int) *Cache {
+	c := &Cache{
+		dir:   dir,
+		size:  max(size, 1),
+		list:  list.New(),
+		index: make(map[[2]int]*list.Element),
+		queue: make(map[[2]int]queuedChunk),
+	}
+	c.queueCond = sync.NewCond(&c.queueLock)
+	return c
+}
+
+// ReadChunk reads the data of the chunk (cx, cz), the first byte of which is the compression type.
+// ErrNoSector is returned if the chunk doesn't exist.
+func (c *Cache) ReadChunk(cx, cz int) ([]byte, error) {
+	c.queueLock.Lock()
+	if q, ok := c.queue[[2]int{cx, cz}]; ok {
+		c.queueLock.Unlock()
+		return append([]byte(nil), q.data...), nil
+	}
+	c.queueLock.Unlock()
+
+	rx, rz := At(cx, cz)
+	e, err := c.acquire(rx, rz, false)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(e)
+	if err := c.open(e, false); err != nil {
+		return nil, err
+	}
+
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	x, z := In(cx, cz)
+	if e.r == nil || !e.r.ExistSector(x, z) {
+		return nil, ErrNoSector
+	}
+	return e.r.ReadSector(x, z)
+}
+
+// ExistChunk returns if the chunk (cx, cz) exists.
+func (c *Cache) ExistChunk(cx, cz int) (bool, error) {
+	c.queueLock.Lock()
+	_, ok := c.queue[[2]int{cx, cz}]
+	c.queueLock.Unlock()
+	if ok {
+		return true, nil
+	}
+
+	rx, rz := At(cx, cz)
+	e, err := c.acquire(rx, rz, false)
+	if err != nil {
+		return false, err
+	}
+	defer c.release(e)
+	if err := c.open(e, false); err != nil {
+		return false, err
+	}
+
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	x, z := In(cx, cz)
+	return e.r != nil && e.r.ExistSector(x, z), nil
+}
+
+// WriteChunk writes the data of the chunk (cx, cz) synchronously,
+// the first byte of which is the compression type.
+// The region file is created if it doesn't exist.
+// The chunk queued by WriteChunkAsync before is replaced, and won't be written.
+func (c *Cache) WriteChunk(cx, cz int, data []byte) error {
+	return c.writeChunk(cx, cz, data, 0)
+}
+
+// writeChunk writes the chunk synchronously if version is 0,
+// otherwise it's the queued chunk of the version, which is allowed after the Cache is closing.
+// The queued chunk is skipped if it's replaced by a later write.
+func (c *Cache) writeChunk(cx, cz int, data []byte, version uint64) error {
+	rx, rz := At(cx, cz)
+	e, err := c.acquire(rx, rz, version != 0)
+	if err != nil {
+		return err
+	}
+	defer c.release(e)
+	if err := c.open(e, true); err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	// The queue is checked with the region locked, so that the writes of a chunk are in order
+	pos := [2]int{cx, cz}
+	c.queueLock.Lock()
+	if version == 0 {
+		delete(c.queue, pos)
+	} else if c.queue[pos].version != version {
+		c.queueLock.Unlock()
+		return nil
+	}
+	c.queueLock.Unlock()
+
+	x, z := In(cx, cz)
+	return e.r.WriteSector(x, z, data)
+}
+
+// WriteChunkAsync queues the data of the chunk (cx, cz) to be written in background.
+// A later write of the same chunk replaces the queued one.
+// The errors of the background writes are returned by Flush and Close.
+// The data must not be modified after passed in.
+func (c *Cache) WriteChunkAsync(cx, cz int, data []byte) error {
+	// The lock is held until queued, so that the chunk is flushed by Close if it's closing
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+
+	c.queueLock.Lock()
+	defer c.queueLock.Unlock()
+	c.version++
+	c.queue[[2]int{cx, cz}] = queuedChunk{data: data, version: c.version}
+	if !c.running {
+		c.running = true
+		go c.writeQueued()
+	}
+	c.queueCond.Broadcast()
+	return nil
+}
+
+// writeQueued writes the queued chunks until the queue is empty.
+func (c *Cache) writeQueued() {
+	c.queueLock.Lock()
+	defer c.queueLock.Unlock()
+	for len(c.queue) > 0 {
+		var pos [2]int
+		var q queuedChunk
+		for pos, q = range c.queue {
+			break
+		}
+
+		// The chunk is kept in the queue while writing, so that it's still visible to ReadChunk
+		c.queueLock.Unlock()
+		err := c.writeChunk(pos[0], pos[1], q.data, q.version)
+		c.queueLock.Lock()
+
+		if err != nil && c.queueErr == nil {
+			c.queueErr = err
+		}
+		// The chunk might be queued again while writing
+		if c.queue[pos].version == q.version {
+			delete(c.queue, pos)
+		}
+	}
+	c.running = false
+	c.queueCond.Broadcast()
+}
+
+// Flush waits for all queued chunks to be written,
+// and returns the first error of the background writes since the last Flush.
+func (c *Cache) Flush() error {
+	c.queueLock.Lock()
+	defer c.queueLock.Unlock()
+	for c.running {
+		c.queueCond.Wait()
+	}
+	err := c.queueErr
+	c.queueErr = nil
+	return err
+}
+
+// Close flushes the queued chunks, and closes all region files.
+func (c *Cache) Close() error {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return ErrClosed
+	}
+	c.closed = true
+	c.lock.Unlock()
+
+	errs := []error{c.Flush()}
+	// wait for the running operations, no new ones can start now
+	c.closing.Wait()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for c.list.Len() > 0 {
+		e := c.list.Remove(c.list.Front()).(*cacheEntry)
+		delete(c.index, e.pos)
+		if e.r != nil {
+			errs = append(errs, e.r.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// acquire returns the entry of the region (rx, rz), which is kept open until released.
+// The queued chunks are flushed when closing, which sets flushing to true.
+func (c *Cache) acquire(rx, rz int, flushing bool) (*cacheEntry, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed && !flushing {
+		return nil, ErrClosed
+	}
+	c.closing.Add(1)
+
+	pos := [2]int{rx, rz}
+	if elem, ok := c.index[pos]; ok {
+		c.list.MoveToFront(elem)
+		e := elem.Value.(*cacheEntry)
+		e.refs++
+		return e, nil
+	}
+	e := &cacheEntry{pos: pos, refs: 1}
+	c.index[pos] = c.list.PushFront(e)
+	c.evict()
+	return e, nil
+}
+
+func (c *Cache) release(e *cacheEntry) {
+	c.lock.Lock()
+	e.refs--
+	c.evict()
+	c.lock.Unlock()
+	c.closing.Done()
+}
+
+// evict closes the least recently used regions which aren't in use, until there are at most size regions.
+// The lock must be held.
+func (c *Cache) evict() {
+	for elem := c.list.Back(); elem != nil && c.list.Len() > c.size; {
+		prev := elem.Prev()
+		if e := elem.Value.(*cacheEntry); e.refs == 0 {
+			c.list.Remove(elem)
+			delete(c.index, e.pos)
+			if e.r != nil {
+				_ = e.r.Close()
+			}
+		}
+		elem = prev
+	}
+}
+
+// open opens the region file of the entry if it isn't opened.
+// The file is created if it doesn't exist and create is true.
+func (c *Cache) open(e *cacheEntry, create bool) error {
+	e.lock.RLock()
+	ok := e.done && (e.r != nil || !create)
+	e.lock.RUnlock()
+	if ok {
+		return nil
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.done && (e.r != nil || !create) {
+		return nil // opened by someone else at the same time
+	}
+	name := filepath.Join(c.dir, "r."+strconv.Itoa(e.pos[0])+"."+strconv.Itoa(e.pos[1])+".mca")
+	r, err := Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		r, err = nil, nil
+		if create {
+			if err := os.MkdirAll(c.dir, 0o755); err != nil {
+				return err
+			}
+			r, err = Create(name)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	e.r, e.done = r, true
+	return nil
+}
@@ -0,0 +1,124 @@
+package region
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	c := NewCache(dir, 2)
+
+	// write chunks in 4 regions concurrently, more than the cache size
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cx, cz := i%2*32-i, i/4*32+i
+			for j := 0; j < 10; j++ {
+				if err := c.WriteChunk(cx, cz, testChunk(i+j, 100*j+10)); err != nil {
+					t.Error(err)
+				}
+				if _, err := c.ReadChunk(cx, cz); err != nil {
+					t.Error(err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	c.lock.Lock()
+	if n := c.list.Len(); n > 2 {
+		t.Errorf("%d regions are kept open", n)
+	}
+	c.lock.Unlock()
+
+	// read the chunks concurrently
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cx, cz := i%2*32-i, i/4*32+i
+			data, err := c.ReadChunk(cx, cz)
+			if err != nil {
+				t.Error(err)
+			} else if !bytes.Equal(data, testChunk(i+9, 910)) {
+				t.Errorf("chunk (%d, %d) mismatch", cx, cz)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, err := c.ReadChunk(100, 100); !errors.Is(err, ErrNoSector) {
+		t.Errorf("read missing chunk: got %v, want ErrNoSector", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadChunk(0, 0); !errors.Is(err, ErrClosed) {
+		t.Errorf("read closed cache: got %v, want ErrClosed", err)
+	}
+}
+
+func TestCache_WriteChunkAsync(t *testing.T) {
+	dir := t.TempDir()
+	c := NewCache(dir, 1)
+	for i := 0; i < 100; i++ {
+		if err := c.WriteChunkAsync(i, -i, testChunk(i, 1000)); err != nil {
+			t.Fatal(err)
+		}
+		// the queued chunk is visible immediately
+		if data, err := c.ReadChunk(i, -i); err != nil || !bytes.Equal(data, testChunk(i, 1000)) {
+			t.Fatalf("read queued chunk %d: %v", i, err)
+		}
+	}
+	if err := c.WriteChunkAsync(0, 0, testChunk(1, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteChunkAsync(0, 0, testChunk(1, 10)); !errors.Is(err, ErrClosed) {
+		t.Errorf("write closed cache: got %v, want ErrClosed", err)
+	}
+
+	// all chunks are written when closed
+	c = NewCache(dir, 4)
+	defer c.Close()
+	for i := 1; i < 100; i++ {
+		if data, err := c.ReadChunk(i, -i); err != nil || !bytes.Equal(data, testChunk(i, 1000)) {
+			t.Errorf("read chunk %d: %v", i, err)
+		}
+	}
+	if data, err := c.ReadChunk(0, 0); err != nil || !bytes.Equal(data, testChunk(1, 10)) {
+		t.Errorf("the last write of chunk 0 is lost: %v", err)
+	}
+}
+
+func TestCache_WriteChunkAfterAsync(t *testing.T) {
+	c := NewCache(t.TempDir(), 4)
+	defer c.Close()
+	for i := 0; i < 100; i++ {
+		if err := c.WriteChunkAsync(i, 0, testChunk(i, 1000)); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.WriteChunk(i, 0, testChunk(i+1, 10)); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := c.ReadChunk(i, 0); err != nil || !bytes.Equal(data, testChunk(i+1, 10)) {
+			t.Fatalf("read chunk %d after the synchronous write: %v", i, err)
+		}
+	}
+	if err := c.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// the queued chunks don't overwrite the later synchronous writes
+	for i := 0; i < 100; i++ {
+		if data, err := c.ReadChunk(i, 0); err != nil || !bytes.Equal(data, testChunk(i+1, 10)) {
+			t.Errorf("read chunk %d after flushed: %v", i, err)
+		}
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,7 +20,9 @@ var (
 )
 
 // Region contain 32*32 chunks in one .mca file
-// Not MT-Safe!
+// Not MT-Safe! Except that ReadSector can be called concurrently
+// if the underlying file implements io.ReaderAt, like *os.File,
+// as long as no other method is called at the same time. See Cache for a concurrent-safe one.
 type Region struct {
 	f          io.ReadWriteSeeker
 	offsets    [32][32]int32
@@ -25,8 +31,19 @@ type Region struct {
 	// sectors record if a sector is in used.
 	// contrary to mojang's, because false is the default value in Go.
 	sectors map[int32]bool
+
+	// The file name of the region, and its folder and coordinates, which are used to locate the external chunks.
+	// Only available for the Region opened by Open or Create.
+	name     string
+	dir      string
+	rx, rz   int
+	external bool
 }
 
+// externalFlag is set on the compression type byte if the chunk is stored in a separate .mcc file,
+// because it's too large to fit in the region file.
+const externalFlag = 0x80
+
 // In calculate chunk's coordinates relative to region
 // 计算chunk在region中的相对坐标。即，除以32并取余。
 func In(cx, cz int) (int, int) {
@@ -52,7 +69,9 @@ func Open(name string) (r *Region, err error) {
 	r, err = Load(f)
 	if err != nil {
 		_ = f.Close()
+		return
 	}
+	r.setName(name)
 	return
 }
 
@@ -97,7 +116,34 @@ func Create(name string) (*Region, error) {
 	if err != nil {
 		return nil, err
 	}
-	return CreateWriter(f)
+	r, err := CreateWriter(f)
+	if err != nil {
+		return nil, err
+	}
+	r.setName(name)
+	return r, nil
+}
+
+// setName records the location of the region from its file name "r.<x>.<z>.mca",
+// which enables reading and writing the external chunks.
+func (r *Region) setName(name string) {
+	r.name = name
+	parts := strings.Split(filepath.Base(name), ".")
+	if len(parts) != 4 || parts[0] != "r" {
+		return
+	}
+	rx, err1 := strconv.Atoi(parts[1])
+	rz, err2 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil {
+		return
+	}
+	r.dir, r.rx, r.rz, r.external = filepath.Dir(name), rx, rz, true
+}
+
+// externalPath returns the path of the .mcc file storing the chunk, which is named by its absolute coordinates.
+func (r *Region) externalPath(x, z int) string {
+	cx, cz := r.rx*32+x, r.rz*32+z
+	return filepath.Join(r.dir, "c."+strconv.Itoa(cx)+"."+strconv.Itoa(cz)+".mcc")
 }
 
 // CreateWriter create Region by an io.ReadWriteSeeker
@@ -140,17 +186,28 @@ func sectorLoc(offset int32) (sec, num int32) {
 	return (offset >> 8) & 0xFFFFFF, offset & 0xFF
 }
 
-// ReadSector find and read the Chunk data from region
+// ReadSector find and read the Chunk data from region.
+// The first byte of the data is the compression type.
+//
+// If the chunk is too large and stored in a .mcc file, the file is read instead,
+// and the external flag (0x80) of the compression type is cleared.
+// The flag is kept for the Region made by Load, which doesn't know where the file is.
 func (r *Region) ReadSector(x, z int) (data []byte, err error) {
 	sec, num := sectorLoc(r.offsets[z][x])
 	if sec == 0 {
 		return nil, ErrNoSector
 	}
-	_, err = r.f.Seek(4096*int64(sec), 0)
-	if err != nil {
-		return
+	var reader io.Reader
+	if f, ok := r.f.(io.ReaderAt); ok {
+		// positional read, which doesn't change the offset of the file
+		reader = io.NewSectionReader(f, 4096*int64(sec), 4096*int64(num))
+	} else {
+		_, err = r.f.Seek(4096*int64(sec), 0)
+		if err != nil {
+			return
+		}
+		reader = io.LimitReader(r.f, 4096*int64(num))
 	}
-	reader := io.LimitReader(r.f, 4096*int64(num))
 
 	var length int32
 	err = binary.Read(reader, binary.BigEndian, &length)
@@ -168,19 +225,41 @@ func (r *Region) ReadSector(x, z int) (data []byte, err error) {
 	}
 	data = make([]byte, length)
 	_, err = io.ReadFull(reader, data)
+	if err != nil {
+		return nil, err
+	}
 
+	if r.external && data[0]&externalFlag != 0 {
+		external, err := os.ReadFile(r.externalPath(x, z))
+		if err != nil {
+			return nil, err
+		}
+		data = append([]byte{data[0] &^ externalFlag}, external...)
+	}
 	return
 }
 
-// WriteSector write Chunk data into region file
+// WriteSector write Chunk data into region file.
+// The first byte of the data is the compression type.
+//
+// The chunk larger than 1MB is written into a .mcc file beside the region file, as vanilla does.
+// ErrTooLarge is returned for the Region made by CreateWriter or Load, which doesn't know where to put the file.
 func (r *Region) WriteSector(x, z int, data []byte) error {
 	need := int32((len(data) + 4 + 4096 - 1) / 4096)
-	n, now := sectorLoc(r.offsets[z][x])
 
 	// maximum chunk size is 1MB
-	if need >= 256 {
-		return ErrTooLarge
+	external := need >= 256
+	if external {
+		if !r.external || len(data) == 0 {
+			return ErrTooLarge
+		}
+		if err := r.writeExternal(x, z, data[1:]); err != nil {
+			return err
+		}
+		// only the compression type is stored in the region file
+		data, need = []byte{data[0] | externalFlag}, 1
 	}
+	n, now := sectorLoc(r.offsets[z][x])
 
 	if n != 0 && now == need {
 		// we can simply overwrite the old sectors
@@ -208,7 +287,7 @@ func (r *Region) WriteSector(x, z int, data []byte) error {
 		if err != nil {
 			return err
 		}
-		r.Timestamps[x][z] = int32(time.Now().Unix())
+		r.Timestamps[z][x] = int32(time.Now().Unix())
 	}
 
 	_, err := r.f.Seek(4096*int64(n), 0)
@@ -227,9 +306,38 @@ func (r *Region) WriteSector(x, z int, data []byte) error {
 		return err
 	}
 
+	if r.external && !external {
+		// the chunk might be stored externally before,
+		// which is removed only after the new data is written, so it's not lost on failure
+		err := os.Remove(r.externalPath(x, z))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
 	return nil
 }
 
+// writeExternal writes the chunk data into the .mcc file.
+// The data is written into a temporary file first, so that the old one isn't broken on failure.
+func (r *Region) writeExternal(x, z int, data []byte) error {
+	name := r.externalPath(x, z)
+	f, err := os.CreateTemp(r.dir, filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
 // ExistSector return if a sector is existed
 func (r *Region) ExistSector(x, z int) bool {
 	return r.offsets[z][x] != 0
@@ -3,9 +3,11 @@ package region
 import (
 	"bytes"
 	"compress/zlib"
+	"errors"
 	"io"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Tnze/go-mc/nbt"
@@ -168,3 +170,69 @@ func TestWriteSectors(t *testing.T) {
 		t.Fatalf("wrong file size. Got %d, Want: %d", stat.Size(), stat.Size()+(4096-stat.Size()%4096))
 	}
 }
+
+func TestWriteSectors_external(t *testing.T) {
+	dir := t.TempDir()
+	region, err := Create(filepath.Join(dir, "r.-1.2.mca"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer region.Close()
+
+	data := make([]byte, 2<<20)
+	rand.Read(data)
+	data[0] = 2
+	if err := region.WriteSector(3, 4, data); err != nil {
+		t.Fatal("write sector", err)
+	}
+	external := filepath.Join(dir, "c.-29.68.mcc")
+	if _, err := os.Stat(external); err != nil {
+		t.Fatalf("external chunk not written: %v", err)
+	}
+	if read, err := region.ReadSector(3, 4); err != nil {
+		t.Fatal("read sector", err)
+	} else if !bytes.Equal(data, read) {
+		t.Fatal("read corrupted external sector data")
+	}
+
+	// The external file is removed once the chunk fits in the region
+	if err := region.WriteSector(3, 4, data[:100]); err != nil {
+		t.Fatal("write sector", err)
+	}
+	if _, err := os.Stat(external); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("external chunk not removed: %v", err)
+	}
+	if read, err := region.ReadSector(3, 4); err != nil {
+		t.Fatal("read sector", err)
+	} else if !bytes.Equal(data[:100], read) {
+		t.Fatal("read corrupted sector data")
+	}
+
+	// The external file is kept if the chunk fails to be written into the region
+	if err := region.WriteSector(3, 4, data); err != nil {
+		t.Fatal("write sector", err)
+	}
+	if err := region.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := region.WriteSector(3, 4, data[:100]); err == nil {
+		t.Fatal("write sector into the closed region")
+	}
+	if _, err := os.Stat(external); err != nil {
+		t.Errorf("external chunk removed on failure: %v", err)
+	}
+
+	// The region without a file name can't store external chunks
+	temp, err := os.CreateTemp(dir, "region*.mca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer temp.Close()
+	writer, err := CreateWriter(temp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteSector(0, 0, data); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("write large sector: got %v, want ErrTooLarge", err)
+	}
+}
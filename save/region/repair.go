@@ -0,0 +1,326 @@
+package region
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ProblemKind is the kind of Problem found by Region.Verify.
+type ProblemKind int
+
+const (
+	// ProblemOutOfBounds means the chunk's sectors are in the file header or beyond the end of the file.
+	ProblemOutOfBounds ProblemKind = iota
+	// ProblemOverlap means the chunk's sectors are shared with another chunk.
+	ProblemOverlap
+	// ProblemLength means the declared length of the chunk data is invalid or exceeds its sectors.
+	ProblemLength
+	// ProblemData means the chunk data can't be read, or is rejected by the check function.
+	ProblemData
+)
+
+func (k ProblemKind) String() string {
+	switch k {
+	case ProblemOutOfBounds:
+		return "out of bounds"
+	case ProblemOverlap:
+		return "overlap"
+	case ProblemLength:
+		return "bad length"
+	case ProblemData:
+		return "bad data"
+	default:
+		return fmt.Sprintf("ProblemKind(%d)", int(k))
+	}
+}
+
+// Problem is an issue of a chunk in the region file.
+type Problem struct {
+	X, Z int // The coordinates of the chunk relative to the region
+	Kind ProblemKind
+	Err  error
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("chunk (%d, %d): %v: %v", p.X, p.Z, p.Kind, p.Err)
+}
+
+// CheckFunc checks the data of the chunk (x, z) returned by ReadSector,
+// for example, by decompressing and decoding it.
+// The data is considered valid if nil is returned.
+type CheckFunc func(x, z int, data []byte) error
+
+// Verify checks the structure of the region file and returns the problems found.
+// The check function is called for each chunk whose sectors are fine, and can be nil.
+func (r *Region) Verify(check CheckFunc) ([]Problem, error) {
+	size, err := r.f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	owners := make(map[int32][2]int)
+	for z := 0; z < 32; z++ {
+		for x := 0; x < 32; x++ {
+			sec, num := sectorLoc(r.offsets[z][x])
+			if r.offsets[z][x] == 0 {
+				continue
+			}
+			if sec < 2 || num == 0 || int64(sec)*4096 >= size {
+				problems = append(problems, Problem{x, z, ProblemOutOfBounds, fmt.Errorf("sectors [%d, %d) in a file of %d bytes", sec, sec+num, size)})
+				continue
+			}
+
+			overlapped := false
+			for i := sec; i < sec+num; i++ {
+				if owner, ok := owners[i]; ok && !overlapped {
+					problems = append(problems, Problem{x, z, ProblemOverlap, fmt.Errorf("sector %d is used by chunk (%d, %d)", i, owner[0], owner[1])})
+					overlapped = true
+				} else if !ok {
+					owners[i] = [2]int{x, z}
+				}
+			}
+
+			length, err := r.readLength(sec)
+			if err != nil {
+				return problems, err
+			}
+			switch {
+			case length <= 0:
+				problems = append(problems, Problem{x, z, ProblemLength, fmt.Errorf("declared length %d", length)})
+				continue
+			case int64(sec)*4096+4+int64(length) > size:
+				problems = append(problems, Problem{x, z, ProblemLength, fmt.Errorf("declared length %d exceeds the end of file", length)})
+				continue
+			case length > 4096*num-4:
+				problems = append(problems, Problem{x, z, ProblemLength, fmt.Errorf("declared length %d exceeds %d sectors", length, num)})
+				continue
+			}
+
+			data, err := r.ReadSector(x, z)
+			if err == nil && check != nil {
+				err = check(x, z, data)
+			}
+			if err != nil {
+				problems = append(problems, Problem{x, z, ProblemData, err})
+			}
+		}
+	}
+	return problems, nil
+}
+
+// Repair verifies the region like Verify, then rewrites it like Compact,
+// with the chunks can't be recovered removed.
+//
+// The chunks whose length exceeds their sectors are kept if the whole data is in the file and valid,
+// and the valid chunks sharing sectors with others are relocated.
+// The recovered chunks larger than 1MB are moved into the .mcc files like WriteSector,
+// or removed for the Region made by CreateWriter or Load.
+// The problems found before repairing are returned.
+func (r *Region) Repair(check CheckFunc) ([]Problem, error) {
+	problems, err := r.Verify(check)
+	if err != nil || len(problems) == 0 {
+		return problems, err
+	}
+	size, err := r.f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return problems, err
+	}
+
+	var chunks [32][32][]byte
+	for z := 0; z < 32; z++ {
+		for x := 0; x < 32; x++ {
+			if r.offsets[z][x] == 0 {
+				continue
+			}
+			raw, err := r.readRawLenient(x, z, size)
+			if err == nil {
+				err = r.checkRaw(x, z, raw, check)
+			}
+			if err == nil {
+				chunks[z][x] = raw
+			}
+		}
+	}
+	return problems, r.rewrite(&chunks)
+}
+
+// Compact rewrites the region file with the chunks stored in contiguous sectors,
+// which removes the free sectors left by rewriting chunks and shrinks the file.
+// An error is returned without modifying the file if any chunk can't be read, in which case use Repair instead.
+//
+// The Region opened by Open or Create is written into a new file which replaces the old one,
+// others are rewritten in place, and only truncated if it implements Truncate(size int64) error.
+func (r *Region) Compact() error {
+	var chunks [32][32][]byte
+	for z := 0; z < 32; z++ {
+		for x := 0; x < 32; x++ {
+			if r.offsets[z][x] == 0 {
+				continue
+			}
+			raw, err := r.readRaw(x, z)
+			if err != nil {
+				return Problem{x, z, ProblemData, err}
+			}
+			chunks[z][x] = raw
+		}
+	}
+	return r.rewrite(&chunks)
+}
+
+// rewrite writes the chunks one by one from the third sector, and updates the header.
+// The timestamps of the removed chunks are cleared.
+//
+// The chunks which need more than 255 sectors are moved into the .mcc files,
+// or removed if the region doesn't know where to put them.
+// The region opened by Open or Create is written into a temporary file, which replaces the old one after synced,
+// so the file isn't broken if it fails halfway. Others are rewritten in place.
+func (r *Region) rewrite(chunks *[32][32][]byte) error {
+	offsets := r.offsets
+	timestamps := r.Timestamps
+	buf := make([]byte, 8192, 8192+len(chunks)*4096)
+	for z := 0; z < 32; z++ {
+		for x := 0; x < 32; x++ {
+			data := chunks[z][x]
+			need := int32((len(data) + 4 + 4096 - 1) / 4096)
+			if data != nil && need >= 256 {
+				if r.external {
+					if err := r.writeExternal(x, z, data[1:]); err != nil {
+						return err
+					}
+					data, need = []byte{data[0] | externalFlag}, 1
+				} else {
+					data = nil
+				}
+			}
+			if data == nil {
+				offsets[z][x] = 0
+				timestamps[z][x] = 0
+				continue
+			}
+			offsets[z][x] = int32(len(buf)/4096)<<8 | need
+			buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+			buf = append(buf, data...)
+			buf = append(buf, make([]byte, int(need)*4096-len(data)-4)...)
+		}
+	}
+	for z := 0; z < 32; z++ {
+		for x := 0; x < 32; x++ {
+			binary.BigEndian.PutUint32(buf[4*(z*32+x):], uint32(offsets[z][x]))
+			binary.BigEndian.PutUint32(buf[4096+4*(z*32+x):], uint32(timestamps[z][x]))
+		}
+	}
+
+	if f, ok := r.f.(*os.File); ok && r.name != "" {
+		if err := r.replaceFile(f, buf); err != nil {
+			return err
+		}
+	} else {
+		if _, err := r.writeAt(buf, 0); err != nil {
+			return err
+		}
+		if f, ok := r.f.(interface{ Truncate(size int64) error }); ok {
+			if err := f.Truncate(int64(len(buf))); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.offsets, r.Timestamps = offsets, timestamps
+	r.sectors = make(map[int32]bool)
+	for i := int32(0); i < int32(len(buf)/4096); i++ {
+		r.sectors[i] = true
+	}
+	return nil
+}
+
+// replaceFile writes the content of the region into a temporary file, and renames it to the region file.
+// The region uses the new file after that, and the old file f is closed.
+func (r *Region) replaceFile(f *os.File, content []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(r.name), filepath.Base(r.name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(content)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if err == nil {
+		// The old file is closed first, since the opened file can't be replaced on Windows
+		err = f.Close()
+		if err == nil {
+			err = os.Rename(temp.Name(), r.name)
+			if err != nil {
+				// Keep using the old file
+				if old, openErr := os.OpenFile(r.name, os.O_RDWR, 0o666); openErr == nil {
+					r.f = old
+				}
+			}
+		}
+	}
+	if err != nil {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+		return err
+	}
+	r.f = temp
+	return nil
+}
+
+func (r *Region) readLength(sec int32) (length int32, err error) {
+	if _, err := r.f.Seek(4096*int64(sec), io.SeekStart); err != nil {
+		return 0, err
+	}
+	err = binary.Read(r.f, binary.BigEndian, &length)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
+
+// readRaw reads the chunk data as stored in the region file, without resolving the external chunk.
+func (r *Region) readRaw(x, z int) ([]byte, error) {
+	external := r.external
+	r.external = false
+	defer func() { r.external = external }()
+	return r.ReadSector(x, z)
+}
+
+// readRawLenient works like readRaw, but ignores the number of the sectors of the chunk,
+// as long as the data is inside the file.
+func (r *Region) readRawLenient(x, z int, size int64) ([]byte, error) {
+	sec, num := sectorLoc(r.offsets[z][x])
+	if sec < 2 || num == 0 {
+		return nil, ErrNoSector
+	}
+	length, err := r.readLength(sec)
+	if err != nil {
+		return nil, err
+	}
+	if length <= 0 || int64(sec)*4096+4+int64(length) > size {
+		return nil, ErrNoData
+	}
+	data := make([]byte, length)
+	_, err = io.ReadFull(r.f, data)
+	return data, err
+}
+
+// checkRaw resolves the external chunk of the raw data and checks it.
+func (r *Region) checkRaw(x, z int, raw []byte, check CheckFunc) error {
+	data := raw
+	if r.external && raw[0]&externalFlag != 0 {
+		external, err := os.ReadFile(r.externalPath(x, z))
+		if err != nil {
+			return err
+		}
+		data = append([]byte{raw[0] &^ externalFlag}, external...)
+	}
+	if check == nil {
+		return nil
+	}
+	return check(x, z, data)
+}
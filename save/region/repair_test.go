@@ -0,0 +1,245 @@
+package region
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testChunk returns the data of a chunk for testing, whose content is determined by x and size.
+func testChunk(x, size int) []byte {
+	data := bytes.Repeat([]byte{byte(x)}, size)
+	data[0] = 3 // not compressed
+	return data
+}
+
+// checkTestChunk checks the data made by testChunk.
+func checkTestChunk(x, _ int, data []byte) error {
+	if data[0] != 3 || len(data) < 2 || !bytes.Equal(data[1:], bytes.Repeat([]byte{byte(x)}, len(data)-1)) {
+		return errors.New("corrupted")
+	}
+	return nil
+}
+
+func TestRegion_Compact(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "r.0.0.mca")
+	r, err := Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for x, size := range []int{5000, 100, 9000, 4000} {
+		if err := r.WriteSector(x, 0, testChunk(x, size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// grow the first chunks, which leaves holes in the file
+	for x, size := range []int{9000, 5000} {
+		if err := r.WriteSector(x, 0, testChunk(x, size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timestamp := r.Timestamps[0][2]
+
+	if err := r.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(name); err != nil {
+		t.Fatal(err)
+	} else if want := int64(2+3+2+3+1) * 4096; info.Size() != want {
+		t.Errorf("compacted file size: got %d, want %d", info.Size(), want)
+	}
+	if problems, err := r.Verify(checkTestChunk); err != nil || len(problems) > 0 {
+		t.Errorf("verify compacted region: %v %v", problems, err)
+	}
+	if r.Timestamps[0][2] != timestamp {
+		t.Error("timestamp not preserved")
+	}
+
+	// the compacted region can be loaded and written
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err = Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.WriteSector(5, 0, testChunk(5, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := r.Verify(checkTestChunk); err != nil || len(problems) > 0 {
+		t.Errorf("verify region: %v %v", problems, err)
+	}
+}
+
+func TestRegion_Repair(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "r.0.0.mca")
+	r, err := Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for x, size := range []int{5000, 100, 9000, 4000, 100} {
+		if err := r.WriteSector(x, 0, testChunk(x, size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// chunk 5 shares the sectors with chunk 2
+	sec, num := sectorLoc(r.offsets[0][2])
+	r.offsets[0][5] = sec<<8 | num
+	// chunk 6 is in the file header
+	r.offsets[0][6] = 1<<8 | 1
+	// chunk 7 is out of the file
+	r.offsets[0][7] = 1000<<8 | 1
+	// chunk 1 has a bad length
+	sec, _ = sectorLoc(r.offsets[0][1])
+	if _, err := r.writeAt([]byte{0xFF, 0xFF, 0xFF, 0xFF}, int64(sec)*4096); err != nil {
+		t.Fatal(err)
+	}
+	// chunk 3 is corrupted
+	sec, _ = sectorLoc(r.offsets[0][3])
+	if _, err := r.writeAt([]byte{0xFF}, int64(sec)*4096+100); err != nil {
+		t.Fatal(err)
+	}
+	// chunk 4 has more data than its sector, which is recoverable
+	r.offsets[0][4] &^= 0xFF
+	sec, _ = sectorLoc(r.offsets[0][4])
+	if _, err := r.writeAt(testChunk(4, 5000), int64(sec)*4096+4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.writeAt([]byte{0, 0, 0x13, 0x88}, int64(sec)*4096); err != nil {
+		t.Fatal(err)
+	}
+	r.offsets[0][4] |= 1
+
+	want := map[[2]int]ProblemKind{
+		{5, 0}: ProblemOverlap,
+		{6, 0}: ProblemOutOfBounds,
+		{7, 0}: ProblemOutOfBounds,
+		{1, 0}: ProblemLength,
+		{3, 0}: ProblemData,
+		{4, 0}: ProblemLength,
+	}
+	problems, err := r.Repair(checkTestChunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[[2]int]ProblemKind)
+	for _, p := range problems {
+		// only the first problem of each chunk
+		if _, ok := got[[2]int{p.X, p.Z}]; !ok {
+			got[[2]int{p.X, p.Z}] = p.Kind
+		}
+	}
+	for pos, kind := range want {
+		if got[pos] != kind {
+			t.Errorf("chunk %v: got problem %v, want %v", pos, got[pos], kind)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("problems: %v", problems)
+	}
+
+	if problems, err := r.Verify(checkTestChunk); err != nil || len(problems) > 0 {
+		t.Errorf("verify repaired region: %v %v", problems, err)
+	}
+	for x, exist := range []bool{true, false, true, false, true, false, false, false} {
+		if r.ExistSector(x, 0) != exist {
+			t.Errorf("chunk %d exist: got %v, want %v", x, !exist, exist)
+		}
+	}
+	if data, err := r.ReadSector(4, 0); err != nil || !bytes.Equal(data, testChunk(4, 5000)) {
+		t.Errorf("chunk 4 not recovered: %v", err)
+	}
+}
+
+func TestRegion_Repair_large(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "r.0.0.mca")
+	// makeLarge writes a chunk whose declared length exceeds its sector and needs more than 255 sectors,
+	// the data beyond the end of file is filled with zeros, which is valid for the chunk 0.
+	const length = 300 * 4096
+	makeLarge := func(r *Region) {
+		if err := r.WriteSector(0, 1, testChunk(0, 100)); err != nil {
+			t.Fatal(err)
+		}
+		sec, _ := sectorLoc(r.offsets[1][0])
+		if _, err := r.writeAt(binary.BigEndian.AppendUint32(nil, length), int64(sec)*4096); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.writeAt([]byte{0}, int64(sec)*4096+4+length-1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the chunk is moved into the .mcc file
+	r, err := Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.WriteSector(1, 0, testChunk(1, 5000)); err != nil {
+		t.Fatal(err)
+	}
+	makeLarge(r)
+	if _, err := r.Repair(checkTestChunk); err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := r.Verify(checkTestChunk); err != nil || len(problems) > 0 {
+		t.Errorf("verify repaired region: %v %v", problems, err)
+	}
+	if data, err := r.ReadSector(0, 1); err != nil || !bytes.Equal(data, testChunk(0, length)) {
+		t.Errorf("large chunk not recovered: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c.0.1.mcc")); err != nil {
+		t.Errorf("external chunk not written: %v", err)
+	}
+	// the file is replaced, and the header is written
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil {
+		t.Fatal(err)
+	} else if len(entries) != 2 {
+		t.Errorf("files left in the folder: %v", entries)
+	}
+	r, err = Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := r.Verify(checkTestChunk); err != nil || len(problems) > 0 {
+		t.Errorf("verify reopened region: %v %v", problems, err)
+	}
+	if data, err := r.ReadSector(0, 1); err != nil || !bytes.Equal(data, testChunk(0, length)) {
+		t.Errorf("large chunk not reopened: %v", err)
+	}
+
+	// the chunk is removed if the region doesn't have a name
+	f, err := os.Create(filepath.Join(t.TempDir(), "region"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = CreateWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := r.WriteSector(1, 0, testChunk(1, 5000)); err != nil {
+		t.Fatal(err)
+	}
+	makeLarge(r)
+	if _, err := r.Repair(checkTestChunk); err != nil {
+		t.Fatal(err)
+	}
+	if problems, err := r.Verify(checkTestChunk); err != nil || len(problems) > 0 {
+		t.Errorf("verify repaired region: %v %v", problems, err)
+	}
+	if r.ExistSector(0, 1) || !r.ExistSector(1, 0) {
+		t.Error("only the large chunk should be removed")
+	}
+}
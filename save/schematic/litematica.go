@@ -0,0 +1,294 @@
+package schematic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// The schematic file of the Litematica mod.
+type litematic struct {
+	MinecraftDataVersion int32
+	Version              int32
+	SubVersion           int32 `nbt:"SubVersion,omitempty"`
+	Metadata             litematicMetadata
+	Regions              map[string]litematicRegion
+}
+
+type litematicMetadata struct {
+	Name          string
+	Author        string
+	Description   string
+	RegionCount   int32
+	TotalVolume   int32
+	TotalBlocks   int32
+	TimeCreated   int64
+	TimeModified  int64
+	EnclosingSize litematicVec
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+type litematicVec struct {
+	X int32 `nbt:"x"`
+	Y int32 `nbt:"y"`
+	Z int32 `nbt:"z"`
+}
+
+type litematicRegion struct {
+	Position          litematicVec
+	Size              litematicVec // Could be negative, which extends from the Position to the negative direction
+	BlockStatePalette []paletteEntry
+	BlockStates       []uint64
+	TileEntities      []litematicBlockEntity
+	Entities          []litematicEntity
+	PendingBlockTicks []nbt.RawMessage
+	PendingFluidTicks []nbt.RawMessage
+}
+
+type litematicBlockEntity struct {
+	X    int32                     `nbt:"x"`
+	Y    int32                     `nbt:"y"`
+	Z    int32                     `nbt:"z"`
+	Data map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+type litematicEntity struct {
+	ID   string                    `nbt:"id"`
+	Pos  [3]float64                `nbt:"Pos"`
+	Data map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+// minCorner returns the position of the min corner and the size of the region, which are positive.
+func (r *litematicRegion) minCorner() (pos, size [3]int) {
+	p := [3]int32{r.Position.X, r.Position.Y, r.Position.Z}
+	s := [3]int32{r.Size.X, r.Size.Y, r.Size.Z}
+	for i := range p {
+		if s[i] < 0 {
+			pos[i], size[i] = int(p[i])+int(s[i])+1, -int(s[i])
+		} else {
+			pos[i], size[i] = int(p[i]), int(s[i])
+		}
+	}
+	return
+}
+
+// ReadLitematica reads the Litematica schematic (.litematic).
+// All regions are merged into one Schematic, which is the box enclosing them,
+// and the positions not in any region are filled with Void.
+// The pending ticks are ignored.
+func ReadLitematica(r io.Reader) (*Schematic, error) {
+	var f litematic
+	if err := decodeGzip(r, &f); err != nil {
+		return nil, err
+	}
+	if len(f.Regions) == 0 {
+		return nil, errors.New("litematica: no region")
+	}
+
+	// Calculate the enclosing box
+	names := make([]string, 0, len(f.Regions))
+	for name := range f.Regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lo, hi [3]int
+	for i, name := range names {
+		region := f.Regions[name]
+		pos, size := region.minCorner()
+		for j := range pos {
+			if i == 0 || pos[j] < lo[j] {
+				lo[j] = pos[j]
+			}
+			if i == 0 || pos[j]+size[j] > hi[j] {
+				hi[j] = pos[j] + size[j]
+			}
+		}
+	}
+
+	if err := checkSize(hi[0]-lo[0], hi[1]-lo[1], hi[2]-lo[2]); err != nil {
+		return nil, fmt.Errorf("litematica: %w", err)
+	}
+	s := New(hi[0]-lo[0], hi[1]-lo[1], hi[2]-lo[2])
+	s.DataVersion = f.MinecraftDataVersion
+	s.Name, s.Author = f.Metadata.Name, f.Metadata.Author
+	for i := range s.Blocks {
+		s.Blocks[i] = Void
+	}
+	for _, name := range names {
+		region := f.Regions[name]
+		pos, size := region.minCorner()
+		offset := [3]int{pos[0] - lo[0], pos[1] - lo[1], pos[2] - lo[2]}
+		if err := s.readLitematicRegion(&region, offset, size); err != nil {
+			return nil, fmt.Errorf("litematica region %q: %w", name, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *Schematic) readLitematicRegion(r *litematicRegion, offset, size [3]int) error {
+	states := make([]block.StateID, len(r.BlockStatePalette))
+	for i, v := range r.BlockStatePalette {
+		var err error
+		if states[i], err = v.state(); err != nil {
+			return err
+		}
+	}
+	volume := size[0] * size[1] * size[2]
+	n := max(2, bits.Len(uint(len(states)-1)))
+	if len(r.BlockStates)*64 < volume*n {
+		return errors.New("block states too short")
+	}
+	for y := 0; y < size[1]; y++ {
+		for z := 0; z < size[2]; z++ {
+			for x := 0; x < size[0]; x++ {
+				v := getSpanning(r.BlockStates, n, (y*size[2]+z)*size[0]+x)
+				if v >= uint64(len(states)) {
+					return fmt.Errorf("palette index %d out of range", v)
+				}
+				s.SetBlock(offset[0]+x, offset[1]+y, offset[2]+z, states[v])
+			}
+		}
+	}
+	for _, v := range r.TileEntities {
+		if v.X < 0 || v.Y < 0 || v.Z < 0 || int(v.X) >= size[0] || int(v.Y) >= size[1] || int(v.Z) >= size[2] {
+			return fmt.Errorf("block entity (%d, %d, %d) out of the region", v.X, v.Y, v.Z)
+		}
+		be := BlockEntity{
+			Pos:  [3]int32{v.X + int32(offset[0]), v.Y + int32(offset[1]), v.Z + int32(offset[2])},
+			Data: v.Data,
+		}
+		if err := popString(be.Data, "id", &be.ID); err != nil {
+			return err
+		}
+		if be.ID == "" {
+			// The id is omitted by the older versions of Litematica
+			b := block.StateList[s.Block(int(be.Pos[0]), int(be.Pos[1]), int(be.Pos[2]))]
+			be.ID = blockEntityID(b)
+		}
+		s.BlockEntities = append(s.BlockEntities, be)
+	}
+	for _, v := range r.Entities {
+		// The tile positions of the hanging entities are relative to the region
+		data, err := moveTile(v.Data, offset)
+		if err != nil {
+			return err
+		}
+		s.Entities = append(s.Entities, Entity{
+			Pos:  [3]float64{v.Pos[0] + float64(offset[0]), v.Pos[1] + float64(offset[1]), v.Pos[2] + float64(offset[2])},
+			ID:   v.ID,
+			Data: data,
+		})
+	}
+	return nil
+}
+
+// WriteLitematica writes the schematic as a Litematica schematic (.litematic) with one region.
+func WriteLitematica(w io.Writer, s *Schematic) error {
+	states, indices := palette(s.Blocks)
+	region := litematicRegion{
+		Size:              litematicVec{int32(s.Size[0]), int32(s.Size[1]), int32(s.Size[2])},
+		BlockStatePalette: make([]paletteEntry, len(states)),
+		TileEntities:      []litematicBlockEntity{},
+		Entities:          []litematicEntity{},
+		PendingBlockTicks: []nbt.RawMessage{},
+		PendingFluidTicks: []nbt.RawMessage{},
+	}
+	// Litematica expects air at the first of the palette
+	air := block.ToStateID[block.Air{}]
+	for i, v := range states {
+		if v == air && i != 0 {
+			states[0], states[i] = states[i], states[0]
+			for j, k := range indices {
+				switch k {
+				case 0:
+					indices[j] = i
+				case i:
+					indices[j] = 0
+				}
+			}
+			break
+		}
+	}
+	var totalBlocks int32
+	for i, v := range indices {
+		if states[v] != air && s.Blocks[i] != Void {
+			totalBlocks++
+		}
+	}
+	for i, v := range states {
+		region.BlockStatePalette[i] = newPaletteEntry(v)
+	}
+	n := max(2, bits.Len(uint(len(states)-1)))
+	region.BlockStates = make([]uint64, (len(indices)*n+63)/64)
+	for i, v := range indices {
+		setSpanning(region.BlockStates, n, i, uint64(v))
+	}
+	for _, v := range s.BlockEntities {
+		data, err := withString(v.Data, "id", v.ID)
+		if err != nil {
+			return err
+		}
+		region.TileEntities = append(region.TileEntities, litematicBlockEntity{X: v.Pos[0], Y: v.Pos[1], Z: v.Pos[2], Data: data})
+	}
+	for _, v := range s.Entities {
+		region.Entities = append(region.Entities, litematicEntity{ID: v.ID, Pos: v.Pos, Data: v.Data})
+	}
+
+	name := s.Name
+	if name == "" {
+		name = "Unnamed"
+	}
+	now := time.Now().UnixMilli()
+	f := litematic{
+		MinecraftDataVersion: s.DataVersion,
+		Version:              7,
+		SubVersion:           1,
+		Metadata: litematicMetadata{
+			Name:          name,
+			Author:        s.Author,
+			RegionCount:   1,
+			TotalVolume:   int32(len(s.Blocks)),
+			TotalBlocks:   totalBlocks,
+			TimeCreated:   now,
+			TimeModified:  now,
+			EnclosingSize: region.Size,
+		},
+		Regions: map[string]litematicRegion{name: region},
+	}
+	return encodeGzip(w, f, "")
+}
+
+// getSpanning returns the i-th value of the data, whose values are packed across the longs.
+func getSpanning(data []uint64, bits, i int) uint64 {
+	start, offset := i*bits/64, i*bits%64
+	v := data[start] >> offset
+	if offset+bits > 64 {
+		v |= data[start+1] << (64 - offset)
+	}
+	return v & (1<<bits - 1)
+}
+
+func setSpanning(data []uint64, bits, i int, v uint64) {
+	start, offset := i*bits/64, i*bits%64
+	data[start] |= v << offset
+	if offset+bits > 64 {
+		data[start+1] |= v >> (64 - offset)
+	}
+}
+
+// blockEntityID returns the ID of the block entity of the block, or "" if it's unknown.
+func blockEntityID(b block.Block) string {
+	for _, v := range block.EntityList {
+		if v.IsValidBlock(b) {
+			return v.ID()
+		}
+	}
+	return ""
+}
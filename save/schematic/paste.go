@@ -0,0 +1,303 @@
+package schematic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/Tnze/go-mc/save"
+	"github.com/Tnze/go-mc/save/region"
+)
+
+// box is the intersection of the schematic placed at "at" and the chunk, in the world coordinates.
+type box struct{ min, max [3]int }
+
+func (s *Schematic) chunkBox(pos level.ChunkPos, minY, secs int, at [3]int) (b box, ok bool) {
+	chunkMin := [3]int{int(pos[0]) << 4, minY, int(pos[1]) << 4}
+	chunkMax := [3]int{chunkMin[0] + 16, minY + secs*16, chunkMin[2] + 16}
+	for i := range b.min {
+		b.min[i] = max(at[i], chunkMin[i])
+		b.max[i] = min(at[i]+s.Size[i], chunkMax[i])
+		if b.min[i] >= b.max[i] {
+			return b, false
+		}
+	}
+	return b, true
+}
+
+func (b box) contains(x, y, z int) bool {
+	return x >= b.min[0] && y >= b.min[1] && z >= b.min[2] && x < b.max[0] && y < b.max[1] && z < b.max[2]
+}
+
+// PasteChunk pastes the part of the schematic in the chunk at pos, whose lowest block is at minY.
+// The min corner of the schematic is placed at "at", in the world coordinates.
+//
+// The Void blocks are skipped, and the block entities of the replaced blocks are removed.
+// The heightmaps and lights are not updated, and the entities are not pasted, which aren't in the level.Chunk.
+func (s *Schematic) PasteChunk(c *level.Chunk, pos level.ChunkPos, minY int, at [3]int) error {
+	b, ok := s.chunkBox(pos, minY, len(c.Sections), at)
+	if !ok {
+		return nil
+	}
+	baseX, baseZ := int(pos[0])<<4, int(pos[1])<<4
+	replaced := make(map[[3]int]bool)
+	for y := b.min[1]; y < b.max[1]; y++ {
+		sec := &c.Sections[(y-minY)>>4]
+		for z := b.min[2]; z < b.max[2]; z++ {
+			for x := b.min[0]; x < b.max[0]; x++ {
+				state := s.Block(x-at[0], y-at[1], z-at[2])
+				if state == Void {
+					continue
+				}
+				sec.SetBlock((y-minY)&15<<8|(z-baseZ)<<4|(x-baseX), state)
+				replaced[[3]int{x, y, z}] = true
+			}
+		}
+	}
+
+	blockEntities := c.BlockEntity[:0]
+	for _, v := range c.BlockEntity {
+		x, z := v.UnpackXZ()
+		if !replaced[[3]int{baseX + x, int(v.Y), baseZ + z}] {
+			blockEntities = append(blockEntities, v)
+		}
+	}
+	for _, v := range s.BlockEntities {
+		x, y, z := int(v.Pos[0])+at[0], int(v.Pos[1])+at[1], int(v.Pos[2])+at[2]
+		if !replaced[[3]int{x, y, z}] {
+			continue
+		}
+		typ, ok := block.EntityTypes[v.ID]
+		if !ok {
+			return fmt.Errorf("unknown block entity id: %v", v.ID)
+		}
+		data, err := rawNBT(nonNilMap(v.Data))
+		if err != nil {
+			return err
+		}
+		be := level.BlockEntity{Y: int16(y), Type: typ, Data: data}
+		be.PackXZ(x-baseX, z-baseZ)
+		blockEntities = append(blockEntities, be)
+	}
+	c.BlockEntity = blockEntities
+	return nil
+}
+
+// ExtractChunk copies the blocks and block entities in the chunk at pos, whose lowest block is at minY,
+// into the part of the schematic in it. The min corner of the schematic is at "at", in the world coordinates.
+func (s *Schematic) ExtractChunk(c *level.Chunk, pos level.ChunkPos, minY int, at [3]int) error {
+	b, ok := s.chunkBox(pos, minY, len(c.Sections), at)
+	if !ok {
+		return nil
+	}
+	baseX, baseZ := int(pos[0])<<4, int(pos[1])<<4
+	for y := b.min[1]; y < b.max[1]; y++ {
+		sec := &c.Sections[(y-minY)>>4]
+		for z := b.min[2]; z < b.max[2]; z++ {
+			for x := b.min[0]; x < b.max[0]; x++ {
+				state := sec.GetBlock((y-minY)&15<<8 | (z-baseZ)<<4 | (x - baseX))
+				s.SetBlock(x-at[0], y-at[1], z-at[2], state)
+			}
+		}
+	}
+
+	blockEntities := s.BlockEntities[:0]
+	for _, v := range s.BlockEntities {
+		if !b.contains(int(v.Pos[0])+at[0], int(v.Pos[1])+at[1], int(v.Pos[2])+at[2]) {
+			blockEntities = append(blockEntities, v)
+		}
+	}
+	for _, v := range c.BlockEntity {
+		x, z := v.UnpackXZ()
+		x, y, z := baseX+x, int(v.Y), baseZ+z
+		if !b.contains(x, y, z) {
+			continue
+		}
+		if v.Type < 0 || int(v.Type) >= len(block.EntityList) {
+			return fmt.Errorf("unknown block entity type: %d", v.Type)
+		}
+		be := BlockEntity{
+			Pos:  [3]int32{int32(x - at[0]), int32(y - at[1]), int32(z - at[2])},
+			ID:   block.EntityList[v.Type].ID(),
+			Data: make(map[string]nbt.RawMessage),
+		}
+		if v.Data.Type != nbt.TagEnd {
+			if err := v.Data.Unmarshal(&be.Data); err != nil {
+				return err
+			}
+		}
+		for _, k := range [...]string{"id", "x", "y", "z"} {
+			delete(be.Data, k)
+		}
+		blockEntities = append(blockEntities, be)
+	}
+	s.BlockEntities = blockEntities
+	return nil
+}
+
+// PasteWorld pastes the schematic into the dimension of the world, with the min corner at "at".
+// The chunks not generated yet are skipped.
+//
+// The heightmaps of the changed chunks are removed and their light is marked as not computed,
+// which are recomputed by the game when the chunks are loaded.
+// The entities are pasted with new UUIDs.
+func (s *Schematic) PasteWorld(w *save.World, dim string, at [3]int) error {
+	for cz := at[2] >> 4; cz <= (at[2]+s.Size[2]-1)>>4; cz++ {
+		for cx := at[0] >> 4; cx <= (at[0]+s.Size[0]-1)>>4; cx++ {
+			if err := s.pasteWorldChunk(w, dim, at, cx, cz); err != nil {
+				return fmt.Errorf("paste chunk (%d, %d): %w", cx, cz, err)
+			}
+		}
+	}
+
+	entities := make(map[[2]int][]save.Entity)
+	for _, v := range s.Entities {
+		e, err := v.toSave(at)
+		if err != nil {
+			return err
+		}
+		cx, cz := e.ChunkPos()
+		entities[[2]int{cx, cz}] = append(entities[[2]int{cx, cz}], e)
+	}
+	for pos, list := range entities {
+		c, err := w.LoadEntities(dim, pos[0], pos[1])
+		if errors.Is(err, region.ErrNoSector) {
+			c = &save.EntityChunk{DataVersion: save.DataVersion, Position: [2]int32{int32(pos[0]), int32(pos[1])}}
+		} else if err != nil {
+			return err
+		}
+		c.Entities = append(c.Entities, list...)
+		if err := w.SaveEntities(dim, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schematic) pasteWorldChunk(w *save.World, dim string, at [3]int, cx, cz int) error {
+	sc, err := w.LoadChunk(dim, cx, cz)
+	if errors.Is(err, region.ErrNoSector) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	c, err := level.ChunkFromSave(sc)
+	if err != nil {
+		return err
+	}
+	if err := s.PasteChunk(c, level.ChunkPos{int32(cx), int32(cz)}, int(sc.YPos)*16, at); err != nil {
+		return err
+	}
+	c.HeightMaps = level.HeightMaps{}
+	if err := level.ChunkToSave(c, sc); err != nil {
+		return err
+	}
+	sc.IsLightOn = 0
+	return w.SaveChunk(dim, sc)
+}
+
+// ExtractWorld copies the box of the size from the dimension of the world, whose min corner is at "at".
+// The blocks in the chunks not generated yet are Void.
+func ExtractWorld(w *save.World, dim string, at, size [3]int) (*Schematic, error) {
+	s := New(size[0], size[1], size[2])
+	for i := range s.Blocks {
+		s.Blocks[i] = Void
+	}
+	for cz := at[2] >> 4; cz <= (at[2]+size[2]-1)>>4; cz++ {
+		for cx := at[0] >> 4; cx <= (at[0]+size[0]-1)>>4; cx++ {
+			if err := s.extractWorldChunk(w, dim, at, cx, cz); err != nil {
+				return nil, fmt.Errorf("extract chunk (%d, %d): %w", cx, cz, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *Schematic) extractWorldChunk(w *save.World, dim string, at [3]int, cx, cz int) error {
+	sc, err := w.LoadChunk(dim, cx, cz)
+	if errors.Is(err, region.ErrNoSector) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	c, err := level.ChunkFromSave(sc)
+	if err != nil {
+		return err
+	}
+	if err := s.ExtractChunk(c, level.ChunkPos{int32(cx), int32(cz)}, int(sc.YPos)*16, at); err != nil {
+		return err
+	}
+
+	ec, err := w.LoadEntities(dim, cx, cz)
+	if errors.Is(err, region.ErrNoSector) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, v := range ec.Entities {
+		pos := [3]float64{v.Pos[0] - float64(at[0]), v.Pos[1] - float64(at[1]), v.Pos[2] - float64(at[2])}
+		if v.ID == "" || pos[0] < 0 || pos[1] < 0 || pos[2] < 0 ||
+			pos[0] >= float64(s.Size[0]) || pos[1] >= float64(s.Size[1]) || pos[2] >= float64(s.Size[2]) {
+			continue
+		}
+		e := Entity{Pos: pos, ID: v.ID}
+		raw, err := rawNBT(v)
+		if err != nil {
+			return err
+		}
+		if err := raw.Unmarshal(&e.Data); err != nil {
+			return err
+		}
+		for _, k := range [...]string{"id", "Pos", "UUID"} {
+			delete(e.Data, k)
+		}
+		if e.Data, err = moveTile(e.Data, [3]int{-at[0], -at[1], -at[2]}); err != nil {
+			return err
+		}
+		s.Entities = append(s.Entities, e)
+	}
+	return nil
+}
+
+// toSave converts the entity to be saved in the world, with a new UUID.
+// The positions are moved by "at", the min corner of the schematic in the world.
+func (e Entity) toSave(at [3]int) (v save.Entity, err error) {
+	data, err := moveTile(e.Data, at)
+	if err != nil {
+		return
+	}
+	if data, err = withString(data, "id", e.ID); err != nil {
+		return
+	}
+	if data["Pos"], err = rawNBT([3]float64{
+		e.Pos[0] + float64(at[0]),
+		e.Pos[1] + float64(at[1]),
+		e.Pos[2] + float64(at[2]),
+	}); err != nil {
+		return
+	}
+	raw, err := rawNBT(data)
+	if err != nil {
+		return
+	}
+	if err = raw.Unmarshal(&v); err != nil {
+		return
+	}
+	id := uuid.New()
+	for i := range v.UUID {
+		v.UUID[i] = int32(binary.BigEndian.Uint32(id[i*4:]))
+	}
+	return
+}
+
+func nonNilMap(m map[string]nbt.RawMessage) map[string]nbt.RawMessage {
+	if m == nil {
+		return map[string]nbt.RawMessage{}
+	}
+	return m
+}
@@ -0,0 +1,234 @@
+// Package schematic reads and writes the files storing a part of the world, which are used to move builds between worlds.
+//
+// The supported formats are:
+//   - Sponge schematic (.schem) version 2 and 3, used by WorldEdit.
+//   - Structure (.nbt), saved by the structure blocks of the vanilla game.
+//   - Litematica (.litematic).
+//
+// All of them are read into a Schematic, which can be pasted into level.Chunk or save.World.
+package schematic
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/Tnze/go-mc/save"
+)
+
+// Void is the state of minecraft:structure_void.
+// The positions of it are left unchanged when pasting the schematic.
+var Void = block.ToStateID[block.StructureVoid{}]
+
+// Schematic is a box of blocks, with the block entities and entities in it.
+type Schematic struct {
+	DataVersion int32
+	Name        string
+	Author      string
+
+	Size   [3]int   // The size of the box, X, Y and Z
+	Offset [3]int32 // The position of the box relative to the player when it's copied, if known
+
+	Blocks        []block.StateID // In the YZX order, see Index
+	BlockEntities []BlockEntity
+	Entities      []Entity
+}
+
+// BlockEntity is a block entity in the schematic.
+type BlockEntity struct {
+	Pos  [3]int32 // Relative to the schematic
+	ID   string
+	Data map[string]nbt.RawMessage // Without the id and the position
+}
+
+// Entity is an entity in the schematic.
+type Entity struct {
+	Pos  [3]float64 // Relative to the schematic
+	ID   string
+	Data map[string]nbt.RawMessage // Without the id and the position
+}
+
+// The hanging entities, like the item frames and paintings, have the position of the block they're in,
+// or hung on for the paintings, in the TileX, TileY and TileZ tags of Entity.Data,
+// which are relative to the schematic like Entity.Pos.
+
+// New creates a schematic of the size, filled with air.
+func New(x, y, z int) *Schematic {
+	return &Schematic{
+		DataVersion: save.DataVersion,
+		Size:        [3]int{x, y, z},
+		Blocks:      make([]block.StateID, x*y*z),
+	}
+}
+
+// maxVolume is the max number of blocks of the schematics read from the files,
+// which stops the broken files from allocating too much memory.
+const maxVolume = 1 << 27
+
+// checkSize returns an error if the size read from the file is negative, or the volume is larger than maxVolume.
+func checkSize(x, y, z int) error {
+	if x < 0 || y < 0 || z < 0 {
+		return fmt.Errorf("invalid size %dx%dx%d", x, y, z)
+	}
+	if x == 0 || y == 0 || z == 0 {
+		return nil
+	}
+	if x > maxVolume || y > maxVolume/x || z > maxVolume/(x*y) {
+		return fmt.Errorf("size %dx%dx%d too large", x, y, z)
+	}
+	return nil
+}
+
+// Index returns the index of the block at (x, y, z) in Blocks.
+func (s *Schematic) Index(x, y, z int) int {
+	return (y*s.Size[2]+z)*s.Size[0] + x
+}
+
+// Contains returns if (x, y, z) is in the schematic.
+func (s *Schematic) Contains(x, y, z int) bool {
+	return x >= 0 && y >= 0 && z >= 0 && x < s.Size[0] && y < s.Size[1] && z < s.Size[2]
+}
+
+// Block returns the block at (x, y, z).
+func (s *Schematic) Block(x, y, z int) block.StateID {
+	return s.Blocks[s.Index(x, y, z)]
+}
+
+// SetBlock sets the block at (x, y, z).
+func (s *Schematic) SetBlock(x, y, z int, state block.StateID) {
+	s.Blocks[s.Index(x, y, z)] = state
+}
+
+// StateString formats the block state like "minecraft:oak_stairs[facing=east,half=bottom]",
+// which is used by the commands and Sponge schematics.
+func StateString(state block.StateID) string {
+	b := block.StateList[state]
+	props := block.Properties(b)
+	if len(props) == 0 {
+		return b.ID()
+	}
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(b.ID())
+	for i, k := range keys {
+		if i == 0 {
+			sb.WriteByte('[')
+		} else {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(props[k])
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// ParseState parses the block state formatted like StateString.
+// The namespace "minecraft:" can be omitted.
+func ParseState(str string) (block.StateID, error) {
+	name, propsStr, hasProps := strings.Cut(str, "[")
+	if !strings.Contains(name, ":") {
+		name = "minecraft:" + name
+	}
+	props := make(map[string]string)
+	if hasProps {
+		propsStr, ok := strings.CutSuffix(propsStr, "]")
+		if !ok {
+			return 0, errors.New("invalid block state: " + str)
+		}
+		for _, kv := range strings.Split(propsStr, ",") {
+			if kv == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				return 0, errors.New("invalid block state: " + str)
+			}
+			props[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return block.FromProperties(name, props)
+}
+
+// paletteEntry is a block state in the palette of the structure and Litematica files.
+type paletteEntry struct {
+	Name       string
+	Properties map[string]string `nbt:"Properties,omitempty"`
+}
+
+func (p paletteEntry) state() (block.StateID, error) {
+	return block.FromProperties(p.Name, p.Properties)
+}
+
+func newPaletteEntry(state block.StateID) paletteEntry {
+	b := block.StateList[state]
+	props := block.Properties(b)
+	if len(props) == 0 {
+		props = nil
+	}
+	return paletteEntry{Name: b.ID(), Properties: props}
+}
+
+// palette builds the palette of the blocks, and returns the indices of the blocks in it.
+func palette(blocks []block.StateID) (states []block.StateID, indices []int) {
+	index := make(map[block.StateID]int)
+	indices = make([]int, len(blocks))
+	for i, v := range blocks {
+		j, ok := index[v]
+		if !ok {
+			j = len(states)
+			index[v] = j
+			states = append(states, v)
+		}
+		indices[i] = j
+	}
+	return
+}
+
+// decodeGzip decodes the NBT file into v, which is gzip compressed or not.
+func decodeGzip(r io.Reader, v any) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		r = zr
+	} else {
+		r = br
+	}
+	_, err := nbt.NewDecoder(r).Decode(v)
+	return err
+}
+
+// encodeGzip encodes v as a gzip compressed NBT file.
+func encodeGzip(w io.Writer, v any, name string) error {
+	zw := gzip.NewWriter(w)
+	if err := nbt.NewEncoder(zw).Encode(v, name); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// rawNBT encodes v into a nbt.RawMessage.
+func rawNBT(v any) (raw nbt.RawMessage, err error) {
+	var buf bytes.Buffer
+	if err = nbt.NewEncoder(&buf).Encode(v, ""); err != nil {
+		return
+	}
+	_, err = nbt.NewDecoder(&buf).Decode(&raw)
+	return
+}
@@ -0,0 +1,513 @@
+package schematic
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+	"github.com/Tnze/go-mc/save"
+)
+
+func mustParseState(t *testing.T, str string) block.StateID {
+	t.Helper()
+	state, err := ParseState(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func mustRawNBT(t *testing.T, v any) nbt.RawMessage {
+	t.Helper()
+	raw, err := rawNBT(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// testSchematic returns a 3x2x4 schematic with a chest, some stairs, a Void block and an armor stand.
+func testSchematic(t *testing.T) *Schematic {
+	s := New(3, 2, 4)
+	for x := 0; x < 3; x++ {
+		for z := 0; z < 4; z++ {
+			s.SetBlock(x, 0, z, block.ToStateID[block.Stone{}])
+		}
+	}
+	s.SetBlock(0, 1, 0, mustParseState(t, "minecraft:chest[facing=west,type=single,waterlogged=false]"))
+	s.SetBlock(1, 1, 0, mustParseState(t, "oak_stairs[facing=north,half=bottom,shape=straight,waterlogged=false]"))
+	s.SetBlock(2, 1, 3, mustParseState(t, "minecraft:oak_log[axis=x]"))
+	s.SetBlock(1, 1, 2, Void)
+	s.BlockEntities = []BlockEntity{{
+		Pos: [3]int32{0, 1, 0},
+		ID:  "minecraft:chest",
+		Data: map[string]nbt.RawMessage{
+			"CustomName": mustRawNBT(t, `{"text":"Loot"}`),
+		},
+	}}
+	s.Entities = []Entity{{
+		Pos: [3]float64{1.5, 1, 3.5},
+		ID:  "minecraft:armor_stand",
+		Data: map[string]nbt.RawMessage{
+			"Rotation": mustRawNBT(t, [2]float32{90, 0}),
+		},
+	}}
+	return s
+}
+
+func TestParseState(t *testing.T) {
+	for _, str := range []string{
+		"minecraft:stone",
+		"minecraft:oak_stairs[facing=east,half=top,shape=inner_left,waterlogged=true]",
+	} {
+		state, err := ParseState(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := StateString(state); got != str {
+			t.Errorf("StateString(ParseState(%q)) = %q", str, got)
+		}
+	}
+	if _, err := ParseState("minecraft:oak_stairs[facing=up]"); err == nil {
+		t.Error("invalid property value is parsed")
+	}
+	if _, err := ParseState("minecraft:not_a_block"); err == nil {
+		t.Error("unknown block is parsed")
+	}
+	// The properties can be omitted
+	if state := mustParseState(t, "oak_log"); block.StateList[state].ID() != "minecraft:oak_log" {
+		t.Errorf("state of oak_log: %s", StateString(state))
+	}
+}
+
+func TestFormats(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		write func(w io.Writer, s *Schematic) error
+		read  func(r io.Reader) (*Schematic, error)
+	}{
+		{"sponge v2", func(w io.Writer, s *Schematic) error { return WriteSponge(w, s, 2) }, ReadSponge},
+		{"sponge v3", func(w io.Writer, s *Schematic) error { return WriteSponge(w, s, 3) }, ReadSponge},
+		{"structure", WriteStructure, ReadStructure},
+		{"litematica", WriteLitematica, ReadLitematica},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			want := testSchematic(t)
+			want.Name = "test"
+			var buf bytes.Buffer
+			if err := tt.write(&buf, want); err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.read(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Size != want.Size || got.DataVersion != want.DataVersion {
+				t.Errorf("size %v and data version %d, want %v and %d", got.Size, got.DataVersion, want.Size, want.DataVersion)
+			}
+			if !reflect.DeepEqual(got.Blocks, want.Blocks) {
+				t.Errorf("blocks: got %v, want %v", got.Blocks, want.Blocks)
+			}
+			if !reflect.DeepEqual(got.BlockEntities, want.BlockEntities) {
+				t.Errorf("block entities: got %v, want %v", got.BlockEntities, want.BlockEntities)
+			}
+			if !reflect.DeepEqual(got.Entities, want.Entities) {
+				t.Errorf("entities: got %v, want %v", got.Entities, want.Entities)
+			}
+		})
+	}
+}
+
+// The fixtures are the same build saved by WorldEdit, the structure block and Litematica,
+// which is copied from (99, 64, 199) to (101, 65, 200), with an item frame and a painting hung on the wall.
+func TestRead_fixtures(t *testing.T) {
+	for _, tt := range []struct {
+		file  string
+		read  func(r io.Reader) (*Schematic, error)
+		write func(w io.Writer, s *Schematic) error
+	}{
+		{"worldedit.schem", ReadSponge, func(w io.Writer, s *Schematic) error { return WriteSponge(w, s, 3) }},
+		{"structure.nbt", ReadStructure, WriteStructure},
+		{"test.litematic", ReadLitematica, WriteLitematica},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			s := readFixture(t, tt.file, tt.read)
+			if s.Size != [3]int{3, 2, 2} {
+				t.Fatalf("size: %v", s.Size)
+			}
+			chest := mustParseState(t, "minecraft:chest[facing=south,type=single,waterlogged=false]")
+			stone, air := block.ToStateID[block.Stone{}], block.ToStateID[block.Air{}]
+			if s.Block(0, 0, 0) != stone || s.Block(2, 1, 0) != stone || s.Block(0, 1, 1) != chest || s.Block(1, 1, 1) != air {
+				t.Errorf("blocks: %v", s.Blocks)
+			}
+			if len(s.BlockEntities) != 1 || s.BlockEntities[0].ID != "minecraft:chest" || s.BlockEntities[0].Pos != [3]int32{0, 1, 1} || s.BlockEntities[0].Data["Items"].Type != nbt.TagList {
+				t.Errorf("block entities: %v", s.BlockEntities)
+			}
+			checkHanging(t, s.Entities, map[string][3]int32{
+				"minecraft:item_frame": {2, 1, 1},
+				"minecraft:painting":   {1, 1, 1},
+			})
+
+			// The tile positions are kept by writing and reading again
+			var buf bytes.Buffer
+			if err := tt.write(&buf, s); err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.read(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Entities, s.Entities) {
+				t.Errorf("entities written: got %v, want %v", got.Entities, s.Entities)
+			}
+		})
+	}
+}
+
+func readFixture(t *testing.T, name string, read func(r io.Reader) (*Schematic, error)) *Schematic {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, err := read(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// checkHanging checks the tile positions of the hanging entities, by their ids.
+func checkHanging(t *testing.T, entities []Entity, want map[string][3]int32) {
+	t.Helper()
+	if len(entities) != len(want) {
+		t.Errorf("entities: %v", entities)
+	}
+	for _, e := range entities {
+		if pos, ok := tilePos(e.Data); !ok || pos != want[e.ID] {
+			t.Errorf("tile position of %s: got %v, want %v", e.ID, pos, want[e.ID])
+		}
+	}
+}
+
+// facing returns the byte tag of the entity.
+func facing(t *testing.T, e Entity, name string) (v int8) {
+	t.Helper()
+	if err := e.Data[name].Unmarshal(&v); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestReadLitematica_regions(t *testing.T) {
+	stone, dirt := newPaletteEntry(block.ToStateID[block.Stone{}]), newPaletteEntry(block.ToStateID[block.Dirt{}])
+	air := newPaletteEntry(block.ToStateID[block.Air{}])
+	f := litematic{
+		MinecraftDataVersion: save.DataVersion,
+		Version:              6,
+		Regions: map[string]litematicRegion{
+			// The regions with the negative size extend from the position to the negative direction
+			"a": {
+				Position:          litematicVec{0, 0, 0},
+				Size:              litematicVec{-2, 1, 1},
+				BlockStatePalette: []paletteEntry{air, stone},
+				BlockStates:       []uint64{0b01_01},
+			},
+			"b": {
+				Position:          litematicVec{1, 1, 0},
+				Size:              litematicVec{1, 1, 1},
+				BlockStatePalette: []paletteEntry{air, dirt},
+				BlockStates:       []uint64{0b01},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := encodeGzip(&buf, f, ""); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ReadLitematica(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Size != [3]int{3, 2, 1} {
+		t.Fatalf("size: %v", s.Size)
+	}
+	want := []block.StateID{
+		block.ToStateID[block.Stone{}], block.ToStateID[block.Stone{}], Void,
+		Void, Void, block.ToStateID[block.Dirt{}],
+	}
+	if !reflect.DeepEqual(s.Blocks, want) {
+		t.Errorf("blocks: got %v, want %v", s.Blocks, want)
+	}
+}
+
+func TestSchematic_Transformed(t *testing.T) {
+	s := testSchematic(t)
+
+	r := s.Transformed(Transform{Rotation: block.RotationClockwise90})
+	if r.Size != [3]int{4, 2, 3} {
+		t.Fatalf("size: %v", r.Size)
+	}
+	// Rotating clockwise, the north-west corner goes to the north-east
+	if got := StateString(r.Block(3, 1, 1)); got != "minecraft:oak_stairs[facing=east,half=bottom,shape=straight,waterlogged=false]" {
+		t.Errorf("rotated stairs: %s", got)
+	}
+	if got := StateString(r.Block(0, 1, 2)); got != "minecraft:oak_log[axis=z]" {
+		t.Errorf("rotated log: %s", got)
+	}
+	if r.Block(1, 1, 1) != Void {
+		t.Errorf("Void moved")
+	}
+	if pos := r.BlockEntities[0].Pos; pos != [3]int32{3, 1, 0} {
+		t.Errorf("block entity position: %v", pos)
+	}
+	if pos := r.Entities[0].Pos; pos != [3]float64{0.5, 1, 1.5} {
+		t.Errorf("entity position: %v", pos)
+	}
+	var rotation [2]float32
+	if err := r.Entities[0].Data["Rotation"].Unmarshal(&rotation); err != nil || rotation[0] != 180 {
+		t.Errorf("entity rotation: %v, %v", rotation, err)
+	}
+
+	m := s.Transformed(Transform{Mirror: MirrorLeftRight})
+	if got := StateString(m.Block(1, 1, 3)); got != "minecraft:oak_stairs[facing=south,half=bottom,shape=straight,waterlogged=false]" {
+		t.Errorf("mirrored stairs: %s", got)
+	}
+
+	// Rotating 4 times or mirroring twice is the same as the original
+	for _, ts := range [][]Transform{
+		{{Rotation: block.RotationClockwise90}, {Rotation: block.RotationClockwise90}, {Rotation: block.RotationClockwise90}, {Rotation: block.RotationClockwise90}},
+		{{Rotation: block.RotationCounterclockwise90, Mirror: MirrorFrontBack}, {Rotation: block.RotationClockwise90}, {Mirror: MirrorFrontBack}},
+		{{Mirror: MirrorLeftRight}, {Mirror: MirrorLeftRight}},
+	} {
+		got := s
+		for _, v := range ts {
+			got = got.Transformed(v)
+		}
+		if !reflect.DeepEqual(got.Blocks, s.Blocks) || !reflect.DeepEqual(got.BlockEntities, s.BlockEntities) {
+			t.Errorf("%v is not identity", ts)
+		}
+	}
+}
+
+func TestSchematic_Transformed_hanging(t *testing.T) {
+	s := readFixture(t, "structure.nbt", ReadStructure)
+	r := s.Transformed(Transform{Rotation: block.RotationClockwise90})
+	// The wall is on the east of the entities after rotated
+	checkHanging(t, r.Entities, map[string][3]int32{
+		"minecraft:item_frame": {0, 1, 2},
+		"minecraft:painting":   {0, 1, 1},
+	})
+	for _, e := range r.Entities {
+		switch e.ID {
+		case "minecraft:item_frame":
+			if got := facing(t, e, "Facing"); got != 4 {
+				t.Errorf("item frame facing: got %d, want west", got)
+			}
+		case "minecraft:painting":
+			if got := facing(t, e, "facing"); got != 1 {
+				t.Errorf("painting facing: got %d, want west", got)
+			}
+		}
+	}
+
+	m := s.Transformed(Transform{Mirror: MirrorLeftRight})
+	checkHanging(t, m.Entities, map[string][3]int32{
+		"minecraft:item_frame": {2, 1, 0},
+		"minecraft:painting":   {1, 1, 0},
+	})
+	for _, e := range m.Entities {
+		if e.ID == "minecraft:item_frame" && facing(t, e, "Facing") != 2 {
+			t.Errorf("mirrored item frame facing: %d", facing(t, e, "Facing"))
+		}
+	}
+}
+
+func TestSchematic_PasteChunk(t *testing.T) {
+	s := testSchematic(t)
+	c := level.EmptyChunk(24)
+	c.BlockEntity = []level.BlockEntity{{XZ: 0x00, Y: -63, Type: block.EntityTypes["minecraft:furnace"]}}
+	// Across the chunk border, the x of the schematic is from -2 to 0
+	at := [3]int{-2, -64, 14}
+	if err := s.PasteChunk(c, level.ChunkPos{0, 0}, -64, at); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Sections[0].GetBlock(0<<8 | 14<<4 | 0); got != block.ToStateID[block.Stone{}] {
+		t.Errorf("block at (0, -64, 14): %v", got)
+	}
+	if got := c.Sections[0].BlockCount; got != 2 {
+		t.Errorf("block count: %d", got)
+	}
+	if len(c.BlockEntity) != 1 {
+		t.Errorf("block entities: %v", c.BlockEntity)
+	}
+
+	// The chest is at x=-2, out of the chunk
+	e := New(3, 2, 4)
+	if err := e.ExtractChunk(c, level.ChunkPos{0, 0}, -64, at); err != nil {
+		t.Fatal(err)
+	}
+	if e.Block(2, 0, 0) != block.ToStateID[block.Stone{}] || e.Block(2, 0, 1) != block.ToStateID[block.Stone{}] {
+		t.Errorf("extracted blocks: %v", e.Blocks)
+	}
+	if len(e.BlockEntities) != 0 {
+		t.Errorf("extracted block entities: %v", e.BlockEntities)
+	}
+}
+
+func TestSchematic_PasteWorld(t *testing.T) {
+	w, err := save.OpenWorld(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for _, pos := range [][2]int32{{-1, 0}, {0, 0}} {
+		c := save.Chunk{DataVersion: save.DataVersion, XPos: pos[0], YPos: -4, ZPos: pos[1]}
+		if err := level.ChunkToSave(level.EmptyChunk(24), &c); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.SaveChunk(save.Overworld, &c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := testSchematic(t).Transformed(Transform{Rotation: block.RotationClockwise180})
+	at := [3]int{-1, 64, 3}
+	if err := s.PasteWorld(w, save.Overworld, at); err != nil {
+		t.Fatal(err)
+	}
+	c, err := w.LoadChunk(save.Overworld, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.IsLightOn != 0 || len(c.Heightmaps) != 0 {
+		t.Errorf("light and heightmaps are not reset")
+	}
+	if len(c.BlockEntities) != 1 {
+		t.Errorf("block entities: %v", c.BlockEntities)
+	}
+	ec, err := w.LoadEntities(save.Overworld, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ec.Entities) != 1 || ec.Entities[0].ID != "minecraft:armor_stand" || ec.Entities[0].Pos != [3]float64{0.5, 65, 3.5} {
+		t.Errorf("entities: %+v", ec.Entities)
+	}
+
+	got, err := ExtractWorld(w, save.Overworld, at, s.Size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The Void is not pasted, and is air in the world
+	s.SetBlock(1, 1, 1, block.ToStateID[block.Air{}])
+	if !reflect.DeepEqual(got.Blocks, s.Blocks) {
+		t.Errorf("blocks: got %v, want %v", got.Blocks, s.Blocks)
+	}
+	if len(got.BlockEntities) != 1 || got.BlockEntities[0].ID != "minecraft:chest" || got.BlockEntities[0].Pos != s.BlockEntities[0].Pos {
+		t.Errorf("block entities: got %v, want %v", got.BlockEntities, s.BlockEntities)
+	}
+	if len(got.Entities) != 1 || got.Entities[0].Pos != s.Entities[0].Pos {
+		t.Errorf("entities: got %v, want %v", got.Entities, s.Entities)
+	}
+
+	// The hanging entities are moved to and from the world
+	h := readFixture(t, "test.litematic", ReadLitematica)
+	if err := h.PasteWorld(w, save.Overworld, at); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := w.LoadEntities(save.Overworld, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frames []save.Entity
+	for _, e := range raw.Entities {
+		if e.ID == "minecraft:item_frame" {
+			frames = append(frames, e)
+		}
+	}
+	if len(frames) != 1 {
+		t.Fatalf("item frames: %v", frames)
+	}
+	var tile struct{ TileX, TileY, TileZ int32 }
+	if data, err := rawNBT(frames[0]); err != nil {
+		t.Fatal(err)
+	} else if err := data.Unmarshal(&tile); err != nil {
+		t.Fatal(err)
+	}
+	if tile != (struct{ TileX, TileY, TileZ int32 }{1, 65, 4}) {
+		t.Errorf("tile position in the world: %v", tile)
+	}
+	got, err = ExtractWorld(w, save.Overworld, at, h.Size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hanging []Entity
+	for _, e := range got.Entities {
+		if e.ID != "minecraft:armor_stand" {
+			hanging = append(hanging, e)
+		}
+	}
+	checkHanging(t, hanging, map[string][3]int32{
+		"minecraft:item_frame": {2, 1, 1},
+		"minecraft:painting":   {1, 1, 1},
+	})
+
+	// Out of the generated chunks
+	if got, err := ExtractWorld(w, save.Overworld, [3]int{100, 0, 0}, [3]int{1, 1, 1}); err != nil || got.Blocks[0] != Void {
+		t.Errorf("extract a missing chunk: %v, %v", got, err)
+	}
+}
+
+func TestRead_invalidSize(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		file any
+		read func(r io.Reader) (*Schematic, error)
+	}{
+		{"negative structure", structure{Size: [3]int32{-1, 2, 3}}, ReadStructure},
+		{"large structure", structure{Size: [3]int32{1 << 30, 1 << 30, 1}}, ReadStructure},
+		// The sizes of the sponge schematics are unsigned
+		{"large sponge", spongeV2{Version: 2, Width: -1, Height: -1, Length: -1}, ReadSponge},
+		{"large litematica", litematic{Regions: map[string]litematicRegion{
+			"a": {Size: litematicVec{math.MinInt32, 1, 1}},
+			"b": {Position: litematicVec{math.MaxInt32, 0, 0}, Size: litematicVec{1, 1, 1}},
+		}}, ReadLitematica},
+	} {
+		var buf bytes.Buffer
+		if err := encodeGzip(&buf, tt.file, ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tt.read(&buf); err == nil || !strings.Contains(err.Error(), "size") {
+			t.Errorf("%s: got error %v", tt.name, err)
+		}
+	}
+}
+
+func TestReadLitematica_blockEntityOutOfRegion(t *testing.T) {
+	f := litematic{
+		Regions: map[string]litematicRegion{
+			"a": {
+				Size:              litematicVec{1, 1, 1},
+				BlockStatePalette: []paletteEntry{newPaletteEntry(block.ToStateID[block.Air{}])},
+				BlockStates:       []uint64{0},
+				TileEntities:      []litematicBlockEntity{{X: 5, Y: 5, Z: 5}},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := encodeGzip(&buf, f, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadLitematica(&buf); err == nil {
+		t.Error("no error")
+	}
+}
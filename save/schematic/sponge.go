@@ -0,0 +1,233 @@
+package schematic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// The Sponge schematic format, see https://github.com/SpongePowered/Schematic-Specification
+type spongeV2 struct {
+	Version     int32
+	DataVersion int32
+	Metadata    spongeMetadata `nbt:"Metadata,omitempty"`
+	Width       int16
+	Height      int16
+	Length      int16
+	Offset      [3]int32
+
+	// Version 2
+	PaletteMax    int32                `nbt:"PaletteMax,omitempty"`
+	Palette       map[string]int32     `nbt:"Palette,omitempty"`
+	BlockData     []byte               `nbt:"BlockData,omitempty"`
+	BlockEntities []spongeBlockEntity2 `nbt:"BlockEntities,omitempty"`
+
+	// Version 3
+	Blocks *spongeBlocks `nbt:"Blocks,omitempty"`
+
+	Entities []spongeEntity `nbt:"Entities,omitempty"`
+}
+
+type spongeMetadata struct {
+	Name   string `nbt:"Name,omitempty"`
+	Author string `nbt:"Author,omitempty"`
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+type spongeBlocks struct {
+	Palette       map[string]int32
+	Data          []byte
+	BlockEntities []spongeBlockEntity3 `nbt:"BlockEntities,omitempty"`
+}
+
+type spongeBlockEntity2 struct {
+	Pos  [3]int32
+	ID   string                    `nbt:"Id"`
+	Data map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+type spongeBlockEntity3 struct {
+	Pos  [3]int32
+	ID   string                    `nbt:"Id"`
+	Data map[string]nbt.RawMessage `nbt:"Data,omitempty"`
+}
+
+type spongeEntity struct {
+	Pos  [3]float64
+	ID   string                    `nbt:"Id"`
+	Data map[string]nbt.RawMessage `nbt:"Data,omitempty"` // Version 3
+
+	Rest map[string]nbt.RawMessage `nbt:",rest"` // Version 2
+}
+
+// ReadSponge reads the Sponge schematic (.schem) of version 2 or 3.
+// The biomes are ignored.
+func ReadSponge(r io.Reader) (*Schematic, error) {
+	var root map[string]nbt.RawMessage
+	if err := decodeGzip(r, &root); err != nil {
+		return nil, err
+	}
+	var raw nbt.RawMessage
+	if v3, ok := root["Schematic"]; ok {
+		// Version 3 is in a compound named "Schematic"
+		raw = v3
+	} else {
+		var err error
+		if raw, err = rawNBT(root); err != nil {
+			return nil, err
+		}
+	}
+	var f spongeV2
+	if err := raw.Unmarshal(&f); err != nil {
+		return nil, err
+	}
+
+	// The sizes are unsigned
+	width, height, length := int(uint16(f.Width)), int(uint16(f.Height)), int(uint16(f.Length))
+	if err := checkSize(width, height, length); err != nil {
+		return nil, fmt.Errorf("sponge schematic: %w", err)
+	}
+	s := New(width, height, length)
+	s.DataVersion = f.DataVersion
+	s.Name, s.Author = f.Metadata.Name, f.Metadata.Author
+	s.Offset = f.Offset
+
+	var palette map[string]int32
+	var data []byte
+	switch f.Version {
+	case 1, 2:
+		palette, data = f.Palette, f.BlockData
+		for _, v := range f.BlockEntities {
+			s.BlockEntities = append(s.BlockEntities, BlockEntity{Pos: v.Pos, ID: v.ID, Data: v.Data})
+		}
+		for _, v := range f.Entities {
+			s.Entities = append(s.Entities, Entity{Pos: v.Pos, ID: v.ID, Data: v.Rest})
+		}
+	case 3:
+		if f.Blocks != nil {
+			palette, data = f.Blocks.Palette, f.Blocks.Data
+			for _, v := range f.Blocks.BlockEntities {
+				s.BlockEntities = append(s.BlockEntities, BlockEntity{Pos: v.Pos, ID: v.ID, Data: v.Data})
+			}
+		}
+		for _, v := range f.Entities {
+			s.Entities = append(s.Entities, Entity{Pos: v.Pos, ID: v.ID, Data: v.Data})
+		}
+	default:
+		return nil, errors.New("unsupported sponge schematic version: " + strconv.Itoa(int(f.Version)))
+	}
+	corner := f.corner()
+	for i := range s.Entities {
+		var err error
+		if s.Entities[i].Data, err = moveTile(s.Entities[i].Data, [3]int{-corner[0], -corner[1], -corner[2]}); err != nil {
+			return nil, err
+		}
+	}
+
+	states := make(map[int32]block.StateID, len(palette))
+	for str, i := range palette {
+		state, err := ParseState(str)
+		if err != nil {
+			return nil, err
+		}
+		states[i] = state
+	}
+	if len(palette) == 0 {
+		return s, nil // Only entities
+	}
+	for i := range s.Blocks {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("sponge schematic: block data too short")
+		}
+		data = data[n:]
+		state, ok := states[int32(v)]
+		if !ok {
+			return nil, fmt.Errorf("sponge schematic: palette index %d out of range", v)
+		}
+		s.Blocks[i] = state
+	}
+	return s, nil
+}
+
+// corner returns the min corner of the schematic where it's copied, if it's known.
+// WorldEdit keeps the tile positions of the hanging entities there,
+// which is the Offset in version 2, or the Offset relative to the Origin in the WorldEdit metadata in version 3.
+func (f *spongeV2) corner() (pos [3]int) {
+	var worldEdit struct{ Origin [3]int32 }
+	if raw, ok := f.Metadata.Unknown["WorldEdit"]; ok && f.Version == 3 {
+		_ = raw.Unmarshal(&worldEdit) // The origin is (0, 0, 0) if it's invalid
+	}
+	for i := range pos {
+		pos[i] = int(f.Offset[i])
+		if f.Version == 3 {
+			pos[i] += int(worldEdit.Origin[i])
+		}
+	}
+	return
+}
+
+// WriteSponge writes the schematic in the Sponge schematic format of the version, 2 or 3.
+func WriteSponge(w io.Writer, s *Schematic, version int) error {
+	for _, v := range s.Size {
+		if v > 0xFFFF {
+			return errors.New("sponge schematic: size too large")
+		}
+	}
+	states, indices := palette(s.Blocks)
+	palette := make(map[string]int32, len(states))
+	for i, v := range states {
+		palette[StateString(v)] = int32(i)
+	}
+	data := make([]byte, 0, len(indices))
+	for _, v := range indices {
+		data = binary.AppendUvarint(data, uint64(v))
+	}
+
+	f := spongeV2{
+		Version:     int32(version),
+		DataVersion: s.DataVersion,
+		Metadata:    spongeMetadata{Name: s.Name, Author: s.Author},
+		Width:       int16(s.Size[0]),
+		Height:      int16(s.Size[1]),
+		Length:      int16(s.Size[2]),
+		Offset:      s.Offset,
+	}
+	switch version {
+	case 2:
+		f.PaletteMax = int32(len(palette))
+		f.Palette, f.BlockData = palette, data
+		for _, v := range s.BlockEntities {
+			f.BlockEntities = append(f.BlockEntities, spongeBlockEntity2{Pos: v.Pos, ID: v.ID, Data: v.Data})
+		}
+		for _, v := range s.Entities {
+			data, err := moveTile(v.Data, f.corner())
+			if err != nil {
+				return err
+			}
+			f.Entities = append(f.Entities, spongeEntity{Pos: v.Pos, ID: v.ID, Rest: data})
+		}
+		return encodeGzip(w, f, "Schematic")
+	case 3:
+		f.Blocks = &spongeBlocks{Palette: palette, Data: data}
+		for _, v := range s.BlockEntities {
+			f.Blocks.BlockEntities = append(f.Blocks.BlockEntities, spongeBlockEntity3{Pos: v.Pos, ID: v.ID, Data: v.Data})
+		}
+		for _, v := range s.Entities {
+			data, err := moveTile(v.Data, f.corner())
+			if err != nil {
+				return err
+			}
+			f.Entities = append(f.Entities, spongeEntity{Pos: v.Pos, ID: v.ID, Data: data})
+		}
+		return encodeGzip(w, struct{ Schematic spongeV2 }{f}, "")
+	default:
+		return errors.New("unsupported sponge schematic version: " + strconv.Itoa(version))
+	}
+}
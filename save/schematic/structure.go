@@ -0,0 +1,186 @@
+package schematic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// The file saved by the structure blocks, in the generated/<namespace>/structures folder of the world.
+type structure struct {
+	DataVersion int32
+	Size        [3]int32          `nbt:"size,list"`
+	Palette     []paletteEntry    `nbt:"palette,omitempty"`
+	Palettes    [][]paletteEntry  `nbt:"palettes,omitempty"` // The random variants, like the shipwrecks
+	Blocks      []structureBlock  `nbt:"blocks"`
+	Entities    []structureEntity `nbt:"entities"`
+}
+
+type structureBlock struct {
+	State int32                     `nbt:"state"`
+	Pos   [3]int32                  `nbt:"pos,list"`
+	NBT   map[string]nbt.RawMessage `nbt:"nbt,omitempty"` // The block entity, with the id
+}
+
+type structureEntity struct {
+	Pos      [3]float64                `nbt:"pos"`
+	BlockPos [3]int32                  `nbt:"blockPos,list"`
+	NBT      map[string]nbt.RawMessage `nbt:"nbt"` // With the id
+}
+
+// ReadStructure reads the structure file (.nbt) saved by the structure blocks.
+// The blocks not in the file, which are the structure voids, are read as Void.
+// If the structure has many palettes, the first one is used.
+func ReadStructure(r io.Reader) (*Schematic, error) {
+	var f structure
+	if err := decodeGzip(r, &f); err != nil {
+		return nil, err
+	}
+	palette := f.Palette
+	if palette == nil && len(f.Palettes) > 0 {
+		palette = f.Palettes[0]
+	}
+	states := make([]block.StateID, len(palette))
+	for i, v := range palette {
+		var err error
+		if states[i], err = v.state(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkSize(int(f.Size[0]), int(f.Size[1]), int(f.Size[2])); err != nil {
+		return nil, fmt.Errorf("structure: %w", err)
+	}
+	s := New(int(f.Size[0]), int(f.Size[1]), int(f.Size[2]))
+	s.DataVersion = f.DataVersion
+	for i := range s.Blocks {
+		s.Blocks[i] = Void
+	}
+	for _, v := range f.Blocks {
+		x, y, z := int(v.Pos[0]), int(v.Pos[1]), int(v.Pos[2])
+		if !s.Contains(x, y, z) {
+			return nil, fmt.Errorf("structure: block %v out of bounds", v.Pos)
+		}
+		if v.State < 0 || int(v.State) >= len(states) {
+			return nil, fmt.Errorf("structure: palette index %d out of range", v.State)
+		}
+		s.SetBlock(x, y, z, states[v.State])
+		if v.NBT != nil {
+			be := BlockEntity{Pos: v.Pos, Data: v.NBT}
+			if err := popString(be.Data, "id", &be.ID); err != nil {
+				return nil, err
+			}
+			s.BlockEntities = append(s.BlockEntities, be)
+		}
+	}
+	for _, v := range f.Entities {
+		e := Entity{Pos: v.Pos, Data: v.NBT}
+		if err := popString(e.Data, "id", &e.ID); err != nil {
+			return nil, err
+		}
+		delete(e.Data, "Pos") // The position when it's saved
+		if _, ok := tilePos(e.Data); ok {
+			// The tile position is also the one when it's saved, and the relative one is in the blockPos
+			var err error
+			if e.Data, err = withTilePos(e.Data, v.BlockPos); err != nil {
+				return nil, err
+			}
+		}
+		s.Entities = append(s.Entities, e)
+	}
+	return s, nil
+}
+
+// WriteStructure writes the schematic as a structure file (.nbt), which can be loaded by the structure blocks.
+// The Void blocks are not written.
+func WriteStructure(w io.Writer, s *Schematic) error {
+	f := structure{
+		DataVersion: s.DataVersion,
+		Size:        [3]int32{int32(s.Size[0]), int32(s.Size[1]), int32(s.Size[2])},
+		Blocks:      []structureBlock{},
+		Entities:    []structureEntity{},
+	}
+	blockEntities := make(map[[3]int32]BlockEntity, len(s.BlockEntities))
+	for _, v := range s.BlockEntities {
+		blockEntities[v.Pos] = v
+	}
+
+	states, indices := palette(s.Blocks)
+	f.Palette = make([]paletteEntry, len(states))
+	for i, v := range states {
+		f.Palette[i] = newPaletteEntry(v)
+	}
+	for y := 0; y < s.Size[1]; y++ {
+		for z := 0; z < s.Size[2]; z++ {
+			for x := 0; x < s.Size[0]; x++ {
+				i := s.Index(x, y, z)
+				if s.Blocks[i] == Void {
+					continue
+				}
+				b := structureBlock{State: int32(indices[i]), Pos: [3]int32{int32(x), int32(y), int32(z)}}
+				if be, ok := blockEntities[b.Pos]; ok {
+					var err error
+					if b.NBT, err = withString(be.Data, "id", be.ID); err != nil {
+						return err
+					}
+				}
+				f.Blocks = append(f.Blocks, b)
+			}
+		}
+	}
+	for _, v := range s.Entities {
+		data, err := withString(v.Data, "id", v.ID)
+		if err != nil {
+			return err
+		}
+		if data["Pos"], err = rawNBT(v.Pos); err != nil {
+			return err
+		}
+		e := structureEntity{
+			Pos: v.Pos,
+			BlockPos: [3]int32{
+				int32(math.Floor(v.Pos[0])),
+				int32(math.Floor(v.Pos[1])),
+				int32(math.Floor(v.Pos[2])),
+			},
+			NBT: data,
+		}
+		if pos, ok := tilePos(data); ok {
+			// The vanilla uses the tile position of the paintings as the blockPos
+			e.BlockPos = pos
+		}
+		f.Entities = append(f.Entities, e)
+	}
+	return encodeGzip(w, f, "")
+}
+
+// popString removes the string tag from the compound, and decodes it into v.
+func popString(data map[string]nbt.RawMessage, name string, v *string) error {
+	raw, ok := data[name]
+	if !ok {
+		return nil
+	}
+	delete(data, name)
+	if raw.Type != nbt.TagString {
+		return errors.New("tag " + name + " is not a string")
+	}
+	return raw.Unmarshal(v)
+}
+
+// withString returns a copy of the compound, with the string tag added.
+func withString(data map[string]nbt.RawMessage, name, value string) (map[string]nbt.RawMessage, error) {
+	raw, err := rawNBT(value)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]nbt.RawMessage, len(data)+1)
+	for k, v := range data {
+		m[k] = v
+	}
+	m[name] = raw
+	return m, nil
+}
@@ -0,0 +1,228 @@
+package schematic
+
+import (
+	"github.com/Tnze/go-mc/level/block"
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// Mirror flips the blocks across a plane. The names follow the vanilla structure blocks.
+type Mirror int
+
+const (
+	MirrorNone      Mirror = iota
+	MirrorLeftRight        // Flips the Z axis, north becomes south
+	MirrorFrontBack        // Flips the X axis, east becomes west
+)
+
+// Transform is applied to the schematic when pasting, the mirror is applied before the rotation.
+type Transform struct {
+	Rotation block.Rotation
+	Mirror   Mirror
+}
+
+// Transformed returns a copy of the schematic, with the blocks, block entities and entities
+// moved and turned by the transform.
+// The box of the result still starts at (0, 0, 0), and the size of X and Z are swapped if it's rotated by 90 or 270 degrees.
+//
+// The block states are turned by block.StateID.Rotate and Mirror, and the yaw of the entities is turned,
+// so are the tile positions and facing of the hanging entities.
+// The other data of the block entities and entities are kept as is.
+func (s *Schematic) Transformed(t Transform) *Schematic {
+	rot := t.Rotation & 3
+	size := s.Size
+	if rot == block.RotationClockwise90 || rot == block.RotationCounterclockwise90 {
+		size[0], size[2] = size[2], size[0]
+	}
+	dst := New(size[0], size[1], size[2])
+	dst.DataVersion = s.DataVersion
+	dst.Name, dst.Author = s.Name, s.Author
+	dst.Offset = s.Offset
+
+	states := make(map[block.StateID]block.StateID)
+	for y := 0; y < s.Size[1]; y++ {
+		for z := 0; z < s.Size[2]; z++ {
+			for x := 0; x < s.Size[0]; x++ {
+				state := s.Block(x, y, z)
+				newState, ok := states[state]
+				if !ok {
+					newState = t.state(state)
+					states[state] = newState
+				}
+				tx, tz := t.blockPos(x, z, s.Size)
+				dst.SetBlock(tx, y, tz, newState)
+			}
+		}
+	}
+	for _, v := range s.BlockEntities {
+		x, z := t.blockPos(int(v.Pos[0]), int(v.Pos[2]), s.Size)
+		v.Pos = [3]int32{int32(x), v.Pos[1], int32(z)}
+		dst.BlockEntities = append(dst.BlockEntities, v)
+	}
+	for _, v := range s.Entities {
+		v.Pos[0], v.Pos[2] = t.pos(v.Pos[0], v.Pos[2], float64(s.Size[0]), float64(s.Size[2]))
+		v.Data = t.entityData(v.Data, s.Size)
+		dst.Entities = append(dst.Entities, v)
+	}
+	return dst
+}
+
+func (t Transform) blockPos(x, z int, size [3]int) (int, int) {
+	tx, tz := t.pos(float64(x), float64(z), float64(size[0]-1), float64(size[2]-1))
+	return int(tx), int(tz)
+}
+
+// pos transforms the position in the box from 0 to (sx, sz).
+func (t Transform) pos(x, z, sx, sz float64) (float64, float64) {
+	switch t.Mirror {
+	case MirrorLeftRight:
+		z = sz - z
+	case MirrorFrontBack:
+		x = sx - x
+	}
+	switch t.Rotation & 3 {
+	case block.RotationClockwise90:
+		return sz - z, x
+	case block.RotationClockwise180:
+		return sx - x, sz - z
+	case block.RotationCounterclockwise90:
+		return z, sx - x
+	}
+	return x, z
+}
+
+// yaw transforms the yaw of the entities in degrees.
+func (t Transform) yaw(yaw float32) float32 {
+	switch t.Mirror {
+	case MirrorLeftRight:
+		yaw = 180 - yaw
+	case MirrorFrontBack:
+		yaw = -yaw
+	}
+	return yaw + 90*float32(t.Rotation&3)
+}
+
+// direction transforms the horizontal direction, which is the 2D data value of the vanilla Direction:
+// 0 for south, 1 for west, 2 for north and 3 for east.
+func (t Transform) direction(d int8) int8 {
+	switch t.Mirror {
+	case MirrorLeftRight:
+		if d == 0 || d == 2 {
+			d = 2 - d
+		}
+	case MirrorFrontBack:
+		if d == 1 || d == 3 {
+			d = 4 - d
+		}
+	}
+	return (d + int8(t.Rotation&3)) % 4
+}
+
+// The 2D data values of the directions indexed by the 3D ones, which are 0 for down, 1 for up, then north, south, west and east.
+var direction2D = [...]int8{-1, -1, 2, 0, 1, 3}
+
+// direction3D transforms the direction in the 3D data value, which is unchanged if it's up or down.
+func (t Transform) direction3D(d int8) int8 {
+	if d < 2 || d >= 6 {
+		return d
+	}
+	d = t.direction(direction2D[d])
+	for i, v := range direction2D {
+		if v == d {
+			return int8(i)
+		}
+	}
+	return d
+}
+
+// entityData transforms the yaw, and the tile position and facing of the hanging entities, in the box of the size.
+func (t Transform) entityData(data map[string]nbt.RawMessage, size [3]int) map[string]nbt.RawMessage {
+	var m map[string]nbt.RawMessage
+	set := func(name string, v any) {
+		raw, err := rawNBT(v)
+		if err != nil {
+			return
+		}
+		if m == nil {
+			m = make(map[string]nbt.RawMessage, len(data))
+			for k, v := range data {
+				m[k] = v
+			}
+		}
+		m[name] = raw
+	}
+	// The invalid data are left unchanged
+	var rotation [2]float32
+	if raw, ok := data["Rotation"]; ok && raw.Unmarshal(&rotation) == nil {
+		rotation[0] = t.yaw(rotation[0])
+		set("Rotation", rotation)
+	}
+	if pos, ok := tilePos(data); ok {
+		x, z := t.blockPos(int(pos[0]), int(pos[2]), size)
+		set("TileX", int32(x))
+		set("TileZ", int32(z))
+	}
+	var facing int8
+	// The item frames
+	if raw, ok := data["Facing"]; ok && raw.Type == nbt.TagByte && raw.Unmarshal(&facing) == nil {
+		set("Facing", t.direction3D(facing))
+	}
+	// The paintings
+	if raw, ok := data["facing"]; ok && raw.Type == nbt.TagByte && raw.Unmarshal(&facing) == nil && facing >= 0 && facing < 4 {
+		set("facing", t.direction(facing))
+	}
+	if m == nil {
+		return data
+	}
+	return m
+}
+
+// tilePos returns the TileX, TileY and TileZ of the hanging entities.
+func tilePos(data map[string]nbt.RawMessage) (pos [3]int32, ok bool) {
+	for i, name := range [...]string{"TileX", "TileY", "TileZ"} {
+		raw, ok := data[name]
+		if !ok || raw.Type != nbt.TagInt || raw.Unmarshal(&pos[i]) != nil {
+			return pos, false
+		}
+	}
+	return pos, true
+}
+
+// withTilePos returns a copy of the compound, with the TileX, TileY and TileZ set.
+func withTilePos(data map[string]nbt.RawMessage, pos [3]int32) (map[string]nbt.RawMessage, error) {
+	m := make(map[string]nbt.RawMessage, len(data))
+	for k, v := range data {
+		m[k] = v
+	}
+	for i, name := range [...]string{"TileX", "TileY", "TileZ"} {
+		raw, err := rawNBT(pos[i])
+		if err != nil {
+			return nil, err
+		}
+		m[name] = raw
+	}
+	return m, nil
+}
+
+// moveTile returns the data with the tile position moved by d if it's a hanging entity,
+// otherwise the data is returned as is.
+func moveTile(data map[string]nbt.RawMessage, d [3]int) (map[string]nbt.RawMessage, error) {
+	pos, ok := tilePos(data)
+	if !ok {
+		return data, nil
+	}
+	for i := range pos {
+		pos[i] += int32(d[i])
+	}
+	return withTilePos(data, pos)
+}
+
+// state transforms the block state.
+func (t Transform) state(state block.StateID) block.StateID {
+	switch t.Mirror {
+	case MirrorLeftRight:
+		state = state.Mirror(block.Z)
+	case MirrorFrontBack:
+		state = state.Mirror(block.X)
+	}
+	return state.Rotate(t.Rotation)
+}
@@ -0,0 +1,323 @@
+package save
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+type upgrade struct {
+	version  int32             // the chunks saved before this version are upgraded
+	blocks   map[string]string // the renamed blocks
+	biomes   map[string]string // the renamed biomes
+	statuses map[string]string // the renamed chunk statuses, without the namespace
+	// properties changes the properties of the block in place, and returns the new name.
+	properties func(name string, props map[string]string) string
+}
+
+// Upgrade converts the chunk saved by an older version of the game to the layout of the DataVersion,
+// which makes it readable by level.ChunkFromSave. Chunks since 1.13 are supported.
+//
+// The chunks of 1.13 to 1.17 are moved out of the Level compound,
+// with their block states repacked and biomes converted into the 3D palettes.
+// They still start at section 0 and have 16 sections,
+// the terrain below y=0 isn't generated like the game does.
+// Then the blocks, biomes and statuses renamed since the version of the chunk are updated.
+//
+// The data of the entities and block entities are kept as is.
+func (c *Chunk) Upgrade() error {
+	if c.DataVersion >= DataVersion {
+		return nil
+	}
+	if c.DataVersion < dataVersion1_13 {
+		return errors.New("upgrade chunk: unsupported data version " + strconv.Itoa(int(c.DataVersion)))
+	}
+	if c.DataVersion < dataVersion1_18Layout {
+		if err := c.upgradeLayout(); err != nil {
+			return err
+		}
+	}
+	for _, u := range upgrades {
+		if c.DataVersion >= u.version {
+			continue
+		}
+		if err := c.rename(u); err != nil {
+			return err
+		}
+	}
+	c.DataVersion = DataVersion
+	return nil
+}
+
+func (c *Chunk) rename(u upgrade) error {
+	if newStatus, ok := u.statuses[strings.TrimPrefix(c.Status, "minecraft:")]; ok {
+		c.Status = newStatus
+	}
+	for i := range c.Sections {
+		s := &c.Sections[i]
+		for j := range s.Biomes.Palette {
+			if newName, ok := u.biomes[string(s.Biomes.Palette[j])]; ok {
+				s.Biomes.Palette[j] = BiomeState(newName)
+			}
+		}
+		for j := range s.BlockStates.Palette {
+			b := &s.BlockStates.Palette[j]
+			if newName, ok := u.blocks[b.Name]; ok {
+				b.Name = newName
+			}
+			if u.properties == nil || b.Properties.Type == nbt.TagEnd {
+				continue
+			}
+			var props map[string]string
+			if err := b.Properties.Unmarshal(&props); err != nil {
+				return err
+			}
+			b.Name = u.properties(b.Name, props)
+			var err error
+			if b.Properties, err = rawNBT(props); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// legacyLevel is the Level compound of the chunks before 1.18.
+type legacyLevel struct {
+	XPos           int32 `nbt:"xPos"`
+	ZPos           int32 `nbt:"zPos"`
+	LastUpdate     int64
+	InhabitedTime  int64
+	Status         string
+	IsLightOn      byte `nbt:"isLightOn"`
+	Sections       []legacySection
+	Biomes         []int32
+	Heightmaps     map[string][]uint64
+	CarvingMasks   map[string][]byte
+	Entities       []nbt.RawMessage // Moved to the entities/*.mca since 1.17
+	TileEntities   []nbt.RawMessage
+	TileTicks      nbt.RawMessage
+	LiquidTicks    nbt.RawMessage
+	PostProcessing nbt.RawMessage
+	Lights         []nbt.RawMessage
+	Structures     struct {
+		References nbt.RawMessage
+		Starts     nbt.RawMessage
+	}
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+type legacySection struct {
+	Y           int8
+	Palette     []BlockState
+	BlockStates []uint64
+	SkyLight    []byte
+	BlockLight  []byte
+
+	Unknown map[string]nbt.RawMessage `nbt:",rest"`
+}
+
+// upgradeLayout converts the chunks before 1.18, whose data are in the Level compound.
+func (c *Chunk) upgradeLayout() error {
+	raw, ok := c.Unknown["Level"]
+	if !ok {
+		return errors.New("upgrade chunk: Level not found")
+	}
+	var l legacyLevel
+	if err := raw.Unmarshal(&l); err != nil {
+		return err
+	}
+	delete(c.Unknown, "Level")
+	for k, v := range l.Unknown {
+		c.Unknown[k] = v
+	}
+
+	c.XPos, c.YPos, c.ZPos = l.XPos, 0, l.ZPos
+	c.LastUpdate = l.LastUpdate
+	c.InhabitedTime = l.InhabitedTime
+	c.Status = l.Status
+	c.IsLightOn = l.IsLightOn
+	c.Entities = l.Entities
+	c.BlockEntities = l.TileEntities
+	if c.BlockEntities == nil {
+		c.BlockEntities = []nbt.RawMessage{}
+	}
+	c.Lights = l.Lights
+
+	emptyList, err := rawNBT([]struct{}{})
+	if err != nil {
+		return err
+	}
+	c.BlockTicks, c.FluidTicks, c.PostProcessing = l.TileTicks, l.LiquidTicks, l.PostProcessing
+	for _, v := range []*nbt.RawMessage{&c.BlockTicks, &c.FluidTicks, &c.PostProcessing} {
+		if v.Type == nbt.TagEnd {
+			*v = emptyList
+		}
+	}
+	// The IDs of the structures aren't upgraded
+	for _, v := range []*nbt.RawMessage{&l.Structures.References, &l.Structures.Starts} {
+		if v.Type == nbt.TagEnd {
+			*v = nbt.RawMessage{Type: nbt.TagCompound, Data: []byte{nbt.TagEnd}}
+		}
+	}
+	c.Structures, err = rawNBT(struct {
+		References nbt.RawMessage `nbt:"References"`
+		Starts     nbt.RawMessage `nbt:"starts"`
+	}{l.Structures.References, l.Structures.Starts})
+	if err != nil {
+		return err
+	}
+
+	// The masks were saved by BitSet.toByteArray, and are saved by BitSet.toLongArray since 1.18
+	c.CarvingMasks = nil
+	if len(l.CarvingMasks) > 0 {
+		c.CarvingMasks = make(map[string][]uint64, len(l.CarvingMasks))
+		for k, v := range l.CarvingMasks {
+			longs := make([]uint64, (len(v)+7)/8)
+			v = append(v, make([]byte, len(longs)*8-len(v))...)
+			for i := range longs {
+				longs[i] = binary.LittleEndian.Uint64(v[i*8:])
+			}
+			c.CarvingMasks[k] = longs
+		}
+	}
+
+	padded := c.DataVersion >= dataVersionPaddedLongs
+	c.Heightmaps = make(map[string][]uint64, len(l.Heightmaps))
+	for k, v := range l.Heightmaps {
+		if !padded {
+			v = repack(v, len(v)*64/(16*16), 16*16)
+		}
+		c.Heightmaps[k] = v
+	}
+
+	biomes := legacyBiomeCells(l.Biomes)
+	// The empty sections were omitted, but all sections are required since 1.18
+	sections := make(map[int8]Section, 18)
+	for y := int8(0); y < 16; y++ {
+		sections[y] = Section{
+			Y:           y,
+			BlockStates: PaletteContainer[BlockState]{Palette: []BlockState{{Name: "minecraft:air"}}},
+		}
+	}
+	for _, v := range l.Sections {
+		s := sections[v.Y]
+		s.Y = v.Y
+		s.SkyLight, s.BlockLight = v.SkyLight, v.BlockLight
+		s.Unknown = v.Unknown
+		if len(v.Palette) > 0 {
+			s.BlockStates.Palette = v.Palette
+			s.BlockStates.Data = nil
+			if len(v.Palette) > 1 {
+				s.BlockStates.Data = v.BlockStates
+				if !padded {
+					s.BlockStates.Data = repack(v.BlockStates, len(v.BlockStates)*64/4096, 4096)
+				}
+			}
+		}
+		sections[v.Y] = s
+	}
+	c.Sections = make([]Section, 0, len(sections))
+	for y, s := range sections {
+		if y >= 0 && y < 16 {
+			s.Biomes = legacyBiomePalette(biomes[int(y)*64 : int(y+1)*64])
+		}
+		c.Sections = append(c.Sections, s)
+	}
+	sort.Slice(c.Sections, func(i, j int) bool { return c.Sections[i].Y < c.Sections[j].Y })
+	return nil
+}
+
+// legacyBiomeCells converts the numeric biomes to the 4x4x4 cells of the 256 blocks high chunk.
+// The biomes were stored per column before 1.15, which are sampled at the corner of each cell.
+func legacyBiomeCells(biomes []int32) []int32 {
+	cells := make([]int32, 16*64)
+	switch len(biomes) {
+	case 1024:
+		copy(cells, biomes)
+	case 256:
+		for y := 0; y < 64; y++ {
+			for z := 0; z < 4; z++ {
+				for x := 0; x < 4; x++ {
+					cells[y<<4|z<<2|x] = biomes[(z*4)*16+x*4]
+				}
+			}
+		}
+	default:
+		for i := range cells {
+			cells[i] = 1 // minecraft:plains
+		}
+	}
+	return cells
+}
+
+func legacyBiomePalette(cells []int32) (p PaletteContainer[BiomeState]) {
+	index := make(map[int32]uint64)
+	values := make([]uint64, len(cells))
+	for i, id := range cells {
+		v, ok := index[id]
+		if !ok {
+			v = uint64(len(p.Palette))
+			index[id] = v
+			name, ok := legacyBiomes[id]
+			if !ok {
+				name = "minecraft:plains"
+			}
+			p.Palette = append(p.Palette, BiomeState(name))
+		}
+		values[i] = v
+	}
+	if n := bits.Len(uint(len(p.Palette) - 1)); n > 0 {
+		p.Data = pack(values, n)
+	}
+	return
+}
+
+// repack converts the length values packed across the longs, which is used before 1.16,
+// into the current format where each long contains as many values as possible without spanning.
+func repack(data []uint64, bits, length int) []uint64 {
+	if bits <= 0 {
+		return data
+	}
+	mask := uint64(1)<<bits - 1
+	values := make([]uint64, length)
+	for i := range values {
+		bitIndex := i * bits
+		start, offset := bitIndex/64, bitIndex%64
+		if start >= len(data) {
+			break
+		}
+		v := data[start] >> offset
+		if offset+bits > 64 && start+1 < len(data) {
+			v |= data[start+1] << (64 - offset)
+		}
+		values[i] = v & mask
+	}
+	return pack(values, bits)
+}
+
+func pack(values []uint64, bits int) []uint64 {
+	valuesPerLong := 64 / bits
+	data := make([]uint64, (len(values)+valuesPerLong-1)/valuesPerLong)
+	for i, v := range values {
+		data[i/valuesPerLong] |= v << (i % valuesPerLong * bits)
+	}
+	return data
+}
+
+// rawNBT encodes v into a nbt.RawMessage.
+func rawNBT(v any) (raw nbt.RawMessage, err error) {
+	var buf bytes.Buffer
+	if err = nbt.NewEncoder(&buf).Encode(v, ""); err != nil {
+		return
+	}
+	_, err = nbt.NewDecoder(&buf).Decode(&raw)
+	return
+}
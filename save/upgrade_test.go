@@ -0,0 +1,226 @@
+package save
+
+import (
+	"testing"
+
+	"github.com/Tnze/go-mc/nbt"
+)
+
+// spanning packs the values across the longs like the chunks before 1.16.
+func spanning(values []uint64, bits int) []uint64 {
+	data := make([]uint64, (len(values)*bits+63)/64)
+	for i, v := range values {
+		start, offset := i*bits/64, i*bits%64
+		data[start] |= v << offset
+		if offset+bits > 64 {
+			data[start+1] |= v >> (64 - offset)
+		}
+	}
+	return data
+}
+
+// unpack returns the i-th value of the data packed in the current format.
+func unpack(data []uint64, bits, i int) uint64 {
+	valuesPerLong := 64 / bits
+	return data[i/valuesPerLong] >> (i % valuesPerLong * bits) & (1<<bits - 1)
+}
+
+func mustRawNBT(t *testing.T, v any) nbt.RawMessage {
+	raw, err := rawNBT(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestChunk_Upgrade_legacy(t *testing.T) {
+	// A chunk of 1.13.2 with 17 blocks in the palette, which uses 5 bits per block
+	palette := []BlockState{
+		{Name: "minecraft:air"},
+		{Name: "minecraft:sign", Properties: mustRawNBT(t, map[string]string{"rotation": "0", "waterlogged": "false"})},
+		{Name: "minecraft:grass_path"},
+		{Name: "minecraft:cauldron", Properties: mustRawNBT(t, map[string]string{"level": "2"})},
+		{Name: "minecraft:cauldron", Properties: mustRawNBT(t, map[string]string{"level": "0"})},
+		{Name: "minecraft:cobblestone_wall", Properties: mustRawNBT(t, map[string]string{"east": "true", "north": "false", "south": "false", "up": "true", "waterlogged": "false", "west": "true"})},
+		{Name: "minecraft:grass"},
+	}
+	for len(palette) < 17 {
+		palette = append(palette, BlockState{Name: "minecraft:stone"})
+	}
+	blocks := make([]uint64, 4096)
+	for i := range blocks {
+		blocks[i] = uint64(i % 17)
+	}
+	heightmap := make([]uint64, 256)
+	for i := range heightmap {
+		heightmap[i] = uint64(i + 100)
+	}
+	biomes := make([]int32, 256)
+	for i := range biomes {
+		biomes[i] = 3 // minecraft:mountains
+	}
+	biomes[4] = 130 // minecraft:desert_lakes, at the corner of the second cell
+
+	type section struct {
+		Y           int8
+		Palette     []BlockState `nbt:",omitempty"`
+		BlockStates []uint64     `nbt:",omitempty"`
+		SkyLight    []byte       `nbt:",omitempty"`
+	}
+	legacy := struct {
+		DataVersion int32
+		Level       struct {
+			XPos         int32 `nbt:"xPos"`
+			ZPos         int32 `nbt:"zPos"`
+			Status       string
+			Sections     []section
+			Biomes       []int32
+			Heightmaps   map[string][]uint64
+			TileEntities []nbt.RawMessage
+			TileTicks    []legacyTick
+			Structures   struct {
+				References struct{}
+				Starts     struct{}
+			}
+			ForgeCaps struct{}
+		}
+	}{DataVersion: 1631}
+	legacy.Level.XPos, legacy.Level.ZPos = -3, 5
+	legacy.Level.Status = "postprocessed"
+	legacy.Level.Sections = []section{
+		{Y: -1, SkyLight: make([]byte, 2048)},
+		{Y: 2, Palette: palette, BlockStates: spanning(blocks, 5)},
+	}
+	legacy.Level.Biomes = biomes
+	legacy.Level.Heightmaps = map[string][]uint64{"WORLD_SURFACE": spanning(heightmap, 9)}
+	legacy.Level.TileEntities = []nbt.RawMessage{mustRawNBT(t, map[string]any{"id": "minecraft:sign", "x": int32(-48), "y": int32(32), "z": int32(80)})}
+	legacy.Level.TileTicks = []legacyTick{{ID: "minecraft:water", X: -48, Y: 33, Z: 80, T: 5}}
+
+	data, err := dataNBT(legacy, CompressionNone, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Chunk
+	if err := c.Load(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Upgrade(); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.DataVersion != DataVersion || c.XPos != -3 || c.YPos != 0 || c.ZPos != 5 {
+		t.Errorf("chunk: version %d at (%d, %d, %d)", c.DataVersion, c.XPos, c.YPos, c.ZPos)
+	}
+	if c.Status != "full" {
+		t.Errorf("status: %s", c.Status)
+	}
+	if _, ok := c.Unknown["Level"]; ok {
+		t.Error("Level is kept")
+	}
+	if _, ok := c.Unknown["ForgeCaps"]; !ok {
+		t.Error("the unknown tags of Level are lost")
+	}
+	if len(c.BlockEntities) != 1 || c.BlockTicks.Type != nbt.TagList || c.FluidTicks.Type != nbt.TagList || c.Structures.Type != nbt.TagCompound {
+		t.Errorf("block entities %d, ticks %v, %v, structures %v", len(c.BlockEntities), c.BlockTicks, c.FluidTicks, c.Structures)
+	}
+
+	// The sections from -1 to 15
+	if len(c.Sections) != 17 {
+		t.Fatalf("got %d sections", len(c.Sections))
+	}
+	for i, s := range c.Sections {
+		if int(s.Y) != i-1 {
+			t.Errorf("section %d: Y = %d", i, s.Y)
+		}
+		if s.Y >= 0 && len(s.BlockStates.Palette) == 0 || s.Y >= 0 && len(s.Biomes.Palette) == 0 {
+			t.Errorf("section %d: empty palette", s.Y)
+		}
+	}
+	if c.Sections[0].SkyLight == nil {
+		t.Error("the light of section -1 is lost")
+	}
+	if s := c.Sections[1]; len(s.BlockStates.Palette) != 1 || s.BlockStates.Palette[0].Name != "minecraft:air" || s.BlockStates.Data != nil {
+		t.Errorf("the empty section: %v", s.BlockStates)
+	}
+
+	s := c.Sections[3]
+	for i := range blocks {
+		if v := unpack(s.BlockStates.Data, 5, i); v != blocks[i] {
+			t.Fatalf("block %d: got %d, want %d", i, v, blocks[i])
+		}
+	}
+	wantNames := []string{"minecraft:air", "minecraft:oak_sign", "minecraft:dirt_path", "minecraft:water_cauldron", "minecraft:cauldron", "minecraft:cobblestone_wall", "minecraft:short_grass"}
+	for i, name := range wantNames {
+		if got := s.BlockStates.Palette[i].Name; got != name {
+			t.Errorf("palette %d: got %s, want %s", i, got, name)
+		}
+	}
+	var wall, emptyCauldron map[string]string
+	if err := s.BlockStates.Palette[5].Properties.Unmarshal(&wall); err != nil {
+		t.Fatal(err)
+	}
+	if wall["east"] != "low" || wall["north"] != "none" || wall["up"] != "true" {
+		t.Errorf("wall: %v", wall)
+	}
+	if err := s.BlockStates.Palette[4].Properties.Unmarshal(&emptyCauldron); err != nil {
+		t.Fatal(err)
+	}
+	if len(emptyCauldron) != 0 {
+		t.Errorf("empty cauldron: %v", emptyCauldron)
+	}
+
+	if len(s.Biomes.Palette) != 2 || s.Biomes.Palette[0] != "minecraft:windswept_hills" || s.Biomes.Palette[1] != "minecraft:desert" {
+		t.Errorf("biomes: %v", s.Biomes.Palette)
+	}
+	for i := 0; i < 64; i++ {
+		want := uint64(0)
+		if i&15 == 1 { // x = 1, z = 0
+			want = 1
+		}
+		if v := unpack(s.Biomes.Data, 1, i); v != want {
+			t.Errorf("biome %d: got %d, want %d", i, v, want)
+		}
+	}
+
+	for i, want := range heightmap {
+		if v := unpack(c.Heightmaps["WORLD_SURFACE"], 9, i); v != want {
+			t.Fatalf("heightmap %d: got %d, want %d", i, v, want)
+		}
+	}
+
+	// Encodable in the current format
+	if _, err := c.Data(CompressionNone); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type legacyTick struct {
+	ID string `nbt:"i"`
+	X  int32  `nbt:"x"`
+	Y  int32  `nbt:"y"`
+	Z  int32  `nbt:"z"`
+	T  int32  `nbt:"t"`
+	P  int32  `nbt:"p"`
+}
+
+func TestChunk_Upgrade(t *testing.T) {
+	forEachChunk(t, "testdata/region/r.*.*.mca", func(rx, rz, x, z int, data []byte) {
+		var c Chunk
+		if err := c.Load(data); err != nil {
+			t.Fatal(err)
+		}
+		if c.DataVersion >= DataVersion {
+			t.Fatalf("the test data is not older than %d", DataVersion)
+		}
+		if err := c.Upgrade(); err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range c.Sections {
+			for _, b := range s.BlockStates.Palette {
+				if b.Name == "minecraft:grass" {
+					t.Fatalf("chunk (%d, %d): minecraft:grass not renamed", c.XPos, c.ZPos)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,355 @@
+package save
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/save/region"
+)
+
+// The IDs of the vanilla dimensions, which are not stored in the dimensions folder.
+const (
+	Overworld = "minecraft:overworld"
+	TheNether = "minecraft:the_nether"
+	TheEnd    = "minecraft:the_end"
+)
+
+// ErrLocked is returned by OpenWorld if the world is held by another program, such as a running server.
+var ErrLocked = errors.New("world is locked by another process")
+
+// World is a world folder in the vanilla layout:
+//
+//	level.dat
+//	session.lock
+//	region/, entities/, poi/, data/   the overworld
+//	DIM-1/, DIM1/                     the nether and the end, with the same layout as the overworld
+//	dimensions/<namespace>/<path>/    the custom dimensions
+//	playerdata/, stats/, advancements/
+//
+// The session.lock is held until the World is closed,
+// so that the world isn't modified by others at the same time.
+// A World is safe for concurrent use.
+type World struct {
+	dir  string
+	lock *os.File
+
+	regionsLock sync.Mutex
+	regions     map[string]*region.Cache // by the folder
+}
+
+// regionCacheSize is the number of region files kept open for each folder.
+const regionCacheSize = 64
+
+// OpenWorld opens the world folder and locks it.
+// ErrLocked is returned if the world is already opened by others.
+func OpenWorld(dir string) (*World, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New("not a directory: " + dir)
+	}
+	lock, err := lockSession(filepath.Join(dir, "session.lock"))
+	if err != nil {
+		return nil, err
+	}
+	return &World{
+		dir:     dir,
+		lock:    lock,
+		regions: make(map[string]*region.Cache),
+	}, nil
+}
+
+// Close writes the queued chunks, closes all region files and releases the session.lock.
+func (w *World) Close() error {
+	w.regionsLock.Lock()
+	defer w.regionsLock.Unlock()
+	var errs []error
+	for k, c := range w.regions {
+		errs = append(errs, c.Close())
+		delete(w.regions, k)
+	}
+	errs = append(errs, unlockSession(w.lock))
+	return errors.Join(errs...)
+}
+
+// Dir returns the path of the world folder.
+func (w *World) Dir() string { return w.dir }
+
+// DimensionDir returns the folder of the dimension, whose ID is like "minecraft:overworld".
+// The ID without a namespace is in the minecraft namespace.
+func (w *World) DimensionDir(dim string) string {
+	switch dim {
+	case Overworld:
+		return w.dir
+	case TheNether:
+		return filepath.Join(w.dir, "DIM-1")
+	case TheEnd:
+		return filepath.Join(w.dir, "DIM1")
+	}
+	namespace, path, ok := strings.Cut(dim, ":")
+	if !ok {
+		namespace, path = "minecraft", dim
+	}
+	return filepath.Join(w.dir, "dimensions", namespace, filepath.FromSlash(path))
+}
+
+// Dimensions returns the IDs of the dimensions existing in the world.
+// The overworld is always returned.
+func (w *World) Dimensions() ([]string, error) {
+	dims := []string{Overworld}
+	for _, dim := range []string{TheNether, TheEnd} {
+		if _, err := os.Stat(w.DimensionDir(dim)); err == nil {
+			dims = append(dims, dim)
+		}
+	}
+
+	// Each folder containing a region folder under dimensions/<namespace>/ is a custom dimension
+	root := filepath.Join(w.dir, "dimensions")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() || d.Name() != "region" {
+			return nil
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if namespace, name, ok := strings.Cut(filepath.ToSlash(rel), "/"); ok {
+			dims = append(dims, namespace+":"+name)
+		}
+		return filepath.SkipDir
+	})
+	return dims, err
+}
+
+// Level reads the level.dat.
+func (w *World) Level() (Level, error) {
+	return readGzipFile(filepath.Join(w.dir, "level.dat"), ReadLevel)
+}
+
+// SaveLevel writes the level.dat.
+// The previous one is kept as level.dat_old, as vanilla does.
+func (w *World) SaveLevel(data Level) error {
+	return writeFileSafe(filepath.Join(w.dir, "level.dat"), func(f io.Writer) error {
+		return WriteLevel(f, data)
+	})
+}
+
+// Players returns the UUIDs of the players who have data in the world.
+func (w *World) Players() ([]uuid.UUID, error) {
+	entries, err := os.ReadDir(filepath.Join(w.dir, "playerdata"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var players []uuid.UUID
+	for _, v := range entries {
+		name, ok := strings.CutSuffix(v.Name(), ".dat")
+		if !ok || v.IsDir() {
+			continue
+		}
+		if id, err := uuid.Parse(name); err == nil {
+			players = append(players, id)
+		}
+	}
+	return players, nil
+}
+
+// PlayerData reads the playerdata/<uuid>.dat of the player.
+func (w *World) PlayerData(id uuid.UUID) (PlayerData, error) {
+	return readGzipFile(w.PlayerDataPath(id), ReadPlayerData)
+}
+
+// SavePlayerData writes the playerdata/<uuid>.dat of the player.
+// The previous one is kept as <uuid>.dat_old, as vanilla does.
+func (w *World) SavePlayerData(id uuid.UUID, data PlayerData) error {
+	if err := os.MkdirAll(filepath.Join(w.dir, "playerdata"), 0o755); err != nil {
+		return err
+	}
+	return writeFileSafe(w.PlayerDataPath(id), func(f io.Writer) error {
+		return WritePlayerData(f, data)
+	})
+}
+
+// PlayerDataPath returns the path of the playerdata file of the player.
+func (w *World) PlayerDataPath(id uuid.UUID) string {
+	return filepath.Join(w.dir, "playerdata", id.String()+".dat")
+}
+
+// StatsPath returns the path of the statistics file of the player, which is in JSON.
+func (w *World) StatsPath(id uuid.UUID) string {
+	return filepath.Join(w.dir, "stats", id.String()+".json")
+}
+
+// AdvancementsPath returns the path of the advancements file of the player, which is in JSON.
+func (w *World) AdvancementsPath(id uuid.UUID) string {
+	return filepath.Join(w.dir, "advancements", id.String()+".json")
+}
+
+// DataPath returns the path of the saved data of the dimension, such as "raids" or "map_0".
+func (w *World) DataPath(dim, name string) string {
+	return filepath.Join(w.DimensionDir(dim), "data", name+".dat")
+}
+
+// LoadChunk reads the chunk at (cx, cz) of the dimension.
+// The region.ErrNoSector is returned if the chunk doesn't exist.
+func (w *World) LoadChunk(dim string, cx, cz int) (*Chunk, error) {
+	data, err := w.regionCache(dim, "region").ReadChunk(cx, cz)
+	if err != nil {
+		return nil, err
+	}
+	var c Chunk
+	if err := c.Load(data); err != nil {
+		return nil, err
+	}
+	if err := c.Upgrade(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// SaveChunk writes the chunk to the dimension, at the position of c.XPos and c.ZPos.
+// The region file is created if it doesn't exist.
+func (w *World) SaveChunk(dim string, c *Chunk) error {
+	data, err := c.Data(CompressionZlib)
+	if err != nil {
+		return err
+	}
+	return w.regionCache(dim, "region").WriteChunk(int(c.XPos), int(c.ZPos), data)
+}
+
+// SaveChunkAsync encodes the chunk and queues it to be written in background.
+// The chunk can be modified after the function returns.
+// The errors of writing are returned by Flush or Close.
+func (w *World) SaveChunkAsync(dim string, c *Chunk) error {
+	data, err := c.Data(CompressionZlib)
+	if err != nil {
+		return err
+	}
+	return w.regionCache(dim, "region").WriteChunkAsync(int(c.XPos), int(c.ZPos), data)
+}
+
+// Flush waits for all chunks queued by SaveChunkAsync to be written.
+func (w *World) Flush() error {
+	w.regionsLock.Lock()
+	defer w.regionsLock.Unlock()
+	var errs []error
+	for _, c := range w.regions {
+		errs = append(errs, c.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// LoadEntities reads the entities in the chunk at (cx, cz) of the dimension.
+// The region.ErrNoSector is returned if the chunk has never had entities.
+func (w *World) LoadEntities(dim string, cx, cz int) (*EntityChunk, error) {
+	data, err := w.regionCache(dim, "entities").ReadChunk(cx, cz)
+	if err != nil {
+		return nil, err
+	}
+	var c EntityChunk
+	if err := c.Load(data); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// SaveEntities writes the entity chunk to the dimension, at the position of c.Position.
+func (w *World) SaveEntities(dim string, c *EntityChunk) error {
+	data, err := c.Data(CompressionZlib)
+	if err != nil {
+		return err
+	}
+	return w.regionCache(dim, "entities").WriteChunk(int(c.Position[0]), int(c.Position[1]), data)
+}
+
+// LoadPOI reads the points of interest in the chunk at (cx, cz) of the dimension.
+// The region.ErrNoSector is returned if the chunk has never had points of interest.
+func (w *World) LoadPOI(dim string, cx, cz int) (*POIChunk, error) {
+	data, err := w.regionCache(dim, "poi").ReadChunk(cx, cz)
+	if err != nil {
+		return nil, err
+	}
+	var c POIChunk
+	if err := c.Load(data); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// SavePOI writes the POI chunk to the dimension at (cx, cz).
+func (w *World) SavePOI(dim string, cx, cz int, c *POIChunk) error {
+	data, err := c.Data(CompressionZlib)
+	if err != nil {
+		return err
+	}
+	return w.regionCache(dim, "poi").WriteChunk(cx, cz, data)
+}
+
+// regionCache returns the region.Cache of the folder, like "region" or "entities", of the dimension.
+func (w *World) regionCache(dim, folder string) *region.Cache {
+	dir := filepath.Join(w.DimensionDir(dim), folder)
+	w.regionsLock.Lock()
+	defer w.regionsLock.Unlock()
+	c, ok := w.regions[dir]
+	if !ok {
+		c = region.NewCache(dir, regionCacheSize)
+		w.regions[dir] = c
+	}
+	return c
+}
+
+func readGzipFile[T any](name string, read func(r io.Reader) (T, error)) (data T, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return data, err
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return data, err
+	}
+	return read(r)
+}
+
+// writeFileSafe writes the file into a temporary file, then replaces the file with it.
+// The replaced file is renamed with the suffix "_old".
+func writeFileSafe(name string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(name, name+"_old")
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package save
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/save/region"
+)
+
+func TestWorld(t *testing.T) {
+	dir := copyWorld(t, "testdata")
+	w, err := OpenWorld(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenWorld(dir); !errors.Is(err, ErrLocked) {
+		t.Errorf("open a locked world: got %v, want ErrLocked", err)
+	}
+
+	dims, err := w.Dimensions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(dims, []string{Overworld, TheNether, TheEnd}) {
+		t.Errorf("dimensions: %v", dims)
+	}
+	if got, want := w.DimensionDir("go-mc:a/b"), filepath.Join(dir, "dimensions", "go-mc", "a", "b"); got != want {
+		t.Errorf("custom dimension dir: got %s, want %s", got, want)
+	}
+
+	players, err := w.Players()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uuid.MustParse("58f6356e-b30c-4811-8bfc-d72a9ee99e73"); !slices.Equal(players, []uuid.UUID{want}) {
+		t.Errorf("players: %v", players)
+	}
+	player, err := w.PlayerData(players[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	player.XpLevel = 30
+	if err := w.SavePlayerData(players[0], player); err != nil {
+		t.Fatal(err)
+	}
+	if player, err := w.PlayerData(players[0]); err != nil || player.XpLevel != 30 {
+		t.Errorf("player data not saved: %v", err)
+	}
+
+	level, err := w.Level()
+	if err != nil {
+		t.Fatal(err)
+	}
+	level.Data.GameRules["keepInventory"] = "true"
+	if err := w.SaveLevel(level); err != nil {
+		t.Fatal(err)
+	}
+	if level, err := w.Level(); err != nil || level.Data.GameRules["keepInventory"] != "true" {
+		t.Errorf("level not saved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "level.dat_old")); err != nil {
+		t.Errorf("level.dat_old not kept: %v", err)
+	}
+
+	c, err := w.LoadChunk(Overworld, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.XPos != 0 || c.ZPos != 0 {
+		t.Errorf("chunk position: (%d, %d)", c.XPos, c.ZPos)
+	}
+	if _, err := w.LoadChunk(Overworld, 1000, 1000); !errors.Is(err, region.ErrNoSector) {
+		t.Errorf("load a missing chunk: got %v, want ErrNoSector", err)
+	}
+
+	// Save the chunk into a new region of a custom dimension
+	c.XPos, c.ZPos = -100, 200
+	if err := w.SaveChunk("go-mc:custom", c); err != nil {
+		t.Fatal(err)
+	}
+	c.XPos = -101
+	if err := w.SaveChunkAsync("go-mc:custom", c); err != nil {
+		t.Fatal(err)
+	}
+	if c2, err := w.LoadChunk("go-mc:custom", -101, 200); err != nil || c2.XPos != -101 {
+		t.Errorf("load the chunk being saved: %v", err)
+	}
+	c.XPos = -100
+
+	// Move the entities of a chunk to the custom dimension
+	var entities *EntityChunk
+	forEachChunk(t, "testdata/entities/r.*.*.mca", func(rx, rz, x, z int, data []byte) {
+		if entities == nil {
+			entities, err = w.LoadEntities(Overworld, rx*32+x, rz*32+z)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if entities == nil {
+		t.Fatal("no entity chunk found")
+	}
+	entities.Position = [2]int32{-100, 200}
+	if err := w.SaveEntities("go-mc:custom", entities); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.LoadEntities("go-mc:custom", 0, 0); !errors.Is(err, region.ErrNoSector) {
+		t.Errorf("load missing entities: got %v, want ErrNoSector", err)
+	}
+
+	var poi POIChunk
+	poi.DataVersion = entities.DataVersion
+	poi.SetSection(4, POISection{Valid: true, Records: []POIRecord{{Type: "minecraft:home", Pos: [3]int32{-1600, 70, 3200}, FreeTickets: 1}}})
+	if err := w.SavePOI("go-mc:custom", -100, 200, &poi); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = OpenWorld(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	c2, err := w.LoadChunk("go-mc:custom", -100, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c2.XPos != -100 || c2.ZPos != 200 || len(c2.Sections) != len(c.Sections) {
+		t.Errorf("saved chunk mismatch: (%d, %d) with %d sections", c2.XPos, c2.ZPos, len(c2.Sections))
+	}
+	if _, err := w.LoadChunk("go-mc:custom", -101, 200); err != nil {
+		t.Errorf("the chunk saved asynchronously is lost: %v", err)
+	}
+	if e, err := w.LoadEntities("go-mc:custom", -100, 200); err != nil || len(e.Entities) != len(entities.Entities) {
+		t.Errorf("saved entities mismatch: %v", err)
+	}
+	if p, err := w.LoadPOI("go-mc:custom", -100, 200); err != nil || len(p.Records()) != 1 || p.Records()[0].Type != "minecraft:home" {
+		t.Errorf("saved poi mismatch: %v", err)
+	}
+	if dims, _ := w.Dimensions(); !slices.Contains(dims, "go-mc:custom") {
+		t.Errorf("custom dimension not found: %v", dims)
+	}
+}
+
+// copyWorld copies the world folder into a temporary directory.
+func copyWorld(t *testing.T, src string) string {
+	dst := t.TempDir()
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
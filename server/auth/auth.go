@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/google/uuid"
@@ -21,6 +22,7 @@ import (
 	"github.com/Tnze/go-mc/net"
 	"github.com/Tnze/go-mc/net/CFB8"
 	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/yggdrasil"
 	"github.com/Tnze/go-mc/yggdrasil/user"
 )
 
@@ -33,8 +35,18 @@ type (
 
 const verifyTokenLen = 16
 
+// ErrNotJoined is returned when the session server doesn't know the player is joining.
+// Usually because the client is not logged in or is cheating with the username.
+var ErrNotJoined = errors.New("player has not joined through the session server")
+
 // Encrypt a connection, with authentication
 func Encrypt(conn *net.Conn, name string, serverKey *rsa.PrivateKey) (*Resp, error) {
+	return EncryptWith(conn, name, serverKey, yggdrasil.Mojang)
+}
+
+// EncryptWith is like Encrypt, but the player is authenticated by the session server of e.
+// If e.PublicKey is not nil, the signatures of the profile properties are verified with it.
+func EncryptWith(conn *net.Conn, name string, serverKey *rsa.PrivateKey, e *yggdrasil.Endpoints) (*Resp, error) {
 	publicKey, err := x509.MarshalPKIXPublicKey(&serverKey.PublicKey)
 	if err != nil {
 		return nil, err
@@ -69,11 +81,18 @@ func Encrypt(conn *net.Conn, name string, serverKey *rsa.PrivateKey) (*Resp, err
 		CFB8.NewCFB8Decrypt(block, SharedSecret),
 	)
 	hash := authDigest("", SharedSecret, publicKey)
-	resp, err := authentication(name, hash) // auth
+	resp, err := authentication(e, name, hash) // auth
 	if err != nil {
-		return nil, errors.New("auth servers down")
+		return nil, err
 	}
 
+	if e.PublicKey != nil {
+		for _, p := range resp.Properties {
+			if err := p.Verify(e.PublicKey); err != nil {
+				return nil, fmt.Errorf("verify property %s: %w", p.Name, err)
+			}
+		}
+	}
 	return resp, nil
 }
 
@@ -121,13 +140,20 @@ func encryptionResponse(conn *net.Conn, serverKey *rsa.PrivateKey, verifyToken [
 	return sharedSecret, nil
 }
 
-func authentication(name, hash string) (*Resp, error) {
-	resp, err := http.Get("https://sessionserver.mojang.com/session/minecraft/hasJoined?username=" + name + "&serverId=" + hash)
+func authentication(e *yggdrasil.Endpoints, name, hash string) (*Resp, error) {
+	query := url.Values{"username": {name}, "serverId": {hash}}
+	resp, err := http.Get(e.SessionServer + "/session/minecraft/hasJoined?" + query.Encode())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("auth servers down: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, ErrNotJoined
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth servers down: %s", resp.Status)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
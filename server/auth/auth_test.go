@@ -1,10 +1,21 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/yggdrasil"
+	"github.com/Tnze/go-mc/yggdrasil/user"
 )
 
 func TestResp(t *testing.T) {
@@ -56,3 +67,50 @@ func TestResp(t *testing.T) {
 			wantCAPE)
 	}
 }
+
+func TestAuthentication(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value := base64.StdEncoding.EncodeToString([]byte(`{"profileName":"Steve"}`))
+	hash := sha1.Sum([]byte(value))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session/minecraft/hasJoined" || r.URL.Query().Get("username") != "Steve" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Resp{
+			Name: "Steve",
+			ID:   uuid.New(),
+			Properties: []user.Property{{
+				Name:      "textures",
+				Value:     value,
+				Signature: base64.StdEncoding.EncodeToString(signature),
+			}},
+		})
+	}))
+	defer srv.Close()
+	e := &yggdrasil.Endpoints{SessionServer: srv.URL, PublicKey: &key.PublicKey}
+
+	resp, err := authentication(e, "Steve", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.Properties[0].Verify(e.PublicKey); err != nil {
+		t.Errorf("verify signature: %v", err)
+	}
+	resp.Properties[0].Value = base64.StdEncoding.EncodeToString([]byte(`{"profileName":"Alex"}`))
+	if err := resp.Properties[0].Verify(e.PublicKey); err == nil {
+		t.Error("modified property passed the verification")
+	}
+
+	if _, err := authentication(e, "Alex", "hash"); !errors.Is(err, ErrNotJoined) {
+		t.Errorf("authenticate unknown player: got %v, want ErrNotJoined", err)
+	}
+}
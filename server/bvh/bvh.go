@@ -1,3 +1,15 @@
+// Package bvh implements a dynamic bounding volume hierarchy,
+// which indexes values by their bounds for fast spatial queries.
+//
+// The [Tree] is generic over the type of bounds, which only needs to be able to union and measure its surface.
+// [AABB] and [Sphere] are provided, working with [Vec2] and [Vec3].
+//
+// Leaves can be inserted and deleted one by one, or built in bulk by [Tree.Rebuild].
+// The queries are:
+//   - [Tree.Find], calls a function for each leaf passing a test, such as [TouchPoint] and [TouchBound].
+//   - [Range], finds the leaves touching an AABB.
+//   - [Tree.Raycast], finds the leaves hit by a ray in the order of distance, see [RayHit].
+//   - [Tree.Nearest], finds the k-nearest leaves, see [PointDistance].
 package bvh
 
 import (
@@ -40,8 +52,12 @@ func (n *Node[I, B, V]) each(test func(bound B) bool, foreach func(n *Node[I, B,
 	if n == nil {
 		return true
 	}
+	if !test(n.Box) {
+		// the children are all inside the box, none of them can pass the test
+		return true
+	}
 	if n.isLeaf {
-		return !test(n.Box) || foreach(n)
+		return foreach(n)
 	} else {
 		return n.children[0].each(test, foreach) && n.children[1].each(test, foreach)
 	}
@@ -137,7 +153,7 @@ func (t *Tree[I, B, V]) Delete(n *Node[I, B, V]) any {
 		p := grand.findChildPointer(n.parent)
 		*p = sibling
 		sibling.parent = grand
-		for p := sibling.parent; p.parent != nil; p = p.parent {
+		for p := sibling.parent; p != nil; p = p.parent {
 			p.Box = p.children[0].Box.Union(p.children[1].Box)
 			t.rotate(p)
 		}
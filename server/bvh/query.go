@@ -0,0 +1,199 @@
+package bvh
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Range calls foreach for each leaf whose bound touches the box, until foreach returns false.
+// It's a shortcut of Find with [TouchBound].
+func Range[I constraints.Float, B interface {
+	Union(B) B
+	Surface() I
+	Touch(B) bool
+}, V any](t *Tree[I, B, V], box B, foreach func(n *Node[I, B, V]) bool) {
+	t.Find(TouchBound(box), foreach)
+}
+
+// Raycast calls foreach for each leaf hit by a ray, in the order of increasing distance,
+// until foreach returns false.
+//
+// The hit function reports whether the ray hits a bound, and the distance along the ray where it enters the bound.
+// Because the traversal is pruned by the bounds of inner nodes,
+// the distance of a bound must not be greater than any bound inside it. See [RayHit].
+func (t *Tree[I, B, V]) Raycast(hit func(bound B) (dist I, ok bool), foreach func(n *Node[I, B, V], dist I) bool) {
+	t.bestFirst(hit, foreach)
+}
+
+// Nearest returns up to k leaves nearest to a query, in the order of increasing distance.
+//
+// The dist function returns the distance from the query to a bound.
+// The distance of a bound must not be greater than any bound inside it. See [PointDistance].
+func (t *Tree[I, B, V]) Nearest(dist func(bound B) I, k int) []*Node[I, B, V] {
+	if k <= 0 {
+		return nil
+	}
+	nodes := make([]*Node[I, B, V], 0, k)
+	t.bestFirst(
+		func(bound B) (I, bool) { return dist(bound), true },
+		func(n *Node[I, B, V], _ I) bool {
+			nodes = append(nodes, n)
+			return len(nodes) < k
+		},
+	)
+	return nodes
+}
+
+// bestFirst visits the leaves in the order of the key.
+// A node is popped before its children, because its key isn't greater than theirs.
+// So when a leaf is popped, there is no leaf with smaller key in the queue.
+func (t *Tree[I, B, V]) bestFirst(key func(bound B) (I, bool), foreach func(n *Node[I, B, V], key I) bool) {
+	if t.root == nil {
+		return
+	}
+	var queue searchHeap[I, Node[I, B, V]]
+	if k, ok := key(t.root.Box); ok {
+		heap.Push(&queue, searchItem[I, Node[I, B, V]]{pointer: t.root, inheritedCost: k})
+	}
+	for queue.Len() > 0 {
+		item := heap.Pop(&queue).(searchItem[I, Node[I, B, V]])
+		n := item.pointer
+		if n.isLeaf {
+			if !foreach(n, item.inheritedCost) {
+				return
+			}
+			continue
+		}
+		for _, child := range n.children {
+			if k, ok := key(child.Box); ok {
+				heap.Push(&queue, searchItem[I, Node[I, B, V]]{pointer: child, inheritedCost: k})
+			}
+		}
+	}
+}
+
+// Rebuild replaces everything in the tree with the bounds and values.
+// The nodes are returned in the same order as the inputs, which can be used to [Tree.Delete] them.
+//
+// Building the tree in bulk is faster than inserting the leaves one by one,
+// and usually results in a better balanced tree.
+// Panics if the lengths of bounds and values are different.
+func (t *Tree[I, B, V]) Rebuild(bounds []B, values []V) []*Node[I, B, V] {
+	if len(bounds) != len(values) {
+		panic("bvh: the lengths of bounds and values are different")
+	}
+	leaves := make([]*Node[I, B, V], len(bounds))
+	for i := range bounds {
+		leaves[i] = &Node[I, B, V]{Box: bounds[i], Value: values[i], isLeaf: true}
+	}
+	t.root = nil
+	if len(leaves) > 0 {
+		t.root = build(append([]*Node[I, B, V](nil), leaves...))
+	}
+	return leaves
+}
+
+// build creates a subtree from the leaves top-down.
+//
+// Only Union and Surface are known about the bounds, so the leaves are split by two seeds far from each other:
+// each leaf is ordered by how much it prefers one seed than another, and the halves become the two subtrees.
+func build[I constraints.Float, B interface {
+	Union(B) B
+	Surface() I
+}, V any](leaves []*Node[I, B, V]) *Node[I, B, V] {
+	if len(leaves) == 1 {
+		return leaves[0]
+	}
+	farthest := func(from B) B {
+		best, bestCost := leaves[0].Box, from.Union(leaves[0].Box).Surface()
+		for _, n := range leaves[1:] {
+			if cost := from.Union(n.Box).Surface(); cost > bestCost {
+				best, bestCost = n.Box, cost
+			}
+		}
+		return best
+	}
+	a := farthest(leaves[0].Box)
+	b := farthest(a)
+
+	keys := make([]I, len(leaves))
+	for i, n := range leaves {
+		keys[i] = n.Box.Union(a).Surface() - n.Box.Union(b).Surface()
+	}
+	sort.Sort(buildSorter[I, B, V]{keys, leaves})
+
+	mid := len(leaves) / 2
+	n := &Node[I, B, V]{children: [2]*Node[I, B, V]{build(leaves[:mid]), build(leaves[mid:])}}
+	n.children[0].parent, n.children[1].parent = n, n
+	n.Box = n.children[0].Box.Union(n.children[1].Box)
+	return n
+}
+
+// RayHit returns a function for [Tree.Raycast], which tests the ray from the origin towards the dir against an AABB.
+// The ray starting inside a bound hits it at distance 0.
+// The distance is measured in the length of the dir.
+//
+// The dir can be zero on some axes, and the ray parallel to a face of the bound hits it if it's on the face.
+// DivVecOr(v, nan) divides the vector by v component-wise, but gives nan for 0/0.
+func RayHit[I constraints.Float, V interface {
+	Add(V) V
+	Sub(V) V
+	Max(V) V
+	Min(V) V
+	Less(V) bool
+	More(V) bool
+	Sum() I
+	DivVecOr(V, I) V
+	MaxElem() I
+	MinElem() I
+}](origin, dir V) func(bound AABB[I, V]) (I, bool) {
+	// -0 + 0 is 0, so that the zero components of the dir are all positive
+	dir = dir.Add(dir.Sub(dir))
+	return func(bound AABB[I, V]) (I, bool) {
+		// the slab method.
+		// The distances are 0/0 if the ray parallel to the axis is on the plane,
+		// which is treated as the lower plane is passed and the upper one is never reached.
+		t1 := bound.Lower.Sub(origin).DivVecOr(dir, I(math.Inf(-1)))
+		t2 := bound.Upper.Sub(origin).DivVecOr(dir, I(math.Inf(1)))
+		tEnter := max(t1.Min(t2).MaxElem(), 0)
+		tExit := t1.Max(t2).MinElem()
+		return tEnter, tEnter <= tExit
+	}
+}
+
+// PointDistance returns a function for [Tree.Nearest], which measures the distance from the point to an AABB.
+// The distance is 0 if the point is inside the bound.
+func PointDistance[I constraints.Float, V interface {
+	Add(V) V
+	Sub(V) V
+	Max(V) V
+	Min(V) V
+	Less(V) bool
+	More(V) bool
+	Sum() I
+	Norm() float64
+}](point V) func(bound AABB[I, V]) I {
+	return func(bound AABB[I, V]) I {
+		zero := point.Sub(point)
+		d := bound.Lower.Sub(point).Max(point.Sub(bound.Upper)).Max(zero)
+		return I(d.Norm())
+	}
+}
+
+type buildSorter[I constraints.Float, B interface {
+	Union(B) B
+	Surface() I
+}, V any] struct {
+	keys   []I
+	leaves []*Node[I, B, V]
+}
+
+func (s buildSorter[I, B, V]) Len() int           { return len(s.keys) }
+func (s buildSorter[I, B, V]) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s buildSorter[I, B, V]) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.leaves[i], s.leaves[j] = s.leaves[j], s.leaves[i]
+}
@@ -0,0 +1,214 @@
+package bvh
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+type (
+	vec3d  = Vec3[float64]
+	aabb3d = AABB[float64, vec3d]
+)
+
+func randomBoxes(n int) []aabb3d {
+	r := rand.New(rand.NewSource(1))
+	boxes := make([]aabb3d, n)
+	for i := range boxes {
+		lower := vec3d{r.Float64() * 1000, r.Float64() * 1000, r.Float64() * 1000}
+		size := vec3d{r.Float64() * 10, r.Float64() * 10, r.Float64() * 10}
+		boxes[i] = aabb3d{Lower: lower, Upper: lower.Add(size)}
+	}
+	return boxes
+}
+
+func buildTree(boxes []aabb3d) *Tree[float64, aabb3d, int] {
+	var tree Tree[float64, aabb3d, int]
+	values := make([]int, len(boxes))
+	for i := range values {
+		values[i] = i
+	}
+	tree.Rebuild(boxes, values)
+	return &tree
+}
+
+func TestRange(t *testing.T) {
+	boxes := randomBoxes(1000)
+	tree := buildTree(boxes)
+	query := aabb3d{Lower: vec3d{200, 200, 200}, Upper: vec3d{500, 500, 500}}
+
+	got := make(map[int]bool)
+	Range(tree, query, func(n *Node[float64, aabb3d, int]) bool {
+		got[n.Value] = true
+		return true
+	})
+	for i, box := range boxes {
+		if box.Touch(query) != got[i] {
+			t.Errorf("box %d: touch %v, found %v", i, box.Touch(query), got[i])
+		}
+	}
+}
+
+func TestTree_Nearest(t *testing.T) {
+	boxes := randomBoxes(1000)
+	tree := buildTree(boxes)
+	point := vec3d{500, 500, 500}
+	dist := PointDistance[float64](point)
+
+	want := make([]float64, len(boxes))
+	for i, box := range boxes {
+		want[i] = dist(box)
+	}
+	sort.Float64s(want)
+
+	nodes := tree.Nearest(dist, 10)
+	if len(nodes) != 10 {
+		t.Fatalf("got %d nodes, want 10", len(nodes))
+	}
+	for i, n := range nodes {
+		if d := dist(n.Box); d != want[i] {
+			t.Errorf("the %dth nearest: distance %v, want %v", i, d, want[i])
+		}
+	}
+}
+
+func TestTree_Raycast(t *testing.T) {
+	boxes := randomBoxes(1000)
+	// insert one by one, to test Raycast on the tree built by Insert
+	var tree Tree[float64, aabb3d, int]
+	for i, box := range boxes {
+		tree.Insert(box, i)
+	}
+	hit := RayHit[float64](vec3d{0, 0, 0}, vec3d{1, 1, 1.1})
+
+	var want []float64
+	for _, box := range boxes {
+		if d, ok := hit(box); ok {
+			want = append(want, d)
+		}
+	}
+	sort.Float64s(want)
+
+	var got []float64
+	tree.Raycast(hit, func(n *Node[float64, aabb3d, int], dist float64) bool {
+		got = append(got, dist)
+		return true
+	})
+	if len(got) != len(want) {
+		t.Fatalf("hit %d boxes, want %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("the %dth hit: distance %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestVec3_Less(t *testing.T) {
+	if (vec3d{0, 0, 1}).Less(vec3d{1, 1, 0}) {
+		t.Error("the Z component is ignored")
+	}
+	if s := (vec3d{1, 2, 3}).Sum(); s != 6 {
+		t.Errorf("sum: got %v, want 6", s)
+	}
+}
+
+func BenchmarkRange(b *testing.B) {
+	boxes := randomBoxes(10000)
+	tree := buildTree(boxes)
+	query := aabb3d{Lower: vec3d{450, 450, 450}, Upper: vec3d{550, 550, 550}}
+	b.Run("BVH", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Range(tree, query, func(*Node[float64, aabb3d, int]) bool { return true })
+		}
+	})
+	b.Run("Naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, box := range boxes {
+				_ = box.Touch(query)
+			}
+		}
+	})
+}
+
+func BenchmarkNearest(b *testing.B) {
+	boxes := randomBoxes(10000)
+	tree := buildTree(boxes)
+	dist := PointDistance[float64](vec3d{500, 500, 500})
+	b.Run("BVH", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree.Nearest(dist, 10)
+		}
+	})
+	b.Run("Naive", func(b *testing.B) {
+		dists := make([]float64, len(boxes))
+		for i := 0; i < b.N; i++ {
+			for j, box := range boxes {
+				dists[j] = dist(box)
+			}
+			sort.Float64s(dists)
+		}
+	})
+}
+
+func BenchmarkRaycast(b *testing.B) {
+	boxes := randomBoxes(10000)
+	tree := buildTree(boxes)
+	hit := RayHit[float64](vec3d{0, 0, 0}, vec3d{1, 1, 1.1})
+	b.Run("BVH", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			// the first hit
+			tree.Raycast(hit, func(*Node[float64, aabb3d, int], float64) bool { return false })
+		}
+	})
+	b.Run("Naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			nearest := math.Inf(1)
+			for _, box := range boxes {
+				if d, ok := hit(box); ok && d < nearest {
+					nearest = d
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkTree_Rebuild(b *testing.B) {
+	boxes := randomBoxes(10000)
+	values := make([]int, len(boxes))
+	b.Run("Rebuild", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var tree Tree[float64, aabb3d, int]
+			tree.Rebuild(boxes, values)
+		}
+	})
+	b.Run("Insert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var tree Tree[float64, aabb3d, int]
+			for j, box := range boxes {
+				tree.Insert(box, values[j])
+			}
+		}
+	})
+}
+
+func TestRayHit_axisParallel(t *testing.T) {
+	box := aabb3d{Lower: vec3d{0, 0, 0}, Upper: vec3d{1, 1, 1}}
+	for _, tt := range []struct {
+		origin, dir vec3d
+		dist        float64
+		hit         bool
+	}{
+		{vec3d{-1, 0, 0.5}, vec3d{1, 0, 0}, 1, true},                  // on the lower plane of Y
+		{vec3d{-1, 1, 1}, vec3d{1, 0, 0}, 1, true},                    // on the upper planes of Y and Z
+		{vec3d{2, 0, 0}, vec3d{-1, math.Copysign(0, -1), 0}, 1, true}, // on the planes with the negative zero
+		{vec3d{-1, 1.5, 0.5}, vec3d{1, 0, 0}, 0, false},               // beside the box
+		{vec3d{0.5, 0, 0.5}, vec3d{0, 0, 0}, 0, true},                 // a point on the face
+	} {
+		dist, hit := RayHit[float64](tt.origin, tt.dir)(box)
+		if hit != tt.hit || hit && dist != tt.dist {
+			t.Errorf("ray from %v towards %v: got (%v, %v), want (%v, %v)", tt.origin, tt.dir, dist, hit, tt.dist, tt.hit)
+		}
+	}
+}
@@ -17,6 +17,15 @@ func (v Vec2[I]) Less(other Vec2[I]) bool   { return v[0] < other[0] && v[1] < o
 func (v Vec2[I]) More(other Vec2[I]) bool   { return v[0] > other[0] && v[1] > other[1] }
 func (v Vec2[I]) Norm() float64             { return sqrt(v[0]*v[0] + v[1]*v[1]) }
 func (v Vec2[I]) Sum() I                    { return v[0] + v[1] }
+func (v Vec2[I]) Dot(other Vec2[I]) I       { return v[0]*other[0] + v[1]*other[1] }
+func (v Vec2[I]) DivVec(other Vec2[I]) Vec2[I] {
+	return Vec2[I]{v[0] / other[0], v[1] / other[1]}
+}
+func (v Vec2[I]) DivVecOr(other Vec2[I], nan I) Vec2[I] {
+	return Vec2[I]{divOr(v[0], other[0], nan), divOr(v[1], other[1], nan)}
+}
+func (v Vec2[I]) MaxElem() I { return max(v[0], v[1]) }
+func (v Vec2[I]) MinElem() I { return min(v[0], v[1]) }
 
 type Vec3[I constraints.Signed | constraints.Float] [3]I
 
@@ -35,10 +44,24 @@ func (v Vec3[I]) Max(other Vec3[I]) Vec3[I] {
 func (v Vec3[I]) Min(other Vec3[I]) Vec3[I] {
 	return Vec3[I]{min(v[0], other[0]), min(v[1], other[1]), min(v[2], other[2])}
 }
-func (v Vec3[I]) Less(other Vec3[I]) bool { return v[0] < other[0] && v[1] < other[1] }
-func (v Vec3[I]) More(other Vec3[I]) bool { return v[0] > other[0] && v[1] > other[1] }
-func (v Vec3[I]) Norm() float64           { return sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2]) }
-func (v Vec3[I]) Sum() I                  { return v[0] + v[1] }
+func (v Vec3[I]) Less(other Vec3[I]) bool {
+	return v[0] < other[0] && v[1] < other[1] && v[2] < other[2]
+}
+
+func (v Vec3[I]) More(other Vec3[I]) bool {
+	return v[0] > other[0] && v[1] > other[1] && v[2] > other[2]
+}
+func (v Vec3[I]) Norm() float64       { return sqrt(v[0]*v[0] + v[1]*v[1] + v[2]*v[2]) }
+func (v Vec3[I]) Sum() I              { return v[0] + v[1] + v[2] }
+func (v Vec3[I]) Dot(other Vec3[I]) I { return v[0]*other[0] + v[1]*other[1] + v[2]*other[2] }
+func (v Vec3[I]) DivVec(other Vec3[I]) Vec3[I] {
+	return Vec3[I]{v[0] / other[0], v[1] / other[1], v[2] / other[2]}
+}
+func (v Vec3[I]) DivVecOr(other Vec3[I], nan I) Vec3[I] {
+	return Vec3[I]{divOr(v[0], other[0], nan), divOr(v[1], other[1], nan), divOr(v[2], other[2], nan)}
+}
+func (v Vec3[I]) MaxElem() I { return max(v[0], max(v[1], v[2])) }
+func (v Vec3[I]) MinElem() I { return min(v[0], min(v[1], v[2])) }
 
 func max[T constraints.Ordered](a, b T) T {
 	if a > b {
@@ -54,6 +77,14 @@ func min[T constraints.Ordered](a, b T) T {
 	return b
 }
 
+// divOr returns a / b, or nan if both of them are zero.
+func divOr[T constraints.Signed | constraints.Float](a, b, nan T) T {
+	if a == 0 && b == 0 {
+		return nan
+	}
+	return a / b
+}
+
 func sqrt[T constraints.Signed | constraints.Float](v T) float64 {
 	return math.Sqrt(float64(v))
 }
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/Tnze/go-mc/level"
+)
+
+// ChunkProvider provides the chunks sent to the players.
+//
+// The returned chunk may be shared between players, and must not be modified after returned.
+type ChunkProvider interface {
+	Chunk(pos level.ChunkPos) (*level.Chunk, error)
+}
+
+// ChunkCache is a ChunkProvider which keeps the recently used chunks of another ChunkProvider in memory.
+// It's safe for concurrent use.
+type ChunkCache struct {
+	provider ChunkProvider
+	size     int
+
+	lock  sync.Mutex
+	list  *list.List // of chunkCacheItem, the front is the most recently used
+	index map[level.ChunkPos]*list.Element
+}
+
+type chunkCacheItem struct {
+	pos   level.ChunkPos
+	chunk *level.Chunk
+}
+
+// NewChunkCache creates a ChunkCache which keeps up to size chunks provided by the provider.
+func NewChunkCache(provider ChunkProvider, size int) *ChunkCache {
+	return &ChunkCache{
+		provider: provider,
+		size:     size,
+		list:     list.New(),
+		index:    make(map[level.ChunkPos]*list.Element),
+	}
+}
+
+// Chunk implements ChunkProvider.
+// The chunk is loaded from the underlying provider if it's not in the cache.
+func (c *ChunkCache) Chunk(pos level.ChunkPos) (*level.Chunk, error) {
+	c.lock.Lock()
+	if elem, ok := c.index[pos]; ok {
+		c.list.MoveToFront(elem)
+		c.lock.Unlock()
+		return elem.Value.(chunkCacheItem).chunk, nil
+	}
+	c.lock.Unlock()
+
+	// The provider is called without holding the lock, so that loading chunks doesn't block each other.
+	chunk, err := c.provider.Chunk(pos)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.index[pos]; ok {
+		// loaded by someone else at the same time
+		c.list.MoveToFront(elem)
+		return elem.Value.(chunkCacheItem).chunk, nil
+	}
+	c.index[pos] = c.list.PushFront(chunkCacheItem{pos: pos, chunk: chunk})
+	for c.list.Len() > c.size {
+		last := c.list.Back()
+		delete(c.index, c.list.Remove(last).(chunkCacheItem).pos)
+	}
+	return chunk, nil
+}
+
+// Invalidate removes the chunk from the cache, so it will be loaded again next time.
+func (c *ChunkCache) Invalidate(pos level.ChunkPos) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.index[pos]; ok {
+		c.list.Remove(elem)
+		delete(c.index, pos)
+	}
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+const (
+	// chunkStreamTickInterval is the interval of sending chunk batches, which is a game tick.
+	chunkStreamTickInterval = time.Second / 20
+	// chunkStreamStartRate is the chunks per tick sent before the client reports its desired rate.
+	chunkStreamStartRate = 9
+	// chunkStreamMaxUnacknowledged is the maximum number of batches waiting for acknowledgement.
+	// Only 1 batch is allowed before the client's first acknowledgement.
+	chunkStreamMaxUnacknowledged = 10
+)
+
+type ChunkStreamClient interface {
+	WritePacket(p pk.Packet) error
+}
+
+// ChunkStream sends the chunks around the players.
+//
+// For each player, it tracks the center chunk and the view distance.
+// Chunks in the view distance are sent nearest first, in batches limited by the rate the client
+// reported in ChunkBatchReceived packet. Chunks leaving the view distance are forgotten by the client.
+type ChunkStream struct {
+	provider ChunkProvider
+
+	// OnError is called when the provider failed to provide a chunk.
+	// The chunk is skipped for the player until it leaves and re-enters the view distance.
+	// Optional.
+	OnError func(c ChunkStreamClient, pos level.ChunkPos, err error)
+
+	lock    sync.Mutex
+	clients map[ChunkStreamClient]*chunkView
+}
+
+// chunkView is the status of a player in the ChunkStream.
+type chunkView struct {
+	lock         sync.Mutex
+	center       level.ChunkPos
+	viewDistance int32
+	order        []level.ChunkPos // the offsets of chunks in the view distance, in spiral order
+	sent         map[level.ChunkPos]struct{}
+
+	desiredChunksPerTick float32
+	batchQuota           float32
+	unacknowledged       int
+	maxUnacknowledged    int
+}
+
+func NewChunkStream(provider ChunkProvider) *ChunkStream {
+	return &ChunkStream{
+		provider: provider,
+		clients:  make(map[ChunkStreamClient]*chunkView),
+	}
+}
+
+// ClientJoin starts sending chunks to the client.
+func (s *ChunkStream) ClientJoin(c ChunkStreamClient, center level.ChunkPos, viewDistance int32) {
+	v := &chunkView{
+		center:               center,
+		viewDistance:         viewDistance,
+		order:                spiral(viewDistance),
+		sent:                 make(map[level.ChunkPos]struct{}),
+		desiredChunksPerTick: chunkStreamStartRate,
+		maxUnacknowledged:    1,
+	}
+	_ = c.WritePacket(pk.Marshal(packetid.ClientboundSetChunkCacheCenter, pk.VarInt(center[0]), pk.VarInt(center[1])))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.clients[c] = v
+}
+
+// ClientLeft stops sending chunks to the client.
+func (s *ChunkStream) ClientLeft(c ChunkStreamClient) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.clients, c)
+}
+
+func (s *ChunkStream) view(c ChunkStreamClient) *chunkView {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.clients[c]
+}
+
+// SetCenter updates the center chunk of the client, usually the chunk where the player is in.
+// The chunks out of the view distance are forgotten immediately,
+// and the new chunks are sent in the following ticks.
+func (s *ChunkStream) SetCenter(c ChunkStreamClient, center level.ChunkPos) {
+	v := s.view(c)
+	if v == nil {
+		return
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if v.center == center {
+		return
+	}
+	v.center = center
+	_ = c.WritePacket(pk.Marshal(packetid.ClientboundSetChunkCacheCenter, pk.VarInt(center[0]), pk.VarInt(center[1])))
+	v.forgetOutOfRange(c)
+}
+
+// SetViewDistance updates the view distance of the client.
+func (s *ChunkStream) SetViewDistance(c ChunkStreamClient, viewDistance int32) {
+	v := s.view(c)
+	if v == nil {
+		return
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if v.viewDistance == viewDistance {
+		return
+	}
+	v.viewDistance = viewDistance
+	v.order = spiral(viewDistance)
+	v.forgetOutOfRange(c)
+}
+
+// BatchReceived handles the ChunkBatchReceived packet sent by the client,
+// which acknowledges a batch and reports the rate the client wants.
+func (s *ChunkStream) BatchReceived(c ChunkStreamClient, desiredChunksPerTick float32) {
+	v := s.view(c)
+	if v == nil {
+		return
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if v.unacknowledged > 0 {
+		v.unacknowledged--
+	}
+	if math.IsNaN(float64(desiredChunksPerTick)) {
+		v.desiredChunksPerTick = 0.01
+	} else {
+		v.desiredChunksPerTick = min(max(desiredChunksPerTick, 0.01), 64)
+	}
+	if v.unacknowledged == 0 {
+		v.batchQuota = 1
+	}
+	v.maxUnacknowledged = chunkStreamMaxUnacknowledged
+}
+
+// Run sends the chunk batches every tick until the ctx is done.
+func (s *ChunkStream) Run(ctx context.Context) {
+	ticker := time.NewTicker(chunkStreamTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick()
+		}
+	}
+}
+
+func (s *ChunkStream) tick() {
+	s.lock.Lock()
+	clients := make(map[ChunkStreamClient]*chunkView, len(s.clients))
+	for c, v := range s.clients {
+		clients[c] = v
+	}
+	s.lock.Unlock()
+
+	for c, v := range clients {
+		s.sendBatch(c, v)
+	}
+}
+
+func (s *ChunkStream) sendBatch(c ChunkStreamClient, v *chunkView) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if v.unacknowledged >= v.maxUnacknowledged {
+		return
+	}
+	v.batchQuota = min(v.batchQuota+v.desiredChunksPerTick, max(1, v.desiredChunksPerTick))
+	if v.batchQuota < 1 {
+		return
+	}
+
+	var batch []level.ChunkPos
+	for _, offset := range v.order {
+		if len(batch) >= int(v.batchQuota) {
+			break
+		}
+		pos := level.ChunkPos{v.center[0] + offset[0], v.center[1] + offset[1]}
+		if _, ok := v.sent[pos]; !ok {
+			batch = append(batch, pos)
+		}
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	v.unacknowledged++
+	v.batchQuota -= float32(len(batch))
+	_ = c.WritePacket(pk.Marshal(packetid.ClientboundChunkBatchStart))
+	var count int
+	for _, pos := range batch {
+		v.sent[pos] = struct{}{}
+		chunk, err := s.provider.Chunk(pos)
+		if err != nil {
+			if s.OnError != nil {
+				s.OnError(c, pos, err)
+			}
+			continue
+		}
+		if err := c.WritePacket(pk.Marshal(packetid.ClientboundLevelChunkWithLight, pos, chunk)); err != nil {
+			return
+		}
+		count++
+	}
+	_ = c.WritePacket(pk.Marshal(packetid.ClientboundChunkBatchFinished, pk.VarInt(count)))
+}
+
+// forgetOutOfRange tells the client to unload the chunks out of the view distance.
+func (v *chunkView) forgetOutOfRange(c ChunkStreamClient) {
+	for pos := range v.sent {
+		if abs(pos[0]-v.center[0]) > v.viewDistance || abs(pos[1]-v.center[1]) > v.viewDistance {
+			// The chunk pos is encoded as a Long, which is z in higher bits and x in lower bits.
+			_ = c.WritePacket(pk.Marshal(packetid.ClientboundForgetLevelChunk, pk.Int(pos[1]), pk.Int(pos[0])))
+			delete(v.sent, pos)
+		}
+	}
+}
+
+// spiral returns the offsets of the chunks within the radius, in the order of a square spiral from the center.
+func spiral(radius int32) []level.ChunkPos {
+	if radius < 0 {
+		return nil
+	}
+	offsets := make([]level.ChunkPos, 0, (2*radius+1)*(2*radius+1))
+	offsets = append(offsets, level.ChunkPos{0, 0})
+	for r := int32(1); r <= radius; r++ {
+		// start from the corner (-r, -r) and walk around the ring
+		for x := -r; x < r; x++ {
+			offsets = append(offsets, level.ChunkPos{x, -r})
+		}
+		for z := -r; z < r; z++ {
+			offsets = append(offsets, level.ChunkPos{r, z})
+		}
+		for x := r; x > -r; x-- {
+			offsets = append(offsets, level.ChunkPos{x, r})
+		}
+		for z := r; z > -r; z-- {
+			offsets = append(offsets, level.ChunkPos{-r, z})
+		}
+	}
+	return offsets
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level"
+	pk "github.com/Tnze/go-mc/net/packet"
+)
+
+type testClient struct {
+	packets []pk.Packet
+}
+
+func (c *testClient) WritePacket(p pk.Packet) error {
+	c.packets = append(c.packets, p)
+	return nil
+}
+
+// count returns the number of packets with the id, and clears the received packets.
+func (c *testClient) count(id packetid.ClientboundPacketID) (n int) {
+	for _, p := range c.packets {
+		if p.ID == int32(id) {
+			n++
+		}
+	}
+	c.packets = c.packets[:0]
+	return
+}
+
+type chunkStreamTestProvider struct {
+	loads int
+}
+
+func (p *chunkStreamTestProvider) Chunk(level.ChunkPos) (*level.Chunk, error) {
+	p.loads++
+	return level.EmptyChunk(1), nil
+}
+
+func TestChunkStream(t *testing.T) {
+	s := NewChunkStream(&chunkStreamTestProvider{})
+	c := new(testClient)
+	s.ClientJoin(c, level.ChunkPos{0, 0}, 2)
+	c.packets = c.packets[:0]
+
+	// Only the first batch is sent before the client's acknowledgement
+	s.tick()
+	if n := c.count(packetid.ClientboundLevelChunkWithLight); n != chunkStreamStartRate {
+		t.Errorf("first batch: sent %d chunks, want %d", n, chunkStreamStartRate)
+	}
+	s.tick()
+	if n := c.count(packetid.ClientboundLevelChunkWithLight); n != 0 {
+		t.Errorf("sent %d chunks before acknowledged", n)
+	}
+
+	s.BatchReceived(c, 64)
+	s.tick()
+	if n := c.count(packetid.ClientboundLevelChunkWithLight); n != 25-chunkStreamStartRate {
+		t.Errorf("second batch: sent %d chunks, want %d", n, 25-chunkStreamStartRate)
+	}
+
+	s.SetCenter(c, level.ChunkPos{3, 0})
+	if n := c.count(packetid.ClientboundForgetLevelChunk); n != 15 {
+		t.Errorf("forgot %d chunks, want 15", n)
+	}
+	s.BatchReceived(c, 64)
+	s.tick()
+	if n := c.count(packetid.ClientboundLevelChunkWithLight); n != 15 {
+		t.Errorf("sent %d chunks after moved, want 15", n)
+	}
+
+	s.ClientLeft(c)
+	s.tick()
+	if len(c.packets) != 0 {
+		t.Errorf("sent %d packets after left", len(c.packets))
+	}
+}
+
+func TestSpiral(t *testing.T) {
+	offsets := spiral(3)
+	if len(offsets) != 7*7 {
+		t.Fatalf("got %d offsets, want %d", len(offsets), 7*7)
+	}
+	seen := make(map[level.ChunkPos]bool)
+	var last int32
+	for _, v := range offsets {
+		if seen[v] {
+			t.Errorf("duplicated offset %v", v)
+		}
+		seen[v] = true
+		d := max(abs(v[0]), abs(v[1]))
+		if d < last {
+			t.Errorf("offset %v is nearer than the previous one", v)
+		}
+		last = d
+	}
+}
+
+func TestChunkCache(t *testing.T) {
+	p := new(chunkStreamTestProvider)
+	c := NewChunkCache(p, 2)
+	for _, pos := range []level.ChunkPos{{0, 0}, {1, 0}, {0, 0}, {2, 0}, {0, 0}, {1, 0}} {
+		if _, err := c.Chunk(pos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// {1, 0} is evicted by {2, 0}, and loaded again at last
+	if p.loads != 4 {
+		t.Errorf("loaded %d times, want 4", p.loads)
+	}
+}
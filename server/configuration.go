@@ -1,6 +1,12 @@
 package server
 
 import (
+	"bytes"
+	"io"
+	"slices"
+
+	"github.com/google/uuid"
+
 	"github.com/Tnze/go-mc/chat"
 	"github.com/Tnze/go-mc/data/packetid"
 	"github.com/Tnze/go-mc/net"
@@ -8,26 +14,357 @@ import (
 	"github.com/Tnze/go-mc/registry"
 )
 
+// ConfigHandler is used to handle the configuration phase, that is,
+// from serverbound "LoginAcknowledged" packet to serverbound "FinishConfiguration" packet.
+// The settings sent by the client during the phase are returned.
 type ConfigHandler interface {
-	AcceptConfig(conn *net.Conn) error
+	AcceptConfig(conn *net.Conn) (ClientInformation, error)
 }
 
+// Make sure Configurations implement ConfigHandler
+var _ ConfigHandler = (*Configurations)(nil)
+
+// Configurations is a standard ConfigHandler which sends the following things to the client in order:
+// the server brand, ServerLinks, enabled features, Registries with their tags and ResourcePacks.
 type Configurations struct {
 	Registries registry.Registries
+
+	// KnownPacks are the data packs offered to the client.
+	// The data of the registry entries loaded from the packs also known by the client are not sent.
+	// If nil, the packs which the Registries are loaded from are offered, see [registry.Registries.KnownPacks].
+	KnownPacks []registry.KnownPack
+
+	// EnabledFeatures are the feature flags enabled on the server.
+	// If nil, only "minecraft:vanilla" is enabled.
+	EnabledFeatures []string
+
+	// Brand is the server brand shown on the client's debug screen.
+	// If empty, "go-mc" is used.
+	Brand string
+
+	// ServerLinks are shown in the pause menu of the client. This is an optional field.
+	ServerLinks []ServerLink
+
+	// ResourcePacks are pushed to the client. The configuration fails if a forced pack is not loaded.
+	ResourcePacks []ResourcePack
 }
 
-func (c *Configurations) AcceptConfig(conn *net.Conn) error {
-	err := conn.WritePacket(pk.Marshal(
-		packetid.ClientboundConfigRegistryData,
-		pk.NBT(c.Registries),
+// AcceptConfig implement ConfigHandler for Configurations
+func (c *Configurations) AcceptConfig(conn *net.Conn) (info ClientInformation, err error) {
+	info = DefaultClientInformation()
+	brand := c.Brand
+	if brand == "" {
+		brand = "go-mc"
+	}
+	err = conn.WritePacket(pk.Marshal(
+		packetid.ClientboundConfigCustomPayload,
+		pk.Identifier("minecraft:brand"),
+		pk.String(brand),
 	))
 	if err != nil {
-		return err
+		return
+	}
+
+	if len(c.ServerLinks) > 0 {
+		err = conn.WritePacket(pk.Marshal(
+			packetid.ClientboundConfigServerLinks,
+			pk.Array(c.ServerLinks),
+		))
+		if err != nil {
+			return
+		}
+	}
+
+	features := c.EnabledFeatures
+	if features == nil {
+		features = []string{"minecraft:vanilla"}
+	}
+	featureIDs := make([]pk.Identifier, len(features))
+	for i, v := range features {
+		featureIDs[i] = pk.Identifier(v)
 	}
 	err = conn.WritePacket(pk.Marshal(
-		packetid.ClientboundConfigFinishConfiguration,
+		packetid.ClientboundConfigUpdateEnabledFeatures,
+		pk.Array(featureIDs),
 	))
-	return err
+	if err != nil {
+		return
+	}
+
+	if err = c.syncRegistries(conn, &info); err != nil {
+		return
+	}
+	if err = c.pushResourcePacks(conn, &info); err != nil {
+		return
+	}
+
+	err = conn.WritePacket(pk.Marshal(packetid.ClientboundConfigFinishConfiguration))
+	if err != nil {
+		return
+	}
+	var p pk.Packet
+	err = nextConfigPacket(conn, &info, &p)
+	if err == nil && packetid.ServerboundPacketID(p.ID) != packetid.ServerboundConfigFinishConfiguration {
+		err = wrongPacketErr{expect: int32(packetid.ServerboundConfigFinishConfiguration), get: p.ID}
+	}
+	return
+}
+
+// syncRegistries negotiates the known packs with the client, then sends the registries and their tags.
+func (c *Configurations) syncRegistries(conn *net.Conn, info *ClientInformation) error {
+	offered := c.KnownPacks
+	if offered == nil {
+		offered = c.Registries.KnownPacks()
+	}
+	err := conn.WritePacket(pk.Marshal(
+		packetid.ClientboundConfigSelectKnownPacks,
+		pk.Array(offered),
+	))
+	if err != nil {
+		return err
+	}
+	var p pk.Packet
+	if err := nextConfigPacket(conn, info, &p); err != nil {
+		return err
+	}
+	if packetid.ServerboundPacketID(p.ID) != packetid.ServerboundConfigSelectKnownPacks {
+		return wrongPacketErr{expect: int32(packetid.ServerboundConfigSelectKnownPacks), get: p.ID}
+	}
+	var clientPacks []registry.KnownPack
+	if err := p.Scan(pk.Array(&clientPacks)); err != nil {
+		return err
+	}
+	// Only the packs offered by us are trusted
+	known := slices.DeleteFunc(clientPacks, func(pack registry.KnownPack) bool {
+		return !slices.Contains(offered, pack)
+	})
+
+	var buf bytes.Buffer
+	c.Registries.Range(func(id string, r registry.RegistryCodec) {
+		if err != nil {
+			return
+		}
+		buf.Reset()
+		_, _ = pk.Identifier(id).WriteTo(&buf)
+		_, err = r.WriteKnownTo(&buf, known)
+		if err == nil {
+			err = conn.WritePacket(pk.Packet{ID: int32(packetid.ClientboundConfigRegistryData), Data: buf.Bytes()})
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	buf.Reset()
+	if _, err := c.Registries.WriteTagsTo(&buf); err != nil {
+		return err
+	}
+	return conn.WritePacket(pk.Packet{ID: int32(packetid.ClientboundConfigUpdateTags), Data: buf.Bytes()})
+}
+
+// pushResourcePacks sends the resource packs to the client, and waits until all of them are loaded or refused.
+func (c *Configurations) pushResourcePacks(conn *net.Conn, info *ClientInformation) error {
+	pending := make(map[uuid.UUID]ResourcePack, len(c.ResourcePacks))
+	for _, v := range c.ResourcePacks {
+		var prompt pk.OptionEncoder[chat.Message]
+		if v.PromptMessage != nil {
+			prompt = pk.OptionEncoder[chat.Message]{Has: true, Val: *v.PromptMessage}
+		}
+		err := conn.WritePacket(pk.Marshal(
+			packetid.ClientboundConfigResourcePackPush,
+			pk.UUID(v.ID),
+			pk.String(v.URL),
+			pk.String(v.Hash),
+			pk.Boolean(v.Forced),
+			prompt,
+		))
+		if err != nil {
+			return err
+		}
+		pending[v.ID] = v
+	}
+
+	var p pk.Packet
+	for len(pending) > 0 {
+		if err := nextConfigPacket(conn, info, &p); err != nil {
+			return err
+		}
+		if packetid.ServerboundPacketID(p.ID) != packetid.ServerboundConfigResourcePack {
+			continue
+		}
+		var (
+			id     pk.UUID
+			status pk.VarInt
+		)
+		if err := p.Scan(&id, &status); err != nil {
+			return err
+		}
+		pack, ok := pending[uuid.UUID(id)]
+		if !ok {
+			continue
+		}
+		switch ResourcePackStatus(status) {
+		case ResourcePackLoaded:
+		case ResourcePackDeclined, ResourcePackFailedDownload, ResourcePackInvalidURL,
+			ResourcePackFailedReload, ResourcePackDiscarded:
+			if pack.Forced {
+				return ConfigFailErr{reason: chat.TranslateMsg("multiplayer.requiredTexturePrompt.disconnect")}
+			}
+		default: // not a final status
+			continue
+		}
+		delete(pending, pack.ID)
+	}
+	return nil
+}
+
+// nextConfigPacket reads the next packet which isn't handled here.
+// The client settings and brand are recorded in info.
+func nextConfigPacket(conn *net.Conn, info *ClientInformation, p *pk.Packet) error {
+	for {
+		if err := conn.ReadPacket(p); err != nil {
+			return err
+		}
+		switch packetid.ServerboundPacketID(p.ID) {
+		case packetid.ServerboundConfigClientInformation:
+			if _, err := info.ReadFrom(bytes.NewReader(p.Data)); err != nil {
+				return err
+			}
+		case packetid.ServerboundConfigCustomPayload:
+			var channel pk.Identifier
+			r := bytes.NewReader(p.Data)
+			if _, err := channel.ReadFrom(r); err != nil {
+				return err
+			}
+			if channel == "minecraft:brand" {
+				if _, err := (*pk.String)(&info.Brand).ReadFrom(r); err != nil {
+					return err
+				}
+			}
+		case packetid.ServerboundConfigKeepAlive, packetid.ServerboundConfigPong:
+		default:
+			return nil
+		}
+	}
+}
+
+// ClientInformation is the settings of the client, sent in both configuration and play phase.
+type ClientInformation struct {
+	Locale              string
+	ViewDistance        int8
+	ChatMode            int32 // 0: enabled, 1: commands only, 2: hidden
+	ChatColors          bool
+	DisplayedSkinParts  uint8
+	MainHand            int32 // 0: left, 1: right
+	EnableTextFiltering bool
+	AllowServerListings bool
+
+	// Brand is the client brand sent by "minecraft:brand" custom payload, not a part of the packet.
+	Brand string
+}
+
+// DefaultClientInformation returns the settings used by the vanilla server
+// before the client sends its ClientInformation packet.
+func DefaultClientInformation() ClientInformation {
+	return ClientInformation{
+		Locale:       "en_us",
+		ViewDistance: 2,
+		ChatColors:   true,
+		MainHand:     1,
+	}
+}
+
+func (c ClientInformation) WriteTo(w io.Writer) (int64, error) {
+	return pk.Tuple{
+		pk.String(c.Locale),
+		pk.Byte(c.ViewDistance),
+		pk.VarInt(c.ChatMode),
+		pk.Boolean(c.ChatColors),
+		pk.UnsignedByte(c.DisplayedSkinParts),
+		pk.VarInt(c.MainHand),
+		pk.Boolean(c.EnableTextFiltering),
+		pk.Boolean(c.AllowServerListings),
+	}.WriteTo(w)
+}
+
+func (c *ClientInformation) ReadFrom(r io.Reader) (int64, error) {
+	return pk.Tuple{
+		(*pk.String)(&c.Locale),
+		(*pk.Byte)(&c.ViewDistance),
+		(*pk.VarInt)(&c.ChatMode),
+		(*pk.Boolean)(&c.ChatColors),
+		(*pk.UnsignedByte)(&c.DisplayedSkinParts),
+		(*pk.VarInt)(&c.MainHand),
+		(*pk.Boolean)(&c.EnableTextFiltering),
+		(*pk.Boolean)(&c.AllowServerListings),
+	}.ReadFrom(r)
+}
+
+// ResourcePack is a resource pack pushed to the client.
+type ResourcePack struct {
+	ID            uuid.UUID
+	URL           string
+	Hash          string // The hex-encoded SHA-1 hash of the pack file, can be empty
+	Forced        bool
+	PromptMessage *chat.Message // Optional
+}
+
+// ResourcePackStatus is the result of the resource pack reported by the client.
+type ResourcePackStatus int32
+
+const (
+	ResourcePackLoaded ResourcePackStatus = iota
+	ResourcePackDeclined
+	ResourcePackFailedDownload
+	ResourcePackAccepted
+	ResourcePackDownloaded
+	ResourcePackInvalidURL
+	ResourcePackFailedReload
+	ResourcePackDiscarded
+)
+
+// ServerLink is a link shown in the pause menu of the client.
+// Either Builtin or Label is used as the label of the link.
+type ServerLink struct {
+	// Builtin is one of the vanilla link types, used when Label is nil.
+	// 0: bug report, 1: community guidelines, 2: support, 3: status, 4: feedback,
+	// 5: community, 6: website, 7: forums, 8: news, 9: announcements.
+	Builtin int32
+	Label   *chat.Message
+	URL     string
+}
+
+func (s ServerLink) WriteTo(w io.Writer) (int64, error) {
+	var label pk.FieldEncoder = pk.VarInt(s.Builtin)
+	if s.Label != nil {
+		label = s.Label
+	}
+	return pk.Tuple{
+		pk.Boolean(s.Label == nil),
+		label,
+		pk.String(s.URL),
+	}.WriteTo(w)
+}
+
+func (s *ServerLink) ReadFrom(r io.Reader) (int64, error) {
+	var builtin pk.Boolean
+	n, err := builtin.ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+	var n1 int64
+	if builtin {
+		s.Label = nil
+		n1, err = (*pk.VarInt)(&s.Builtin).ReadFrom(r)
+	} else {
+		s.Label = new(chat.Message)
+		n1, err = s.Label.ReadFrom(r)
+	}
+	if err != nil {
+		return n + n1, err
+	}
+	n2, err := (*pk.String)(&s.URL).ReadFrom(r)
+	return n + n1 + n2, err
 }
 
 type ConfigFailErr struct {
@@ -0,0 +1,118 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/registry"
+	"github.com/Tnze/go-mc/server"
+)
+
+func TestConfigurations(t *testing.T) {
+	label := chat.Text("Home")
+	config := server.Configurations{
+		Registries: registry.Vanilla(),
+		ServerLinks: []server.ServerLink{
+			{Builtin: 6, URL: "https://example.com"},
+			{Label: &label, URL: "https://example.com/home"},
+		},
+	}
+	c := bot.NewClient()
+	info := acceptConfig(t, &config, c)
+
+	// The bot doesn't send its settings in the configuration phase
+	if info != server.DefaultClientInformation() {
+		t.Errorf("unexpected client information: %+v", info)
+	}
+	if id, dim := c.Registries.DimensionType.Get("minecraft:overworld"); id < 0 || dim.Height != 384 {
+		t.Errorf("registry data not received: %d %v", id, dim)
+	}
+	if len(c.Registries.DamageType.Tag("minecraft:is_fire")) == 0 {
+		t.Error("tags not received")
+	}
+	if !c.Registries.Block.IsInTag("minecraft:obsidian", "minecraft:needs_diamond_tool") {
+		t.Error("block tags not received")
+	}
+}
+
+// corePackHandler is a bot.ConfigHandler which knows the vanilla data pack
+type corePackHandler struct{ *bot.DefaultConfigHandler }
+
+func (corePackHandler) SelectDataPacks([]bot.DataPack) []bot.DataPack {
+	return []bot.DataPack{{
+		Namespace: registry.CorePack.Namespace,
+		ID:        registry.CorePack.ID,
+		Version:   registry.CorePack.Version,
+	}}
+}
+
+func TestConfigurations_knownPacks(t *testing.T) {
+	registries := registry.Vanilla()
+	_, overworld := registries.DimensionType.Get("minecraft:overworld")
+	custom := *overworld
+	custom.Height = 128
+	registries.DimensionType.Put("go-mc:custom", custom)
+	config := server.Configurations{Registries: registries}
+
+	c := bot.NewClient()
+	c.Registries = registry.Vanilla()
+	c.ConfigHandler = corePackHandler{bot.NewDefaultConfigHandler()}
+	acceptConfig(t, &config, c)
+
+	for _, key := range []string{"minecraft:the_nether", "go-mc:custom"} {
+		id1, want := registries.DimensionType.Get(key)
+		id2, got := c.Registries.DimensionType.Get(key)
+		if id1 != id2 || got == nil || got.Height != want.Height {
+			t.Errorf("entry %s: got %d %v, want %d %v", key, id2, got, id1, want)
+		}
+	}
+}
+
+// acceptConfig lets the bot join a server running the configuration phase with config.
+func acceptConfig(t *testing.T, config server.ConfigHandler, c *bot.Client) server.ClientInformation {
+	l, err := mcnet.ListenMC("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	type result struct {
+		info server.ClientInformation
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		var handshake pk.Packet
+		if err := conn.ReadPacket(&handshake); err != nil {
+			results <- result{err: err}
+			return
+		}
+		login := server.MojangLoginHandler{Threshold: -1}
+		if _, _, _, _, err := login.AcceptLogin(&conn, bot.ProtocolVersion); err != nil {
+			results <- result{err: err}
+			return
+		}
+		info, err := config.AcceptConfig(&conn)
+		results <- result{info, err}
+	}()
+
+	joinErr := c.JoinServer(l.Addr().String())
+	res := <-results
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if joinErr != nil {
+		t.Fatalf("join server: %v", joinErr)
+	}
+	_ = c.Close()
+	return res.info
+}
@@ -0,0 +1,462 @@
+package server
+
+import (
+	"math"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/data/registryid"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/server/bvh"
+)
+
+// Entity is the information of an entity needed by the clients to display it.
+type Entity struct {
+	ID       int32
+	UUID     uuid.UUID
+	Type     int32 // The protocol ID of the entity type, see [registryid.EntityType]
+	Data     int32 // The data field of AddEntity packet, meaning depends on the Type
+	Pos      Pos
+	Rot      Rot
+	OnGround bool
+}
+
+type EntityViewer interface {
+	WritePacket(p pk.Packet) error
+}
+
+type (
+	trackerVec  = bvh.Vec2[float64] // the X and Z of a position
+	trackerAABB = bvh.AABB[float64, trackerVec]
+)
+
+// EntityTracker tracks the entities and the viewers (usually the players),
+// to tell which viewers can see an entity.
+//
+// An entity can be seen by a viewer if their horizontal distance is in the entity's tracking range,
+// which is also limited by the viewer's view distance.
+// When an entity or a viewer moves, the tracker sends AddEntity and RemoveEntities packets
+// to the viewers that start or stop seeing the entity, and the movement packets to the others.
+//
+// The entities and the viewers are indexed by bounding volume hierarchies,
+// so only the nearby ones are checked when something moves.
+// It's safe for concurrent use.
+type EntityTracker struct {
+	// TrackingRange returns the tracking range in blocks of the entity type.
+	// If nil, [EntityTrackingRange] is used.
+	TrackingRange func(typ int32) float64
+
+	lock       sync.Mutex
+	entities   map[int32]*trackedEntity
+	entityTree bvh.Tree[float64, trackerAABB, *trackedEntity]
+	viewers    map[EntityViewer]*entityViewer
+	viewerTree bvh.Tree[float64, trackerAABB, *entityViewer]
+}
+
+type trackedEntity struct {
+	Entity
+	node *bvh.Node[float64, trackerAABB, *trackedEntity]
+	// the position last sent to the viewers, in 1/4096 blocks
+	encoded [3]int64
+	viewers map[*entityViewer]struct{}
+}
+
+type entityViewer struct {
+	EntityViewer
+	self         int32
+	pos          Pos
+	viewDistance int32
+	node         *bvh.Node[float64, trackerAABB, *entityViewer]
+	visible      map[*trackedEntity]struct{}
+}
+
+func NewEntityTracker() *EntityTracker {
+	return &EntityTracker{
+		entities: make(map[int32]*trackedEntity),
+		viewers:  make(map[EntityViewer]*entityViewer),
+	}
+}
+
+// AddEntity starts tracking the entity, and shows it to the viewers in range.
+// If an entity with the same ID exists, it's replaced.
+func (t *EntityTracker) AddEntity(e Entity) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if old, ok := t.entities[e.ID]; ok {
+		t.removeEntity(old)
+	}
+	te := &trackedEntity{
+		Entity:  e,
+		encoded: encodePos(e.Pos),
+		viewers: make(map[*entityViewer]struct{}),
+	}
+	te.node = t.entityTree.Insert(pointBox(e.Pos), te)
+	t.entities[e.ID] = te
+	t.updateEntity(te, nil)
+}
+
+// RemoveEntity stops tracking the entity, and removes it from its viewers.
+func (t *EntityTracker) RemoveEntity(id int32) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if e, ok := t.entities[id]; ok {
+		t.removeEntity(e)
+	}
+}
+
+func (t *EntityTracker) removeEntity(e *trackedEntity) {
+	removeEntities := pk.Marshal(packetid.ClientboundRemoveEntities, pk.Array([]pk.VarInt{pk.VarInt(e.ID)}))
+	for v := range e.viewers {
+		_ = v.WritePacket(removeEntities)
+		delete(v.visible, e)
+	}
+	t.entityTree.Delete(e.node)
+	delete(t.entities, e.ID)
+}
+
+// MoveEntity updates the position and rotation of the entity.
+// The viewers already seeing the entity receive the movement, the others in range receive an AddEntity.
+func (t *EntityTracker) MoveEntity(id int32, pos Pos, rot Rot, onGround bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	e, ok := t.entities[id]
+	if !ok {
+		return
+	}
+	moved := pos.X != e.Pos.X || pos.Z != e.Pos.Z
+	movement, ok := e.move(pos, rot, onGround)
+	if !ok {
+		return
+	}
+	if moved {
+		t.entityTree.Delete(e.node)
+		e.node = t.entityTree.Insert(pointBox(pos), e)
+	}
+	t.updateEntity(e, &movement)
+}
+
+// move updates the entity and returns the packet telling the movement.
+// The ok is false if nothing needs to be sent.
+func (e *trackedEntity) move(pos Pos, rot Rot, onGround bool) (p pk.Packet, ok bool) {
+	encoded := encodePos(pos)
+	delta := [3]int64{encoded[0] - e.encoded[0], encoded[1] - e.encoded[1], encoded[2] - e.encoded[2]}
+	moved := delta != [3]int64{}
+	rotated := toAngle(rot.Yaw) != toAngle(e.Rot.Yaw) || toAngle(rot.Pitch) != toAngle(e.Rot.Pitch)
+	ok = moved || rotated || onGround != e.OnGround
+	e.Pos, e.Rot, e.OnGround = pos, rot, onGround
+
+	switch {
+	case delta[0] < math.MinInt16 || delta[0] > math.MaxInt16 ||
+		delta[1] < math.MinInt16 || delta[1] > math.MaxInt16 ||
+		delta[2] < math.MinInt16 || delta[2] > math.MaxInt16:
+		// too far to be sent as a delta
+		p = pk.Marshal(
+			packetid.ClientboundTeleportEntity,
+			pk.VarInt(e.ID),
+			pk.Double(pos.X), pk.Double(pos.Y), pk.Double(pos.Z),
+			toAngle(rot.Yaw), toAngle(rot.Pitch),
+			pk.Boolean(onGround),
+		)
+	case moved && rotated:
+		p = pk.Marshal(
+			packetid.ClientboundMoveEntityPosRot,
+			pk.VarInt(e.ID),
+			pk.Short(delta[0]), pk.Short(delta[1]), pk.Short(delta[2]),
+			toAngle(rot.Yaw), toAngle(rot.Pitch),
+			pk.Boolean(onGround),
+		)
+	case moved:
+		p = pk.Marshal(
+			packetid.ClientboundMoveEntityPos,
+			pk.VarInt(e.ID),
+			pk.Short(delta[0]), pk.Short(delta[1]), pk.Short(delta[2]),
+			pk.Boolean(onGround),
+		)
+	default: // rotated, or only the onGround changed
+		p = pk.Marshal(
+			packetid.ClientboundMoveEntityRot,
+			pk.VarInt(e.ID),
+			toAngle(rot.Yaw), toAngle(rot.Pitch),
+			pk.Boolean(onGround),
+		)
+	}
+	e.encoded = encoded
+	return
+}
+
+// updateEntity finds the viewers of the entity after it moved,
+// and sends the movement to the viewers which have been seeing it.
+// The movement is nil for a new entity.
+func (t *EntityTracker) updateEntity(e *trackedEntity, movement *pk.Packet) {
+	seeing := make(map[*entityViewer]struct{})
+	t.viewerTree.Find(
+		bvh.TouchPoint[trackerVec, trackerAABB](trackerVec{e.Pos.X, e.Pos.Z}),
+		func(n *bvh.Node[float64, trackerAABB, *entityViewer]) bool {
+			if t.canSee(n.Value, e) {
+				seeing[n.Value] = struct{}{}
+			}
+			return true
+		},
+	)
+	for v := range e.viewers {
+		if _, ok := seeing[v]; !ok {
+			t.hide(v, e)
+		} else if movement != nil {
+			_ = v.WritePacket(*movement)
+			if movement.ID != int32(packetid.ClientboundMoveEntityPos) {
+				_ = v.WritePacket(rotateHead(e))
+			}
+		}
+	}
+	for v := range seeing {
+		if _, ok := e.viewers[v]; !ok {
+			t.show(v, e)
+		}
+	}
+}
+
+// Viewers returns the viewers which can see the entity.
+func (t *EntityTracker) Viewers(id int32) []EntityViewer {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	e, ok := t.entities[id]
+	if !ok {
+		return nil
+	}
+	viewers := make([]EntityViewer, 0, len(e.viewers))
+	for v := range e.viewers {
+		viewers = append(viewers, v.EntityViewer)
+	}
+	return viewers
+}
+
+// AddViewer starts showing the entities in range to the viewer.
+// The self is the entity ID of the viewer itself, which is never shown to the viewer.
+// Use -1 if the viewer isn't an entity.
+// The viewDistance is in chunks, same as the client's view distance.
+func (t *EntityTracker) AddViewer(v EntityViewer, self int32, pos Pos, viewDistance int32) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if old, ok := t.viewers[v]; ok {
+		t.removeViewer(old)
+	}
+	ev := &entityViewer{
+		EntityViewer: v,
+		self:         self,
+		pos:          pos,
+		viewDistance: viewDistance,
+		visible:      make(map[*trackedEntity]struct{}),
+	}
+	ev.node = t.viewerTree.Insert(ev.box(), ev)
+	t.viewers[v] = ev
+	t.updateViewer(ev)
+}
+
+// RemoveViewer stops tracking the viewer.
+// No packet is sent to the viewer, because it's usually disconnected.
+func (t *EntityTracker) RemoveViewer(v EntityViewer) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if ev, ok := t.viewers[v]; ok {
+		t.removeViewer(ev)
+	}
+}
+
+func (t *EntityTracker) removeViewer(v *entityViewer) {
+	for e := range v.visible {
+		delete(e.viewers, v)
+	}
+	t.viewerTree.Delete(v.node)
+	delete(t.viewers, v.EntityViewer)
+}
+
+// MoveViewer updates the position of the viewer.
+// The entities leaving its range are removed, and the entities entering are added.
+// Call it when the player moves, in addition to [EntityTracker.MoveEntity].
+func (t *EntityTracker) MoveViewer(v EntityViewer, pos Pos) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if ev, ok := t.viewers[v]; ok && ev.pos != pos {
+		ev.pos = pos
+		t.viewerTree.Delete(ev.node)
+		ev.node = t.viewerTree.Insert(ev.box(), ev)
+		t.updateViewer(ev)
+	}
+}
+
+// SetViewDistance updates the view distance of the viewer.
+func (t *EntityTracker) SetViewDistance(v EntityViewer, viewDistance int32) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if ev, ok := t.viewers[v]; ok && ev.viewDistance != viewDistance {
+		ev.viewDistance = viewDistance
+		t.viewerTree.Delete(ev.node)
+		ev.node = t.viewerTree.Insert(ev.box(), ev)
+		t.updateViewer(ev)
+	}
+}
+
+// updateViewer shows and hides the entities for the viewer after it moved.
+func (t *EntityTracker) updateViewer(v *entityViewer) {
+	for e := range v.visible {
+		if !t.canSee(v, e) {
+			t.hide(v, e)
+		}
+	}
+	t.entityTree.Find(
+		bvh.TouchBound(v.box()),
+		func(n *bvh.Node[float64, trackerAABB, *trackedEntity]) bool {
+			if _, ok := v.visible[n.Value]; !ok && t.canSee(v, n.Value) {
+				t.show(v, n.Value)
+			}
+			return true
+		},
+	)
+}
+
+func (t *EntityTracker) show(v *entityViewer, e *trackedEntity) {
+	e.viewers[v] = struct{}{}
+	v.visible[e] = struct{}{}
+	_ = v.WritePacket(pk.Marshal(
+		packetid.ClientboundAddEntity,
+		pk.VarInt(e.ID),
+		pk.UUID(e.UUID),
+		pk.VarInt(e.Type),
+		pk.Double(e.Pos.X), pk.Double(e.Pos.Y), pk.Double(e.Pos.Z),
+		toAngle(e.Rot.Pitch), toAngle(e.Rot.Yaw), toAngle(e.Rot.Yaw),
+		pk.VarInt(e.Data),
+		pk.Short(0), pk.Short(0), pk.Short(0), // velocity
+	))
+}
+
+func (t *EntityTracker) hide(v *entityViewer, e *trackedEntity) {
+	delete(e.viewers, v)
+	delete(v.visible, e)
+	_ = v.WritePacket(pk.Marshal(packetid.ClientboundRemoveEntities, pk.Array([]pk.VarInt{pk.VarInt(e.ID)})))
+}
+
+func (t *EntityTracker) canSee(v *entityViewer, e *trackedEntity) bool {
+	if e.ID == v.self {
+		return false
+	}
+	trackingRange := EntityTrackingRange
+	if t.TrackingRange != nil {
+		trackingRange = t.TrackingRange
+	}
+	r := min(trackingRange(e.Type), v.maxRange())
+	dx, dz := e.Pos.X-v.pos.X, e.Pos.Z-v.pos.Z
+	return dx*dx+dz*dz <= r*r
+}
+
+// maxRange is the max distance in blocks of the entities the viewer can see.
+func (v *entityViewer) maxRange() float64 {
+	return float64(v.viewDistance) * 16
+}
+
+// box is the area of the entities the viewer can see.
+// It's a little larger than the range, because the bounds of AABB is exclusive.
+func (v *entityViewer) box() trackerAABB {
+	r := v.maxRange() + 1
+	return trackerAABB{
+		Upper: trackerVec{v.pos.X + r, v.pos.Z + r},
+		Lower: trackerVec{v.pos.X - r, v.pos.Z - r},
+	}
+}
+
+func pointBox(pos Pos) trackerAABB {
+	return trackerAABB{Upper: trackerVec{pos.X, pos.Z}, Lower: trackerVec{pos.X, pos.Z}}
+}
+
+func encodePos(pos Pos) [3]int64 {
+	return [3]int64{
+		int64(math.Round(pos.X * 4096)),
+		int64(math.Round(pos.Y * 4096)),
+		int64(math.Round(pos.Z * 4096)),
+	}
+}
+
+func rotateHead(e *trackedEntity) pk.Packet {
+	return pk.Marshal(packetid.ClientboundRotateHead, pk.VarInt(e.ID), toAngle(e.Rot.Yaw))
+}
+
+func toAngle(deg float32) pk.Angle {
+	return pk.Angle(int(deg * 256 / 360))
+}
+
+// EntityTrackingRange returns the vanilla tracking range in blocks of the entity type.
+func EntityTrackingRange(typ int32) float64 {
+	if typ >= 0 && int(typ) < len(entityTrackingRanges) {
+		return float64(entityTrackingRanges[typ]) * 16
+	}
+	return defaultEntityTrackingRange * 16
+}
+
+// defaultEntityTrackingRange is the tracking range in chunks of the entities not listed below,
+// which is the range of most hostile mobs.
+const defaultEntityTrackingRange = 8
+
+// entityTrackingRanges is the tracking ranges in chunks, indexed by the protocol ID of entity types.
+var entityTrackingRanges = func() []int8 {
+	ranges := map[string]int8{
+		"minecraft:player":             32,
+		"minecraft:end_crystal":        16,
+		"minecraft:lightning_bolt":     16,
+		"minecraft:ender_dragon":       10,
+		"minecraft:wither":             10,
+		"minecraft:ghast":              10,
+		"minecraft:falling_block":      10,
+		"minecraft:tnt":                10,
+		"minecraft:item_frame":         10,
+		"minecraft:glow_item_frame":    10,
+		"minecraft:painting":           10,
+		"minecraft:leash_knot":         10,
+		"minecraft:armor_stand":        10,
+		"minecraft:boat":               10,
+		"minecraft:chest_boat":         10,
+		"minecraft:area_effect_cloud":  10,
+		"minecraft:cow":                10,
+		"minecraft:pig":                10,
+		"minecraft:sheep":              10,
+		"minecraft:chicken":            10,
+		"minecraft:horse":              10,
+		"minecraft:villager":           10,
+		"minecraft:wandering_trader":   10,
+		"minecraft:iron_golem":         10,
+		"minecraft:item":               6,
+		"minecraft:experience_orb":     6,
+		"minecraft:evoker_fangs":       6,
+		"minecraft:arrow":              4,
+		"minecraft:spectral_arrow":     4,
+		"minecraft:trident":            4,
+		"minecraft:snowball":           4,
+		"minecraft:egg":                4,
+		"minecraft:ender_pearl":        4,
+		"minecraft:experience_bottle":  4,
+		"minecraft:potion":             4,
+		"minecraft:eye_of_ender":       4,
+		"minecraft:firework_rocket":    4,
+		"minecraft:fireball":           4,
+		"minecraft:small_fireball":     4,
+		"minecraft:dragon_fireball":    4,
+		"minecraft:wither_skull":       4,
+		"minecraft:llama_spit":         4,
+		"minecraft:fishing_bobber":     4,
+		"minecraft:wind_charge":        4,
+		"minecraft:breeze_wind_charge": 4,
+		"minecraft:cod":                4,
+		"minecraft:salmon":             4,
+		"minecraft:tropical_fish":      4,
+		"minecraft:pufferfish":         4,
+	}
+	list := make([]int8, len(registryid.EntityType))
+	for i, name := range registryid.EntityType {
+		if r, ok := ranges[name]; ok {
+			list[i] = r
+		} else {
+			list[i] = defaultEntityTrackingRange
+		}
+	}
+	return list
+}()
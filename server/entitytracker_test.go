@@ -0,0 +1,68 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/data/registryid"
+)
+
+func TestEntityTracker(t *testing.T) {
+	var cow int32
+	for i, v := range registryid.EntityType {
+		if v == "minecraft:cow" {
+			cow = int32(i)
+		}
+	}
+
+	tracker := NewEntityTracker()
+	near, far := new(testClient), new(testClient)
+	tracker.AddViewer(near, 1, Pos{X: 0, Z: 0}, 10)
+	tracker.AddViewer(far, 2, Pos{X: 1000, Z: 0}, 10)
+
+	tracker.AddEntity(Entity{ID: 3, Type: cow, Pos: Pos{X: 10, Y: 64, Z: 10}})
+	if n := near.count(packetid.ClientboundAddEntity); n != 1 {
+		t.Errorf("near viewer received %d AddEntity, want 1", n)
+	}
+	if n := far.count(packetid.ClientboundAddEntity); n != 0 {
+		t.Errorf("far viewer received %d AddEntity, want 0", n)
+	}
+	if viewers := tracker.Viewers(3); len(viewers) != 1 || viewers[0] != near {
+		t.Errorf("viewers of the entity: %v", viewers)
+	}
+
+	// a small movement is sent as delta
+	tracker.MoveEntity(3, Pos{X: 11, Y: 64, Z: 10}, Rot{}, true)
+	if n := near.count(packetid.ClientboundMoveEntityPos); n != 1 {
+		t.Errorf("near viewer received %d MoveEntityPos, want 1", n)
+	}
+
+	// move to the far viewer, beyond the cow's tracking range of the near viewer
+	tracker.MoveEntity(3, Pos{X: 990, Y: 64, Z: 0}, Rot{}, true)
+	if n := near.count(packetid.ClientboundRemoveEntities); n != 1 {
+		t.Errorf("near viewer received %d RemoveEntities, want 1", n)
+	}
+	if n := far.count(packetid.ClientboundAddEntity); n != 1 {
+		t.Errorf("far viewer received %d AddEntity, want 1", n)
+	}
+
+	// the viewer walks to the entity
+	tracker.MoveViewer(near, Pos{X: 900, Z: 0})
+	if n := near.count(packetid.ClientboundAddEntity); n != 1 {
+		t.Errorf("near viewer received %d AddEntity after moved, want 1", n)
+	}
+
+	tracker.RemoveEntity(3)
+	if n := near.count(packetid.ClientboundRemoveEntities); n != 1 {
+		t.Errorf("near viewer received %d RemoveEntities, want 1", n)
+	}
+	if n := far.count(packetid.ClientboundRemoveEntities); n != 1 {
+		t.Errorf("far viewer received %d RemoveEntities, want 1", n)
+	}
+
+	// the viewer never sees itself
+	tracker.AddEntity(Entity{ID: 1, Pos: Pos{X: 900, Z: 0}})
+	if n := near.count(packetid.ClientboundAddEntity); n != 0 {
+		t.Errorf("viewer received %d AddEntity of itself", n)
+	}
+}
@@ -0,0 +1,84 @@
+package gameplay
+
+import (
+	"errors"
+	"math/bits"
+	"strings"
+
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/registry"
+	"github.com/Tnze/go-mc/save"
+	"github.com/Tnze/go-mc/save/region"
+)
+
+// chunkLoader reads chunks from a dimension of the world, which implements server.ChunkProvider.
+type chunkLoader struct {
+	world  *save.World
+	dim    string
+	minSec int32 // the Y of the lowest section
+	secs   int
+}
+
+func newChunkLoader(world *save.World, dim string, dimType *registry.Dimension) *chunkLoader {
+	return &chunkLoader{
+		world:  world,
+		dim:    dim,
+		minSec: dimType.MinY >> 4,
+		secs:   int(dimType.Height) / 16,
+	}
+}
+
+// Chunk reads the chunk at the pos.
+// An empty chunk is returned if the chunk isn't generated or not fully generated.
+func (l *chunkLoader) Chunk(pos level.ChunkPos) (*level.Chunk, error) {
+	c, err := l.world.LoadChunk(l.dim, int(pos[0]), int(pos[1]))
+	if errors.Is(err, region.ErrNoSector) {
+		return level.EmptyChunk(l.secs), nil
+	} else if err != nil {
+		return level.EmptyChunk(l.secs), err
+	}
+
+	if strings.TrimPrefix(c.Status, "minecraft:") != string(level.StatusFull) {
+		return level.EmptyChunk(l.secs), nil
+	}
+	chunk, err := level.ChunkFromSave(c)
+	if err != nil {
+		return nil, err
+	}
+	// The chunks upgraded from the versions before 1.18 start at y=0,
+	// fill the sections below with air.
+	if below := int(c.YPos - l.minSec); below > 0 {
+		chunk.Sections = append(level.EmptyChunk(below).Sections, chunk.Sections...)
+		hm := &chunk.HeightMaps
+		for _, v := range []**level.BitStorage{
+			&hm.WorldSurfaceWG, &hm.WorldSurface,
+			&hm.OceanFloorWG, &hm.OceanFloor,
+			&hm.MotionBlocking, &hm.MotionBlockingNoLeaves,
+		} {
+			*v = raiseHeightmap(*v, below*16, l.secs)
+		}
+	}
+	// The client expects exactly the number of sections of the dimension.
+	switch {
+	case len(chunk.Sections) > l.secs:
+		chunk.Sections = chunk.Sections[:l.secs]
+	case len(chunk.Sections) < l.secs:
+		chunk.Sections = append(chunk.Sections, level.EmptyChunk(l.secs-len(chunk.Sections)).Sections...)
+	}
+	return chunk, nil
+}
+
+// raiseHeightmap returns the heightmap of the chunk with secs sections,
+// whose heights are increased by n except the empty columns.
+func raiseHeightmap(hm *level.BitStorage, n, secs int) *level.BitStorage {
+	if hm == nil {
+		return nil
+	}
+	raised := level.NewBitStorage(bits.Len(uint(secs)*16+1), 16*16, nil)
+	for i := 0; i < 16*16; i++ {
+		if v := hm.Get(i); v > 0 {
+			raised.Set(i, v+n)
+		}
+	}
+	return raised
+}
@@ -0,0 +1,207 @@
+// Package gameplay is a minimal but working implementation of [server.GamePlay].
+//
+// The [Game] serves a read-only world loaded from a save directory.
+// Players joining the server can walk around the overworld, see each other and chat.
+// Nothing is saved back into the disk.
+//
+// It's also an example of how to write a GamePlay, which can be a start point for building your own.
+//
+//	registries := registry.Vanilla()
+//	playerList := server.NewPlayerList(20)
+//	game, err := gameplay.New("./world", &registries, playerList)
+//	if err != nil {
+//		panic(err)
+//	}
+//	go game.Run(context.Background())
+//	s := server.Server{
+//		ListPingHandler: ...,
+//		LoginHandler:    &server.MojangLoginHandler{Threshold: 256, LoginChecker: playerList},
+//		ConfigHandler:   &server.Configurations{Registries: registries},
+//		GamePlay:        game,
+//	}
+package gameplay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/registry"
+	"github.com/Tnze/go-mc/save"
+	"github.com/Tnze/go-mc/server"
+	"github.com/Tnze/go-mc/yggdrasil/user"
+)
+
+// Make sure Game implement server.GamePlay
+var _ server.GamePlay = (*Game)(nil)
+
+// Dimension is the only dimension served by the Game.
+const Dimension = "minecraft:overworld"
+
+// chunkCacheSize is the number of chunks kept in memory.
+const chunkCacheSize = 4096
+
+type Game struct {
+	// Logger is optional, set to nil to disable logging.
+	*log.Logger
+	// ViewDistance is the radius of chunks sent to the players.
+	ViewDistance int
+
+	registries    *registry.Registries
+	playerList    *server.PlayerList
+	keepAlive     *server.KeepAlive
+	level         save.LevelData
+	dimTypeID     int32
+	world         *save.World
+	chunkStream   *server.ChunkStream
+	entityTracker *server.EntityTracker
+
+	playersLock sync.Mutex
+	players     map[uuid.UUID]*player
+	nextEID     int32
+}
+
+// New opens the world in the dir, and creates the Game serving it.
+// The world is locked until Run returns.
+// The registries must be the same as the one sent in the configuration phase,
+// which is used to find the dimension type of the overworld.
+// The playerList is optional, and is updated when players join or leave.
+func New(dir string, registries *registry.Registries, playerList *server.PlayerList) (*Game, error) {
+	dimTypeID, dimType := registries.DimensionType.Get(Dimension)
+	if dimType == nil {
+		return nil, errors.New("dimension type " + Dimension + " not found in the registries")
+	}
+	world, err := save.OpenWorld(dir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := world.Level()
+	if err != nil {
+		_ = world.Close()
+		return nil, fmt.Errorf("read level.dat: %w", err)
+	}
+
+	chunks := newChunkLoader(world, Dimension, dimType)
+	g := &Game{
+		ViewDistance:  8,
+		registries:    registries,
+		playerList:    playerList,
+		keepAlive:     server.NewKeepAlive(),
+		level:         data.Data,
+		dimTypeID:     dimTypeID,
+		world:         world,
+		chunkStream:   server.NewChunkStream(server.NewChunkCache(chunks, chunkCacheSize)),
+		entityTracker: server.NewEntityTracker(),
+		players:       make(map[uuid.UUID]*player),
+	}
+	g.chunkStream.OnError = func(c server.ChunkStreamClient, pos level.ChunkPos, err error) {
+		if g.Logger != nil {
+			g.Printf("load chunk %v for player %s: %v", pos, c.(*player).name, err)
+		}
+	}
+	g.keepAlive.AddPlayerDelayUpdateHandler(func(c server.KeepAliveClient, delay time.Duration) {
+		p := c.(*player)
+		p.latency.Store(int32(delay.Milliseconds()))
+		g.broadcast(nil, playerLatencyUpdate(p.id, p.latency.Load()))
+	})
+	return g, nil
+}
+
+// Run runs the background tasks of the Game until the ctx is done.
+// The world is closed when Run returns.
+func (g *Game) Run(ctx context.Context) {
+	go g.chunkStream.Run(ctx)
+	g.keepAlive.Run(ctx)
+	_ = g.world.Close()
+}
+
+// AcceptPlayer implements server.GamePlay
+func (g *Game) AcceptPlayer(name string, id uuid.UUID, _ *user.PublicKey, properties []user.Property, _ int32, info server.ClientInformation, conn *net.Conn) {
+	g.playersLock.Lock()
+	if old, ok := g.players[id]; ok {
+		old.SendDisconnect(chat.TranslateMsg("multiplayer.disconnect.duplicate_login"))
+	}
+	g.nextEID++
+	p := &player{
+		g:            g,
+		conn:         conn,
+		name:         name,
+		id:           id,
+		eid:          g.nextEID,
+		properties:   properties,
+		viewDistance: g.clampViewDistance(int32(info.ViewDistance)),
+		pos:          server.Pos{X: float64(g.level.SpawnX) + 0.5, Y: float64(g.level.SpawnY), Z: float64(g.level.SpawnZ) + 0.5},
+		rot:          server.Rot{Yaw: g.level.SpawnAngle},
+	}
+	g.playersLock.Unlock()
+
+	if g.playerList != nil {
+		g.playerList.ClientJoin(p, server.PlayerSample{Name: name, ID: id})
+		defer g.playerList.ClientLeft(p)
+	}
+
+	err := p.join()
+	if err == nil {
+		g.keepAlive.ClientJoin(p)
+		err = p.handlePackets()
+		g.keepAlive.ClientLeft(p)
+	}
+	g.chunkStream.ClientLeft(p)
+	g.entityTracker.RemoveViewer(p)
+	g.leave(p)
+	if err != nil && g.Logger != nil {
+		g.Printf("player %s (%v) left: %v", name, id, err)
+	}
+}
+
+// clampViewDistance limits the view distance requested by a client to the server's ViewDistance.
+func (g *Game) clampViewDistance(distance int32) int32 {
+	return min(max(distance, 2), int32(g.ViewDistance))
+}
+
+// broadcast sends the packet to all players except the given one, which can be nil.
+func (g *Game) broadcast(except *player, p pk.Packet) {
+	g.playersLock.Lock()
+	defer g.playersLock.Unlock()
+	for _, player := range g.players {
+		if player != except {
+			_ = player.WritePacket(p)
+		}
+	}
+}
+
+// leave removes the player from the game and tells other players.
+func (g *Game) leave(p *player) {
+	g.playersLock.Lock()
+	if !p.joined {
+		g.playersLock.Unlock()
+		return
+	}
+	// The player might have been replaced by a duplicate login,
+	// in which case only the entity is removed.
+	replaced := g.players[p.id] != p
+	if !replaced {
+		delete(g.players, p.id)
+	}
+	g.playersLock.Unlock()
+
+	g.entityTracker.RemoveEntity(p.eid)
+	if !replaced {
+		g.broadcast(p, pk.Marshal(packetid.ClientboundPlayerInfoRemove, pk.Array([]pk.UUID{pk.UUID(p.id)})))
+		g.broadcast(p, systemChat(chat.TranslateMsg("multiplayer.player.left", chat.Text(p.name)).SetColor(chat.Yellow)))
+	}
+}
+
+func systemChat(msg chat.Message) pk.Packet {
+	return pk.Marshal(packetid.ClientboundSystemChat, msg, pk.Boolean(false))
+}
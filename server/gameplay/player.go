@@ -0,0 +1,334 @@
+package gameplay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	"github.com/Tnze/go-mc/data/registryid"
+	"github.com/Tnze/go-mc/level"
+	"github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/server"
+	"github.com/Tnze/go-mc/yggdrasil/user"
+)
+
+// playerEntityType is the protocol ID of minecraft:player
+var playerEntityType = func() int32 {
+	for i, v := range registryid.EntityType {
+		if v == "minecraft:player" {
+			return int32(i)
+		}
+	}
+	panic("entity type minecraft:player not found")
+}()
+
+type player struct {
+	g          *Game
+	conn       *net.Conn
+	name       string
+	id         uuid.UUID
+	eid        int32
+	properties []user.Property
+	joined     bool
+
+	viewDistance int32 // the view distance of the client, limited by Game.ViewDistance
+
+	sendLock sync.Mutex
+
+	// The following fields are only accessed by the player's own goroutine.
+	pos        server.Pos
+	rot        server.Rot
+	teleportID int32
+	teleported bool // whether the last teleport is confirmed by the client
+
+	latency atomic.Int32 // in milliseconds, updated by the KeepAlive
+}
+
+// WritePacket sends the packet to the client. It's safe for concurrent use.
+func (p *player) WritePacket(packet pk.Packet) error {
+	p.sendLock.Lock()
+	defer p.sendLock.Unlock()
+	return p.conn.WritePacket(packet)
+}
+
+// SendKeepAlive implements server.KeepAliveClient
+func (p *player) SendKeepAlive(id int64) {
+	_ = p.WritePacket(pk.Marshal(packetid.ClientboundKeepAlive, pk.Long(id)))
+}
+
+// SendDisconnect implements server.KeepAliveClient and server.PlayerListClient
+func (p *player) SendDisconnect(reason chat.Message) {
+	_ = p.WritePacket(pk.Marshal(packetid.ClientboundDisconnect, reason))
+	_ = p.conn.Close()
+}
+
+// join sends everything the client needs to enter the world, and adds the player to the Game.
+func (p *player) join() error {
+	g := p.g
+	err := p.WritePacket(pk.Marshal(
+		packetid.ClientboundLogin,
+		pk.Int(p.eid),
+		pk.Boolean(g.level.HardCore),
+		pk.Array([]pk.Identifier{Dimension}),
+		pk.VarInt(maxPlayers(g.playerList)),
+		pk.VarInt(g.ViewDistance),
+		pk.VarInt(g.ViewDistance), // simulation distance
+		pk.Boolean(false),         // reduced debug info
+		pk.Boolean(true),          // enable respawn screen
+		pk.Boolean(false),         // do limited crafting
+		pk.VarInt(g.dimTypeID),
+		pk.Identifier(Dimension),
+		pk.Long(hashedSeed(g.level.WorldGenSettings.Seed)),
+		pk.UnsignedByte(g.level.GameType),
+		pk.Byte(-1),       // previous gamemode
+		pk.Boolean(false), // is debug
+		pk.Boolean(false), // is flat
+		pk.Boolean(false), // has death location
+		pk.VarInt(0),      // portal cooldown
+		pk.Boolean(false), // enforces secure chat
+	))
+	if err != nil {
+		return err
+	}
+	err = p.WritePacket(pk.Marshal(
+		packetid.ClientboundSetDefaultSpawnPosition,
+		pk.Position{X: int(g.level.SpawnX), Y: int(g.level.SpawnY), Z: int(g.level.SpawnZ)},
+		pk.Float(g.level.SpawnAngle),
+	))
+	if err != nil {
+		return err
+	}
+	err = p.WritePacket(pk.Marshal(packetid.ClientboundSetTime, pk.Long(g.level.Time), pk.Long(g.level.DayTime)))
+	if err != nil {
+		return err
+	}
+	// Start waiting for level chunks
+	err = p.WritePacket(pk.Marshal(packetid.ClientboundGameEvent, pk.UnsignedByte(13), pk.Float(0)))
+	if err != nil {
+		return err
+	}
+
+	// Tell each other's information
+	g.playersLock.Lock()
+	g.players[p.id] = p
+	p.joined = true
+	others := make([]*player, 0, len(g.players))
+	for _, v := range g.players {
+		if v != p {
+			others = append(others, v)
+		}
+	}
+	g.playersLock.Unlock()
+
+	// The player info must be sent before the player entities are spawned by the EntityTracker
+	if err := p.WritePacket(playerInfoUpdate(append(others, p)...)); err != nil {
+		return err
+	}
+	g.broadcast(p, playerInfoUpdate(p))
+	g.entityTracker.AddEntity(server.Entity{ID: p.eid, UUID: p.id, Type: playerEntityType, Pos: p.pos, Rot: p.rot})
+	g.entityTracker.AddViewer(p, p.eid, p.pos, p.viewDistance)
+
+	// The chunks around the player are sent by the ChunkStream
+	g.chunkStream.ClientJoin(p, chunkPosAt(p.pos), p.viewDistance)
+	if err := p.teleport(); err != nil {
+		return err
+	}
+
+	g.broadcast(nil, systemChat(chat.TranslateMsg("multiplayer.player.joined", chat.Text(p.name)).SetColor(chat.Yellow)))
+	return nil
+}
+
+// handlePackets reads the packets from the client until the connection is closed.
+func (p *player) handlePackets() error {
+	var packet pk.Packet
+	for {
+		if err := p.conn.ReadPacket(&packet); err != nil {
+			return err
+		}
+		var err error
+		switch packetid.ServerboundPacketID(packet.ID) {
+		case packetid.ServerboundKeepAlive:
+			p.g.keepAlive.ClientTick(p)
+		case packetid.ServerboundChunkBatchReceived:
+			var desiredChunksPerTick pk.Float
+			err = packet.Scan(&desiredChunksPerTick)
+			p.g.chunkStream.BatchReceived(p, float32(desiredChunksPerTick))
+		case packetid.ServerboundClientInformation:
+			err = p.handleClientInformation(packet)
+		case packetid.ServerboundAcceptTeleportation:
+			err = p.handleAcceptTeleportation(packet)
+		case packetid.ServerboundMovePlayerPos,
+			packetid.ServerboundMovePlayerPosRot,
+			packetid.ServerboundMovePlayerRot,
+			packetid.ServerboundMovePlayerStatusOnly:
+			err = p.handleMovePlayer(packet)
+		case packetid.ServerboundChat:
+			err = p.handleChat(packet)
+		case packetid.ServerboundChatCommand:
+			err = p.handleChatCommand(packet)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *player) teleport() error {
+	p.teleportID++
+	p.teleported = false
+	return p.WritePacket(pk.Marshal(
+		packetid.ClientboundPlayerPosition,
+		pk.Double(p.pos.X), pk.Double(p.pos.Y), pk.Double(p.pos.Z),
+		pk.Float(p.rot.Yaw), pk.Float(p.rot.Pitch),
+		pk.Byte(0), // all absolute
+		pk.VarInt(p.teleportID),
+	))
+}
+
+func (p *player) handleAcceptTeleportation(packet pk.Packet) error {
+	var id pk.VarInt
+	if err := packet.Scan(&id); err != nil {
+		return err
+	}
+	if int32(id) == p.teleportID {
+		p.teleported = true
+	}
+	return nil
+}
+
+func (p *player) handleMovePlayer(packet pk.Packet) error {
+	var (
+		x, y, z    pk.Double
+		yaw, pitch pk.Float
+		onGround   pk.Boolean
+		err        error
+	)
+	x, y, z = pk.Double(p.pos.X), pk.Double(p.pos.Y), pk.Double(p.pos.Z)
+	yaw, pitch = pk.Float(p.rot.Yaw), pk.Float(p.rot.Pitch)
+
+	switch packetid.ServerboundPacketID(packet.ID) {
+	case packetid.ServerboundMovePlayerPos:
+		err = packet.Scan(&x, &y, &z, &onGround)
+	case packetid.ServerboundMovePlayerPosRot:
+		err = packet.Scan(&x, &y, &z, &yaw, &pitch, &onGround)
+	case packetid.ServerboundMovePlayerRot:
+		err = packet.Scan(&yaw, &pitch, &onGround)
+	case packetid.ServerboundMovePlayerStatusOnly:
+		err = packet.Scan(&onGround)
+	}
+	if err != nil {
+		return err
+	}
+	// Movements are ignored until the client confirms the teleportation
+	if !p.teleported {
+		return nil
+	}
+	if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) ||
+		math.IsNaN(float64(y)) || math.IsInf(float64(y), 0) ||
+		math.IsNaN(float64(z)) || math.IsInf(float64(z), 0) {
+		return errors.New("invalid player movement")
+	}
+
+	p.pos = server.Pos{X: float64(x), Y: float64(y), Z: float64(z)}
+	p.rot = server.Rot{Yaw: float32(yaw), Pitch: float32(pitch)}
+	p.g.entityTracker.MoveEntity(p.eid, p.pos, p.rot, bool(onGround))
+	p.g.entityTracker.MoveViewer(p, p.pos)
+	p.g.chunkStream.SetCenter(p, chunkPosAt(p.pos))
+	return nil
+}
+
+func (p *player) handleClientInformation(packet pk.Packet) error {
+	var info server.ClientInformation
+	if err := packet.Scan(&info); err != nil {
+		return err
+	}
+	p.viewDistance = p.g.clampViewDistance(int32(info.ViewDistance))
+	p.g.chunkStream.SetViewDistance(p, p.viewDistance)
+	p.g.entityTracker.SetViewDistance(p, p.viewDistance)
+	return nil
+}
+
+func (p *player) handleChat(packet pk.Packet) error {
+	var msg pk.String
+	if err := packet.Scan(&msg); err != nil {
+		return err
+	}
+	if p.g.Logger != nil {
+		p.g.Printf("<%s> %s", p.name, msg)
+	}
+	p.g.broadcast(nil, systemChat(chat.TranslateMsg("chat.type.text", chat.Text(p.name), chat.Text(string(msg)))))
+	return nil
+}
+
+func (p *player) handleChatCommand(packet pk.Packet) error {
+	var command pk.String
+	if err := packet.Scan(&command); err != nil {
+		return err
+	}
+	return p.WritePacket(systemChat(chat.TranslateMsg("command.unknown.command").SetColor(chat.Red)))
+}
+
+// playerInfoUpdate creates the PlayerInfoUpdate packet which adds the players to the tab list.
+func playerInfoUpdate(players ...*player) pk.Packet {
+	actions := pk.NewFixedBitSet(6)
+	actions.Set(0, true) // add player
+	actions.Set(2, true) // update gamemode
+	actions.Set(3, true) // update listed
+	actions.Set(4, true) // update latency
+
+	var buf bytes.Buffer
+	_, _ = actions.WriteTo(&buf)
+	_, _ = pk.VarInt(len(players)).WriteTo(&buf)
+	for _, v := range players {
+		_, _ = pk.Tuple{
+			pk.UUID(v.id),
+			pk.String(v.name),
+			pk.Array(v.properties),
+			pk.VarInt(v.g.level.GameType),
+			pk.Boolean(true),
+			pk.VarInt(v.latency.Load()),
+		}.WriteTo(&buf)
+	}
+	return pk.Packet{ID: int32(packetid.ClientboundPlayerInfoUpdate), Data: buf.Bytes()}
+}
+
+// playerLatencyUpdate creates the PlayerInfoUpdate packet which updates the latency of the player.
+func playerLatencyUpdate(id uuid.UUID, latency int32) pk.Packet {
+	actions := pk.NewFixedBitSet(6)
+	actions.Set(4, true)
+	return pk.Marshal(
+		packetid.ClientboundPlayerInfoUpdate,
+		actions,
+		pk.VarInt(1),
+		pk.UUID(id),
+		pk.VarInt(latency),
+	)
+}
+
+func chunkPosAt(pos server.Pos) level.ChunkPos {
+	return level.ChunkPos{int32(math.Floor(pos.X)) >> 4, int32(math.Floor(pos.Z)) >> 4}
+}
+
+// hashedSeed returns the first 8 bytes of the SHA-256 hash of the seed, which is sent to the client.
+func hashedSeed(seed int64) int64 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(seed))
+	sum := sha256.Sum256(b[:])
+	return int64(binary.LittleEndian.Uint64(sum[:8]))
+}
+
+func maxPlayers(list *server.PlayerList) int {
+	if list == nil {
+		return 20
+	}
+	return list.MaxPlayer()
+}
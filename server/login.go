@@ -13,6 +13,7 @@ import (
 	pk "github.com/Tnze/go-mc/net/packet"
 	"github.com/Tnze/go-mc/offline"
 	"github.com/Tnze/go-mc/server/auth"
+	"github.com/Tnze/go-mc/yggdrasil"
 	"github.com/Tnze/go-mc/yggdrasil/user"
 
 	"github.com/google/uuid"
@@ -46,6 +47,10 @@ type MojangLoginHandler struct {
 	// EnforceSecureProfile enforce to check the player's profile public key
 	EnforceSecureProfile bool
 
+	// Endpoints is the Yggdrasil implementation used to authenticate players in OnlineMode.
+	// If nil, yggdrasil.Mojang is used.
+	Endpoints *yggdrasil.Endpoints
+
 	// Threshold set the smallest size of raw network payload to compress.
 	// Set to 0 to compress all packets. Set to -1 to disable compression.
 	Threshold int
@@ -118,7 +123,11 @@ func (d *MojangLoginHandler) AcceptLogin(conn *net.Conn, protocol int32) (name s
 		}
 		var resp *auth.Resp
 		// Auth, Encrypt
-		resp, err = auth.Encrypt(conn, name, serverKey)
+		endpoints := d.Endpoints
+		if endpoints == nil {
+			endpoints = yggdrasil.Mojang
+		}
+		resp, err = auth.EncryptWith(conn, name, serverKey, endpoints)
 		if err != nil {
 			return
 		}
@@ -13,7 +13,7 @@
 //	|--------------------+-----------------+---------------+-----------------------|
 //	|    LoginHandler    |         ListPingHandler         |        Others..       |
 //	|--------------------|------------+----+---------------|-----------------------+
-//	| MojangLoginHandler |  PingInfo  |     PlayerList     |  [go-mc/server], etc. |
+//	| MojangLoginHandler |  PingInfo  |     PlayerList     |   [gameplay], etc.    |
 //	+--------------------+------------+--------------------+-----------------------+
 //
 // Gate, which is used to respond to the client login request, provide login verification,
@@ -23,8 +23,10 @@
 // (that is, after the LoginSuccess package is sent),
 // and is responsible for functions including player status, chunk management, keep alive, chat, etc.
 //
-// The implement of Gameplay is provided at [go-mc/server]. You can also write your version.
+// A minimal implement of Gameplay serving a world from disk is provided at [gameplay].
+// A more complete one can be found at [go-mc/server]. You can also write your version.
 //
+// [gameplay]: https://pkg.go.dev/github.com/Tnze/go-mc/server/gameplay
 // [go-mc/server]: https://github.com/go-mc/server
 package server
 
@@ -38,8 +40,8 @@ import (
 )
 
 const (
-	ProtocolName    = "1.20.2"
-	ProtocolVersion = 764
+	ProtocolName    = "1.21"
+	ProtocolVersion = 767
 )
 
 type Server struct {
@@ -90,7 +92,7 @@ func (s *Server) AcceptConn(conn *net.Conn) {
 			}
 			return
 		}
-		s.AcceptConfig(conn)
+		info, err := s.AcceptConfig(conn)
 		if err != nil {
 			var configErr ConfigFailErr
 			if errors.As(err, &configErr) {
@@ -104,6 +106,6 @@ func (s *Server) AcceptConn(conn *net.Conn) {
 			}
 			return
 		}
-		s.AcceptPlayer(name, id, profilePubKey, properties, protocol, conn)
+		s.AcceptPlayer(name, id, profilePubKey, properties, protocol, info, conn)
 	}
 }
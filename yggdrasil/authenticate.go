@@ -6,6 +6,15 @@ import (
 
 type Access struct {
 	ar authResp
+	// authServer is the auth server that issued the tokens, AuthURL is used if empty
+	authServer string
+}
+
+func (a *Access) server() string {
+	if a.authServer == "" {
+		return AuthURL
+	}
+	return a.authServer
 }
 
 // agent is a struct of auth
@@ -64,6 +73,15 @@ type Profile struct {
 
 // Authenticate authenticates a user using their password.
 func Authenticate(user, password string) (*Access, error) {
+	return authenticate(AuthURL, user, password)
+}
+
+// Authenticate authenticates a user using their password, by the auth server of e.
+func (e *Endpoints) Authenticate(user, password string) (*Access, error) {
+	return authenticate(e.AuthServer, user, password)
+}
+
+func authenticate(authServer, user, password string) (*Access, error) {
 	// Payload
 	pl := authPayload{
 		Agent: defaultAgent,
@@ -78,7 +96,7 @@ func Authenticate(user, password string) (*Access, error) {
 	var ar authResp
 
 	// Request
-	err := post("/authenticate", pl, &ar)
+	err := post(authServer, "/authenticate", pl, &ar)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +105,7 @@ func Authenticate(user, password string) (*Access, error) {
 		return nil, *ar.Error
 	}
 
-	return &Access{ar}, nil
+	return &Access{ar: ar, authServer: authServer}, nil
 }
 
 func (a *Access) SelectedProfile() (ID, Name string) {
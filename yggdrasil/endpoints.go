@@ -0,0 +1,139 @@
+package yggdrasil
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	_ "embed"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Endpoints is the set of URLs of a Yggdrasil implementation,
+// the official one of Mojang or a third-party one such as authlib-injector or Blessing Skin.
+// All URLs have no ending slash.
+type Endpoints struct {
+	// AuthServer handles the username/password login, e.g. https://authserver.mojang.com
+	AuthServer string
+	// SessionServer is used when joining servers, e.g. https://sessionserver.mojang.com
+	SessionServer string
+	// Services provides the player certificates (chat signing keys), e.g. https://api.minecraftservices.com
+	Services string
+	// PublicKey is used to verify the signatures of the profile properties (the textures)
+	// returned by the SessionServer. The signatures are not verified if it's nil.
+	PublicKey *rsa.PublicKey
+	// CertificateKeys are used to verify the player certificates issued by the Services.
+	// Call FetchServicesKeys to get them.
+	CertificateKeys []*rsa.PublicKey
+}
+
+//go:embed yggdrasil_session_pubkey.der
+var mojangPublicKey []byte
+
+// Mojang is the official Yggdrasil endpoints.
+var Mojang = &Endpoints{
+	AuthServer:    "https://authserver.mojang.com",
+	SessionServer: "https://sessionserver.mojang.com",
+	Services:      "https://api.minecraftservices.com",
+	PublicKey:     must(x509.ParsePKIXPublicKey(mojangPublicKey)).(*rsa.PublicKey),
+}
+
+// AuthlibInjector fetches the metadata of an authlib-injector compatible API, and returns its endpoints.
+// The apiRoot is the API root URL, e.g. https://example.com/api/yggdrasil.
+// The API Location Indication (ALI) header is followed if the server responds with it.
+func AuthlibInjector(apiRoot string) (*Endpoints, error) {
+	resp, err := client.Get(apiRoot)
+	if err != nil {
+		return nil, fmt.Errorf("request fail: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ali := resp.Header.Get("X-Authlib-Injector-API-Location"); ali != "" {
+		base, err := url.Parse(apiRoot)
+		if err != nil {
+			return nil, err
+		}
+		loc, err := base.Parse(ali)
+		if err != nil {
+			return nil, fmt.Errorf("parse api location fail: %v", err)
+		}
+		if loc.String() != apiRoot {
+			return AuthlibInjector(loc.String())
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch metadata fail: %s", resp.Status)
+	}
+
+	var meta struct {
+		SignaturePublicKey string `json:"signaturePublickey"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("parse metadata fail: %v", err)
+	}
+
+	apiRoot = strings.TrimSuffix(apiRoot, "/")
+	e := &Endpoints{
+		AuthServer:    apiRoot + "/authserver",
+		SessionServer: apiRoot + "/sessionserver",
+		Services:      apiRoot + "/minecraftservices",
+	}
+	if meta.SignaturePublicKey != "" {
+		block, _ := pem.Decode([]byte(meta.SignaturePublicKey))
+		if block == nil {
+			return nil, errors.New("pem decode error: no data is found")
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse signature public key fail: %v", err)
+		}
+		var ok bool
+		if e.PublicKey, ok = key.(*rsa.PublicKey); !ok {
+			return nil, errors.New("expect RSA public key")
+		}
+	}
+	return e, nil
+}
+
+// FetchServicesKeys gets the CertificateKeys from the Services.
+func (e *Endpoints) FetchServicesKeys() error {
+	resp, err := client.Get(e.Services + "/publickeys")
+	if err != nil {
+		return fmt.Errorf("request fail: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch public keys fail: %s", resp.Status)
+	}
+
+	var keys struct {
+		PlayerCertificateKeys []struct {
+			PublicKey []byte `json:"publicKey"` // base64 encoded DER
+		} `json:"playerCertificateKeys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return fmt.Errorf("parse public keys fail: %v", err)
+	}
+	e.CertificateKeys = e.CertificateKeys[:0]
+	for _, k := range keys.PlayerCertificateKeys {
+		key, err := x509.ParsePKIXPublicKey(k.PublicKey)
+		if err != nil {
+			return fmt.Errorf("parse public key fail: %v", err)
+		}
+		if key, ok := key.(*rsa.PublicKey); ok {
+			e.CertificateKeys = append(e.CertificateKeys, key)
+		}
+	}
+	return nil
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -26,7 +26,7 @@ func (a *Access) Refresh(profile *Profile) error {
 		*Error
 	}{authResp: &a.ar}
 
-	err := post("/refresh", pl, &resp)
+	err := post(a.server(), "/refresh", pl, &resp)
 	if err != nil {
 		return fmt.Errorf("post fail: %v", err)
 	}
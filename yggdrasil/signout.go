@@ -7,12 +7,21 @@ import (
 
 // SignOut invalidates accessTokens using an account's username and password.
 func SignOut(user, password string) error {
+	return signOut(AuthURL, user, password)
+}
+
+// SignOut invalidates accessTokens using an account's username and password, by the auth server of e.
+func (e *Endpoints) SignOut(user, password string) error {
+	return signOut(e.AuthServer, user, password)
+}
+
+func signOut(authServer, user, password string) error {
 	pl := proof{
 		UserName: user,
 		Password: password,
 	}
 
-	resp, err := rawPost("/signout", pl)
+	resp, err := rawPost(authServer, "/signout", pl)
 	if err != nil {
 		return fmt.Errorf("request fail: %v", err)
 	}
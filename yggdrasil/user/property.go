@@ -1,6 +1,11 @@
 package user
 
 import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
 	"io"
 
 	"github.com/google/uuid"
@@ -34,6 +39,19 @@ func (p *Property) ReadFrom(r io.Reader) (n int64, err error) {
 	return
 }
 
+// Verify checks the signature of the property, which is signed by the session server's key.
+func (p Property) Verify(key *rsa.PublicKey) error {
+	if p.Signature == "" {
+		return errors.New("property " + p.Name + " is not signed")
+	}
+	signature, err := base64.StdEncoding.DecodeString(p.Signature)
+	if err != nil {
+		return err
+	}
+	hash := sha1.Sum([]byte(p.Value))
+	return rsa.VerifyPKCS1v15(key, crypto.SHA1, hash[:], signature)
+}
+
 // Texture includes player's skin and cape
 type Texture struct {
 	TimeStamp int64     `json:"timestamp"`
@@ -3,12 +3,17 @@ package user
 import (
 	"crypto"
 	"crypto/rsa"
+	"crypto/sha1"
 	"crypto/x509"
+	"encoding/binary"
 	"errors"
 	"io"
 	"time"
 
+	"github.com/google/uuid"
+
 	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/yggdrasil"
 )
 
 type PublicKey struct {
@@ -58,6 +63,9 @@ func (p *PublicKey) ReadFrom(r io.Reader) (n int64, err error) {
 	return n, nil
 }
 
+// Verify checks the legacy (1.19) signature of the key.
+//
+// Deprecated: The signature is checked with the version 2 format since 1.19.1, use VerifyFor instead.
 func (p *PublicKey) Verify() bool {
 	if p.ExpiresAt.Before(time.Now()) {
 		return false
@@ -69,6 +77,29 @@ func (p *PublicKey) Verify() bool {
 	return VerifySignature(encoded, p.Signature)
 }
 
+// VerifyFor checks if the key is a valid player certificate of the profile id,
+// signed by one of the CertificateKeys of e.
+func (p *PublicKey) VerifyFor(id uuid.UUID, e *yggdrasil.Endpoints) error {
+	if p.ExpiresAt.Before(time.Now()) {
+		return errors.New("public key expired")
+	}
+	encoded, err := x509.MarshalPKIXPublicKey(p.PubKey)
+	if err != nil {
+		return err
+	}
+	hash := sha1.New()
+	hash.Write(id[:])
+	_ = binary.Write(hash, binary.BigEndian, p.ExpiresAt.UnixMilli())
+	hash.Write(encoded)
+	sum := hash.Sum(nil)
+	for _, key := range e.CertificateKeys {
+		if rsa.VerifyPKCS1v15(key, crypto.SHA1, sum, p.Signature) == nil {
+			return nil
+		}
+	}
+	return errors.New("invalid public key signature")
+}
+
 func (p *PublicKey) VerifyMessage(hash, signature []byte) error {
 	return rsa.VerifyPKCS1v15(p.PubKey, crypto.SHA256, hash, signature)
 }
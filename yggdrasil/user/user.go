@@ -11,9 +11,11 @@ import (
 	"time"
 
 	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/yggdrasil"
 )
 
-var ServicesURL = "https://api.minecraftservices.com"
+// ServicesURL is the Minecraft services used by GetOrFetchKeyPair.
+var ServicesURL = yggdrasil.Mojang.Services
 
 var client = http.DefaultClient
 
@@ -45,17 +47,22 @@ func (k KeyPairResp) WriteTo(w io.Writer) (int64, error) {
 }
 
 func GetOrFetchKeyPair(accessToken string) (KeyPairResp, error) {
-	return fetchKeyPair(accessToken) // TODO: cache
+	return fetchKeyPair(ServicesURL, accessToken) // TODO: cache
 }
 
-func fetchKeyPair(accessToken string) (KeyPairResp, error) {
+// GetOrFetchKeyPairFrom is like GetOrFetchKeyPair, but the key pair is fetched from the services of e.
+func GetOrFetchKeyPairFrom(e *yggdrasil.Endpoints, accessToken string) (KeyPairResp, error) {
+	return fetchKeyPair(e.Services, accessToken)
+}
+
+func fetchKeyPair(servicesURL, accessToken string) (KeyPairResp, error) {
 	var keyPairResp KeyPairResp
-	err := post("/player/certificates", accessToken, &keyPairResp)
+	err := post(servicesURL, "/player/certificates", accessToken, &keyPairResp)
 	return keyPairResp, err
 }
 
-func post(endpoint string, accessToken string, resp any) error {
-	rowResp, err := rawPost(endpoint, accessToken)
+func post(base, endpoint string, accessToken string, resp any) error {
+	rowResp, err := rawPost(base, endpoint, accessToken)
 	if err != nil {
 		return fmt.Errorf("request fail: %v", err)
 	}
@@ -68,10 +75,10 @@ func post(endpoint string, accessToken string, resp any) error {
 	return nil
 }
 
-func rawPost(endpoint string, accessToken string) (*http.Response, error) {
+func rawPost(base, endpoint string, accessToken string) (*http.Response, error) {
 	PostRequest, err := http.NewRequest(
 		http.MethodPost,
-		ServicesURL+endpoint, nil)
+		base+endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("make request error: %v", err)
 	}
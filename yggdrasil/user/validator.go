@@ -4,15 +4,13 @@ import (
 	"crypto"
 	"crypto/rsa"
 	"crypto/sha256"
-	"crypto/x509"
-	_ "embed"
 	"encoding/base64"
 	"io"
+
+	"github.com/Tnze/go-mc/yggdrasil"
 )
 
-//go:embed yggdrasil_session_pubkey.der
-var pubKeyBytes []byte
-var pubKey = unwrap(x509.ParsePKIXPublicKey(pubKeyBytes)).(*rsa.PublicKey)
+var pubKey = yggdrasil.Mojang.PublicKey
 
 // VerifySignature has the same functional as
 // net.minecraft.world.entity.player.ProfilePublicKey.Data#validateSignature
@@ -24,12 +24,14 @@ func (e Error) Error() string {
 	return e.Err + ": " + e.ErrMsg + ", " + e.Cause
 }
 
+// AuthURL is the auth server used by Authenticate and SignOut.
+// Use the methods of Endpoints for other Yggdrasil implementations.
 var AuthURL = "https://authserver.mojang.com"
 
 var client = http.DefaultClient
 
-func post(endpoint string, payload any, resp any) error {
-	rowResp, err := rawPost(endpoint, payload)
+func post(base, endpoint string, payload any, resp any) error {
+	rowResp, err := rawPost(base, endpoint, payload)
 	if err != nil {
 		return fmt.Errorf("request fail: %v", err)
 	}
@@ -43,7 +45,7 @@ func post(endpoint string, payload any, resp any) error {
 	return nil
 }
 
-func rawPost(endpoint string, payload any) (*http.Response, error) {
+func rawPost(base, endpoint string, payload any) (*http.Response, error) {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("marshal payload fail: %v", err)
@@ -51,7 +53,7 @@ func rawPost(endpoint string, payload any) (*http.Response, error) {
 
 	PostRequest, err := http.NewRequest(
 		http.MethodPost,
-		AuthURL+endpoint,
+		base+endpoint,
 		bytes.NewReader(data))
 	if err != nil {
 		return nil, fmt.Errorf("make request error: %v", err)
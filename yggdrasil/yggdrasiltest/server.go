@@ -0,0 +1,313 @@
+// Package yggdrasiltest provides an in-process fake Yggdrasil server for testing online-mode logins.
+//
+// The [Server] implements the authlib-injector API layout, including the auth server,
+// the session server and the player certificates of the Minecraft services.
+// Everything is signed by a key generated locally, which is included in [Server.Endpoints].
+// So the code using [yggdrasil.Endpoints] can be tested without accessing the Internet.
+package yggdrasiltest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Tnze/go-mc/yggdrasil"
+	"github.com/Tnze/go-mc/yggdrasil/user"
+)
+
+// Server is a fake Yggdrasil server. Create it by NewServer, and Close it after testing.
+type Server struct {
+	*httptest.Server
+	// Key signs the profile properties and the player certificates.
+	Key *rsa.PrivateKey
+	// CertificateLifetime is the duration the player certificates are valid for.
+	CertificateLifetime time.Duration
+
+	mu      sync.Mutex
+	players map[uuid.UUID]*Player
+	tokens  map[string]*Player // access token → player
+	joins   map[string]*Player // server id → player
+}
+
+// Player is an account of the Server.
+type Player struct {
+	Name        string
+	ID          uuid.UUID
+	Password    string
+	AccessToken string
+	// SkinURL and CapeURL are the textures in the profile. Leave empty for the default skin.
+	SkinURL, CapeURL string
+}
+
+// NewServer starts a new Server.
+func NewServer() *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{
+		Key:                 key,
+		CertificateLifetime: 48 * time.Hour,
+		players:             make(map[uuid.UUID]*Player),
+		tokens:              make(map[string]*Player),
+		joins:               make(map[string]*Player),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleMetadata)
+	mux.HandleFunc("POST /authserver/authenticate", s.handleAuthenticate)
+	mux.HandleFunc("POST /authserver/validate", s.handleValidate)
+	mux.HandleFunc("POST /sessionserver/session/minecraft/join", s.handleJoin)
+	mux.HandleFunc("GET /sessionserver/session/minecraft/hasJoined", s.handleHasJoined)
+	mux.HandleFunc("GET /sessionserver/session/minecraft/profile/{uuid}", s.handleProfile)
+	mux.HandleFunc("POST /minecraftservices/player/certificates", s.handleCertificates)
+	mux.HandleFunc("GET /minecraftservices/publickeys", s.handlePublicKeys)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Endpoints returns the endpoints of the Server, with its public key.
+func (s *Server) Endpoints() *yggdrasil.Endpoints {
+	return &yggdrasil.Endpoints{
+		AuthServer:      s.URL + "/authserver",
+		SessionServer:   s.URL + "/sessionserver",
+		Services:        s.URL + "/minecraftservices",
+		PublicKey:       &s.Key.PublicKey,
+		CertificateKeys: []*rsa.PublicKey{&s.Key.PublicKey},
+	}
+}
+
+// AddPlayer creates a player with a random UUID and access token.
+// The password is the same as the name.
+func (s *Server) AddPlayer(name string) *Player {
+	p := &Player{
+		Name:        name,
+		ID:          uuid.New(),
+		Password:    name,
+		AccessToken: strings.ReplaceAll(uuid.NewString(), "-", ""),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.players[p.ID] = p
+	s.tokens[p.AccessToken] = p
+	return p
+}
+
+func (s *Server) sign(data []byte) string {
+	hash := sha1.Sum(data)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.Key, crypto.SHA1, hash[:])
+	if err != nil {
+		panic(err)
+	}
+	return base64.StdEncoding.EncodeToString(signature)
+}
+
+func (s *Server) profile(p *Player, signed bool) any {
+	var texture user.Texture
+	texture.TimeStamp = time.Now().UnixMilli()
+	texture.ID = p.ID
+	texture.Name = p.Name
+	texture.Textures.SKIN.URL = p.SkinURL
+	texture.Textures.CAPE.URL = p.CapeURL
+	data, err := json.Marshal(texture)
+	if err != nil {
+		panic(err)
+	}
+	textures := user.Property{Name: "textures", Value: base64.StdEncoding.EncodeToString(data)}
+	if signed {
+		textures.Signature = s.sign([]byte(textures.Value))
+	}
+	return map[string]any{
+		"id":         strings.ReplaceAll(p.ID.String(), "-", ""),
+		"name":       p.Name,
+		"properties": []any{textures},
+	}
+}
+
+func (s *Server) handleMetadata(w http.ResponseWriter, _ *http.Request) {
+	der, err := x509.MarshalPKIXPublicKey(&s.Key.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+	reply(w, http.StatusOK, map[string]any{
+		"meta":               map[string]string{"serverName": "go-mc yggdrasiltest"},
+		"skinDomains":        []string{},
+		"signaturePublickey": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	})
+}
+
+func (s *Server) handleAuthenticate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username    string `json:"username"`
+		Password    string `json:"password"`
+		ClientToken string `json:"clientToken"`
+	}
+	if json.NewDecoder(r.Body).Decode(&req) != nil {
+		replyError(w, http.StatusBadRequest, "IllegalArgumentException", "bad request")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.players {
+		if p.Name == req.Username && p.Password == req.Password {
+			profile := map[string]string{"id": strings.ReplaceAll(p.ID.String(), "-", ""), "name": p.Name}
+			reply(w, http.StatusOK, map[string]any{
+				"accessToken":       p.AccessToken,
+				"clientToken":       req.ClientToken,
+				"availableProfiles": []any{profile},
+				"selectedProfile":   profile,
+			})
+			return
+		}
+	}
+	replyError(w, http.StatusForbidden, "ForbiddenOperationException", "Invalid credentials. Invalid username or password.")
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AccessToken string `json:"accessToken"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	s.mu.Lock()
+	_, ok := s.tokens[req.AccessToken]
+	s.mu.Unlock()
+	if !ok {
+		replyError(w, http.StatusForbidden, "ForbiddenOperationException", "Invalid token.")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleJoin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AccessToken string `json:"accessToken"`
+		// The vanilla client sends the UUID, and some clients send the profile object.
+		SelectedProfile json.RawMessage `json:"selectedProfile"`
+		ServerID        string          `json:"serverId"`
+	}
+	if json.NewDecoder(r.Body).Decode(&req) != nil {
+		replyError(w, http.StatusBadRequest, "IllegalArgumentException", "bad request")
+		return
+	}
+	var profile struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(req.SelectedProfile, &profile.ID) != nil {
+		_ = json.Unmarshal(req.SelectedProfile, &profile)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.tokens[req.AccessToken]
+	if id, err := uuid.Parse(profile.ID); !ok || err != nil || id != p.ID {
+		replyError(w, http.StatusForbidden, "ForbiddenOperationException", "Invalid token.")
+		return
+	}
+	s.joins[req.ServerID] = p
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleHasJoined(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	p, ok := s.joins[query.Get("serverId")]
+	if ok && p.Name == query.Get("username") {
+		delete(s.joins, query.Get("serverId"))
+	}
+	s.mu.Unlock()
+	if !ok || p.Name != query.Get("username") {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	reply(w, http.StatusOK, s.profile(p, true))
+}
+
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("uuid"))
+	if err != nil {
+		replyError(w, http.StatusBadRequest, "IllegalArgumentException", "Invalid UUID string")
+		return
+	}
+	s.mu.Lock()
+	p, ok := s.players[id]
+	s.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	reply(w, http.StatusOK, s.profile(p, r.URL.Query().Get("unsigned") == "false"))
+}
+
+func (s *Server) handleCertificates(w http.ResponseWriter, r *http.Request) {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	p, ok := s.tokens[token]
+	s.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	privateKey, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+	now := time.Now()
+	expiresAt := now.Add(s.CertificateLifetime).Truncate(time.Millisecond)
+
+	// the payload of the signature version 2
+	payload := make([]byte, 0, 16+8+len(publicKey))
+	payload = append(payload, p.ID[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(expiresAt.UnixMilli()))
+	payload = append(payload, publicKey...)
+
+	var resp user.KeyPairResp
+	resp.KeyPair.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: privateKey}))
+	resp.KeyPair.PublicKey = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: publicKey}))
+	resp.PublicKeySignatureV2 = s.sign(payload)
+	resp.PublicKeySignature = resp.PublicKeySignatureV2
+	resp.ExpiresAt = expiresAt
+	resp.RefreshedAfter = now.Add(s.CertificateLifetime / 2)
+	reply(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlePublicKeys(w http.ResponseWriter, _ *http.Request) {
+	der, err := x509.MarshalPKIXPublicKey(&s.Key.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+	keys := []map[string][]byte{{"publicKey": der}}
+	reply(w, http.StatusOK, map[string]any{
+		"profilePropertyKeys":   keys,
+		"playerCertificateKeys": keys,
+	})
+}
+
+func reply(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func replyError(w http.ResponseWriter, status int, err, msg string) {
+	reply(w, status, map[string]string{"error": err, "errorMessage": msg})
+}
@@ -0,0 +1,155 @@
+package yggdrasiltest_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Tnze/go-mc/bot"
+	"github.com/Tnze/go-mc/chat"
+	"github.com/Tnze/go-mc/data/packetid"
+	mcnet "github.com/Tnze/go-mc/net"
+	pk "github.com/Tnze/go-mc/net/packet"
+	"github.com/Tnze/go-mc/server"
+	"github.com/Tnze/go-mc/yggdrasil"
+	"github.com/Tnze/go-mc/yggdrasil/user"
+	"github.com/Tnze/go-mc/yggdrasil/yggdrasiltest"
+)
+
+func TestOnlineLogin(t *testing.T) {
+	ygg := yggdrasiltest.NewServer()
+	defer ygg.Close()
+	steve := ygg.AddPlayer("Steve")
+
+	l, err := mcnet.ListenMC("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	type result struct {
+		name       string
+		properties []user.Property
+		err        error
+	}
+	results := make(chan result, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer conn.Close()
+		var handshake pk.Packet
+		if err := conn.ReadPacket(&handshake); err != nil {
+			results <- result{err: err}
+			return
+		}
+		handler := server.MojangLoginHandler{OnlineMode: true, Threshold: -1, Endpoints: ygg.Endpoints()}
+		name, _, _, properties, err := handler.AcceptLogin(&conn, bot.ProtocolVersion)
+		results <- result{name, properties, err}
+		// end the test in the configuration phase
+		_ = conn.WritePacket(pk.Marshal(packetid.ClientboundConfigDisconnect, chat.Text("bye")))
+	}()
+
+	c := bot.NewClient()
+	c.Auth = bot.Auth{Name: steve.Name, UUID: steve.ID.String(), AsTk: steve.AccessToken, Endpoints: ygg.Endpoints()}
+	err = c.JoinServer(l.Addr().String())
+	var disconnect bot.DisconnectErr
+	if !errors.As(err, &disconnect) {
+		t.Errorf("join server: %v", err)
+	}
+
+	res := <-results
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if res.name != steve.Name || len(res.properties) != 1 {
+		t.Errorf("unexpected login result: %s %v", res.name, res.properties)
+	}
+}
+
+func TestNotJoined(t *testing.T) {
+	ygg := yggdrasiltest.NewServer()
+	defer ygg.Close()
+	steve := ygg.AddPlayer("Steve")
+
+	c := bot.NewClient()
+	c.Auth = bot.Auth{Name: steve.Name, UUID: steve.ID.String(), AsTk: "invalid", Endpoints: ygg.Endpoints()}
+
+	l, err := mcnet.ListenMC("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var handshake pk.Packet
+		_ = conn.ReadPacket(&handshake)
+		handler := server.MojangLoginHandler{OnlineMode: true, Threshold: -1, Endpoints: ygg.Endpoints()}
+		_, _, _, _, _ = handler.AcceptLogin(&conn, bot.ProtocolVersion)
+	}()
+	if err := c.JoinServer(l.Addr().String()); err == nil {
+		t.Error("joined with an invalid access token")
+	}
+}
+
+func TestCertificates(t *testing.T) {
+	ygg := yggdrasiltest.NewServer()
+	defer ygg.Close()
+	steve := ygg.AddPlayer("Steve")
+
+	e := ygg.Endpoints()
+	e.CertificateKeys = nil
+	if err := e.FetchServicesKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPair, err := user.GetOrFetchKeyPairFrom(e, steve.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := keyPair.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var pubKey user.PublicKey
+	if _, err := pubKey.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := pubKey.VerifyFor(steve.ID, e); err != nil {
+		t.Errorf("verify certificate: %v", err)
+	}
+	alex := ygg.AddPlayer("Alex")
+	if err := pubKey.VerifyFor(alex.ID, e); err == nil {
+		t.Error("certificate of Steve is valid for Alex")
+	}
+}
+
+func TestAuthlibInjector(t *testing.T) {
+	ygg := yggdrasiltest.NewServer()
+	defer ygg.Close()
+	steve := ygg.AddPlayer("Steve")
+
+	e, err := yggdrasil.AuthlibInjector(ygg.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.PublicKey.Equal(&ygg.Key.PublicKey) {
+		t.Error("public key doesn't match")
+	}
+	access, err := e.Authenticate(steve.Name, steve.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, name := access.SelectedProfile(); name != steve.Name {
+		t.Errorf("selected profile: got %s, want %s", name, steve.Name)
+	}
+	if ok, err := access.Validate(); err != nil || !ok {
+		t.Errorf("validate token: %v %v", ok, err)
+	}
+}